@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/horriblename/mathcha/cas"
+	ed "github.com/horriblename/mathcha/editor"
+	"github.com/horriblename/mathcha/semantic"
+)
+
+// symbolic actions on the selection (or the whole formula) of the focused
+// editor, each inserting its result as a new line below it. Actions marked
+// with needsVar wait for the letter of a variable first
+type action struct {
+	prompt   string
+	needsVar bool
+	run      func(src string, n semantic.Node, x string) (semantic.Node, string, error)
+}
+
+var actions = map[rune]action{
+	'd': {"differentiate with respect to", true, differentiate},
+	'c': {"collect the powers of", true, collect},
+	'e': {"", false, func(src string, n semantic.Node, x string) (semantic.Node, string, error) {
+		return cas.Expand(n), equals(n), nil
+	}},
+	's': {"", false, func(src string, n semantic.Node, x string) (semantic.Node, string, error) {
+		return cas.Simplify(n), equals(n), nil
+	}},
+}
+
+// returns the result and what the new line starts with
+func differentiate(src string, n semantic.Node, x string) (semantic.Node, string, error) {
+	d, err := cas.Differentiate(n, x)
+	if equals(n) == "" {
+		return d, "", err
+	}
+	return d, fmt.Sprintf(`\frac{d}{d%s}\left(%s\right) =`, x, src), err
+}
+
+func collect(src string, n semantic.Node, x string) (semantic.Node, string, error) {
+	return cas.Collect(n, x), equals(n), nil
+}
+
+// the result of a relation is a relation itself, e.g. y = x^2 + x
+func equals(n semantic.Node) string {
+	if _, ok := n.(*semantic.Relation); ok {
+		return ""
+	}
+	return "="
+}
+
+// starts the action bound to alt+key, returns false if there is none
+func (m *model) startAction(key rune) bool {
+	a, ok := actions[key]
+	if !ok {
+		return false
+	}
+	m.message = ""
+	if a.needsVar {
+		m.pending = key
+		m.message = a.prompt + " (press a letter, esc cancels)"
+		return true
+	}
+	m.runAction(a, "")
+	return true
+}
+
+// handles the key pressed while an action waits for a variable
+func (m *model) pendingKey(msg tea.KeyMsg) {
+	a := actions[m.pending]
+	m.pending = 0
+	m.message = ""
+	if msg.Type != tea.KeyRunes || len(msg.Runes) != 1 || !unicode.IsLetter(msg.Runes[0]) {
+		return
+	}
+	m.runAction(a, string(msg.Runes[0]))
+}
+
+func (m *model) runAction(a action, x string) {
+	src := m.editors[m.focus].SelectionSource()
+	n, err := semantic.Parse(src)
+	if err != nil {
+		m.message = err.Error()
+		return
+	}
+	result, prefix, err := a.run(src, n, x)
+	if err != nil {
+		m.message = err.Error()
+		return
+	}
+	line := m.editorConfig.LatexCfg.ProduceLatex(semantic.ToLatex(result))
+	if prefix != "" {
+		line = prefix + " " + line
+	}
+	m.insertLine(line)
+}
+
+// adds an editor line below the focused one and focuses it
+func (m *model) insertLine(formula string) {
+	editor := ed.NewWithConfig(*m.editorConfig, formula)
+	m.editors[m.focus].SetFocus(false)
+	m.focus++
+	m.editors = append(m.editors, ed.Editor{})
+	copy(m.editors[m.focus+1:], m.editors[m.focus:])
+	m.editors[m.focus] = *editor
+	m.editors[m.focus].SetFocus(true)
+}
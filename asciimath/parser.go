@@ -0,0 +1,304 @@
+package asciimath
+
+import (
+	"github.com/horriblename/mathcha/latex"
+)
+
+// Parse reads an AsciiMath formula. It never fails: unbalanced brackets are
+// closed at the end of the input and stray closing brackets are kept as
+// plain symbols.
+//
+// The grammar is the one of asciimath.org:
+//
+//	E ::= I E | I/I E
+//	I ::= S | S_S | S^S | S_S^S
+//	S ::= symbol | number | letter | "text" | (E) | unary S | frac S S
+//
+// where the brackets around S are dropped when it is a script, a fraction's
+// numerator/denominator or a function argument
+func Parse(src string) *latex.UnboundCompExpr {
+	p := parser{toks: tokenize(src)}
+	tree := &latex.UnboundCompExpr{}
+	for {
+		tree.Elts = append(tree.Elts, p.parseExpr()...)
+		if p.peek().kind == tokEOF {
+			break
+		}
+		// stray closing bracket
+		tree.Elts = append(tree.Elts, &latex.SimpleOpLit{Source: p.next().lit})
+	}
+	return tree
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token {
+	if p.pos >= len(p.toks) {
+		return token{kind: tokEOF}
+	}
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.peek()
+	if p.pos < len(p.toks) {
+		p.pos++
+	}
+	return tok
+}
+
+// A parsed S or I. If it is a bracketed expression, inner holds its content
+// without the brackets
+type simple struct {
+	exprs     []latex.Expr
+	inner     []latex.Expr
+	bracketed bool
+}
+
+// the argument of a script, fraction or function, without brackets
+func (s simple) group() *latex.CompositeExpr {
+	if s.bracketed {
+		return &latex.CompositeExpr{Elts: s.inner}
+	}
+	if len(s.exprs) == 1 {
+		if c, ok := s.exprs[0].(*latex.CompositeExpr); ok {
+			return c
+		}
+	}
+	return &latex.CompositeExpr{Elts: s.exprs}
+}
+
+// E, up to a closing bracket or the end of the input
+func (p *parser) parseExpr() []latex.Expr {
+	var exprs []latex.Expr
+	for kind := p.peek().kind; kind != tokEOF && kind != tokRight; kind = p.peek().kind {
+		num := p.parseIntermediate()
+		if p.peek().kind == tokDiv {
+			p.next()
+			den := p.parseIntermediate()
+			exprs = append(exprs, &latex.Cmd2ArgExpr{Type: latex.CMD_frac, Arg1: num.group(), Arg2: den.group()})
+			continue
+		}
+		exprs = append(exprs, num.exprs...)
+	}
+	return exprs
+}
+
+// I: S with optional scripts. In the AST, scripts are siblings following their
+// base
+func (p *parser) parseIntermediate() simple {
+	base := p.parseSimple()
+	for _, order := range [][2]tokenKind{{tokSub, tokSup}, {tokSup, tokSub}} {
+		if p.peek().kind != order[0] {
+			continue
+		}
+		result := simple{exprs: base.exprs}
+		for _, kind := range order {
+			if p.peek().kind != kind {
+				break
+			}
+			p.next()
+			cmd := latex.CMD_subscript
+			if kind == tokSup {
+				cmd = latex.CMD_superscript
+			}
+			result.exprs = append(result.exprs, &latex.Cmd1ArgExpr{Type: cmd, Arg1: p.parseSimple().group()})
+		}
+		return result
+	}
+	return base
+}
+
+func (p *parser) parseSimple() simple {
+	tok := p.next()
+	switch tok.kind {
+	case tokEOF:
+		return simple{}
+	case tokLeft:
+		return p.parseBracketed(tok.lit)
+	case tokUnary:
+		arg := p.parseSimple()
+		switch tok.lit {
+		case "sqrt":
+			return simple{exprs: []latex.Expr{&latex.Cmd1ArgExpr{Type: latex.CMD_sqrt, Arg1: arg.group()}}}
+		case "bar", "overline":
+			return simple{exprs: []latex.Expr{&latex.Cmd1ArgExpr{Type: latex.CMD_overline, Arg1: arg.group()}}}
+		case "ul", "underline":
+			return simple{exprs: []latex.Expr{&latex.Cmd1ArgExpr{Type: latex.CMD_underline, Arg1: arg.group()}}}
+		default: // abs
+			exprs := []latex.Expr{&latex.SimpleOpLit{Source: "|"}}
+			exprs = append(exprs, arg.group().Elts...)
+			return simple{exprs: append(exprs, &latex.SimpleOpLit{Source: "|"})}
+		}
+	case tokBinary:
+		num, den := p.parseSimple(), p.parseSimple()
+		return simple{exprs: []latex.Expr{&latex.Cmd2ArgExpr{Type: latex.CMD_frac, Arg1: num.group(), Arg2: den.group()}}}
+	case tokText:
+		runes := []latex.Expr{}
+		for _, r := range tok.lit {
+			runes = append(runes, latex.RawRuneLit(r))
+		}
+		return simple{exprs: []latex.Expr{&latex.TextContainer{
+			Type: latex.CMD_text,
+			Text: &latex.TextStringWrapper{Runes: runes},
+		}}}
+	case tokSymbol:
+		source := Symbols[tok.lit]
+		return simple{exprs: []latex.Expr{&latex.SimpleCmdLit{Source: source, Type: latex.MatchLatexCmd(source)}}}
+	case tokNumber:
+		// like the LaTeX parser, one NumberLit per digit
+		exprs := make([]latex.Expr, 0, len(tok.lit))
+		for _, r := range tok.lit {
+			if r == '.' {
+				exprs = append(exprs, &latex.SimpleOpLit{Source: "."})
+			} else {
+				exprs = append(exprs, &latex.NumberLit{Source: string(r)})
+			}
+		}
+		return simple{exprs: exprs}
+	case tokLetter:
+		return simple{exprs: []latex.Expr{&latex.VarLit{Source: tok.lit}}}
+	case tokSub, tokSup:
+		// script without a base
+		cmd := latex.CMD_subscript
+		if tok.kind == tokSup {
+			cmd = latex.CMD_superscript
+		}
+		return simple{exprs: []latex.Expr{&latex.Cmd1ArgExpr{Type: cmd, Arg1: p.parseSimple().group()}}}
+	}
+	// tokOther, tokDiv without a numerator and stray closing brackets
+	return simple{exprs: []latex.Expr{&latex.SimpleOpLit{Source: tok.lit}}}
+}
+
+var closingBracket = map[string]string{"(": ")", "[": "]", "{": "}", "(:": ":)", "{:": ":}"}
+
+// the part of a bracketed expression after the opening bracket
+func (p *parser) parseBracketed(left string) simple {
+	inner := p.parseExpr()
+	right := closingBracket[left]
+	if p.peek().kind == tokRight {
+		right = p.next().lit
+	}
+
+	if matrix := parseMatrix(left, inner); matrix != nil {
+		return simple{exprs: []latex.Expr{matrix}, inner: []latex.Expr{matrix}, bracketed: true}
+	}
+
+	result := simple{inner: inner, bracketed: true}
+	switch left {
+	case "{:", "(:":
+		result.exprs = []latex.Expr{&latex.CompositeExpr{Elts: inner}}
+		return result
+	}
+	result.exprs = append(result.exprs, bracketLit(left))
+	result.exprs = append(result.exprs, inner...)
+	if right != ":)" && right != ":}" {
+		result.exprs = append(result.exprs, bracketLit(right))
+	}
+	return result
+}
+
+// brackets are plain symbols, just like in LaTeX sources without \left and
+// \right
+func bracketLit(bracket string) latex.Expr {
+	switch bracket {
+	case "{", "}":
+		return &latex.SimpleCmdLit{Source: `\` + bracket}
+	}
+	return &latex.SimpleOpLit{Source: bracket}
+}
+
+// Matrices are written as brackets of (at least two) bracketed, comma
+// separated rows, e.g. [[a,b],[c,d]]. Rows of "{: ... :}" make an aligned
+// environment instead, e.g. {:(x,=1),(y,=2):}.
+// Returns nil if inner is not a list of rows
+func parseMatrix(left string, inner []latex.Expr) latex.Expr {
+	rows := splitCommas(inner)
+	if len(rows) < 2 {
+		return nil
+	}
+
+	var rowLeft string
+	var cells [][]*latex.UnboundCompExpr
+	for _, row := range rows {
+		// a row is a bracket, its content and the closing bracket
+		if len(row) < 2 {
+			return nil
+		}
+		open, ok := row[0].(*latex.SimpleOpLit)
+		if !ok || (open.Source != "(" && open.Source != "[") || (rowLeft != "" && open.Source != rowLeft) {
+			return nil
+		}
+		rowLeft = open.Source
+		end, ok := row[len(row)-1].(*latex.SimpleOpLit)
+		if !ok || end.Source != closingBracket[rowLeft] || !enclosed(row) {
+			return nil
+		}
+
+		rowCells := splitCommas(row[1 : len(row)-1])
+		if len(cells) > 0 && len(rowCells) != len(cells[0]) {
+			return nil
+		}
+		cellExprs := make([]*latex.UnboundCompExpr, len(rowCells))
+		for i, cell := range rowCells {
+			cellExprs[i] = &latex.UnboundCompExpr{Elts: cell}
+		}
+		cells = append(cells, cellExprs)
+	}
+
+	switch left {
+	case "{:":
+		return &latex.EnvExpr{Name: latex.ENV_align, Elts: cells}
+	case "(":
+		return &latex.ParenCompExpr{Left: "(", Right: ")", Elts: []latex.Expr{
+			&latex.EnvExpr{Name: latex.ENV_matrix, Elts: cells},
+		}}
+	}
+	return &latex.EnvExpr{Name: latex.ENV_matrix, Elts: cells}
+}
+
+// splits exprs at the commas between brackets
+func splitCommas(exprs []latex.Expr) [][]latex.Expr {
+	parts := [][]latex.Expr{{}}
+	depth := 0
+	for _, expr := range exprs {
+		if op, ok := expr.(*latex.SimpleOpLit); ok {
+			switch op.Source {
+			case "(", "[":
+				depth++
+			case ")", "]":
+				depth--
+			case ",":
+				if depth == 0 {
+					parts = append(parts, []latex.Expr{})
+					continue
+				}
+			}
+		}
+		parts[len(parts)-1] = append(parts[len(parts)-1], expr)
+	}
+	return parts
+}
+
+// whether the first bracket of exprs is closed by the last expression, as
+// opposed to e.g. "(a)+(b)"
+func enclosed(exprs []latex.Expr) bool {
+	depth := 0
+	for i, expr := range exprs {
+		if op, ok := expr.(*latex.SimpleOpLit); ok {
+			switch op.Source {
+			case "(", "[":
+				depth++
+			case ")", "]":
+				depth--
+			}
+		}
+		if depth == 0 && i != len(exprs)-1 {
+			return false
+		}
+	}
+	return depth == 0
+}
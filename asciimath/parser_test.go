@@ -0,0 +1,131 @@
+package asciimath
+
+import (
+	"testing"
+
+	"github.com/horriblename/mathcha/latex"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		input  string
+		expect string // LaTeX source with the same AST
+	}{
+		{
+			desc:   "literals",
+			input:  "x+12=y",
+			expect: `x+12=y`,
+		},
+		{
+			desc:   "symbols",
+			input:  "alpha xx beta <= oo",
+			expect: `\alpha\times\beta\le\infty`,
+		},
+		{
+			desc:   "longest symbol wins",
+			input:  "a <=> b",
+			expect: `a\iff b`,
+		},
+		{
+			desc:   "functions",
+			input:  "sin x",
+			expect: `\sin x`,
+		},
+		{
+			desc:   "big operator with scripts",
+			input:  "sum_(i=1)^n i^2",
+			expect: `\sum_{i=1}^{n}i^{2}`,
+		},
+		{
+			desc:   "superscript before subscript",
+			input:  "x^2_i",
+			expect: `x^{2}_{i}`,
+		},
+		{
+			desc:   "fraction drops brackets",
+			input:  "(x+1)/(y-1)",
+			expect: `\frac{x+1}{y-1}`,
+		},
+		{
+			desc:   "fraction of scripts",
+			input:  "x^2/2",
+			expect: `\frac{x^{2}}{2}`,
+		},
+		{
+			desc:   "sqrt over 2",
+			input:  "sqrt(x)/2",
+			expect: `\frac{\sqrt{x}}{2}`,
+		},
+		{
+			desc:   "frac function",
+			input:  "frac a b",
+			expect: `\frac{a}{b}`,
+		},
+		{
+			desc:   "brackets are kept outside of arguments",
+			input:  "f(x)",
+			expect: `f(x)`,
+		},
+		{
+			desc:   "braces",
+			input:  "{a}",
+			expect: `\{a\}`,
+		},
+		{
+			desc:   "invisible brackets",
+			input:  "{:a:}",
+			expect: `{a}`,
+		},
+		{
+			desc:   "decimals",
+			input:  "3.14",
+			expect: `3.14`,
+		},
+		{
+			desc:   "text",
+			input:  `x "if" text(else)`,
+			expect: `x\text{if}\text{else}`,
+		},
+		{
+			desc:   "decorations",
+			input:  "bar x + ul(y)",
+			expect: `\overline{x}+\underline{y}`,
+		},
+		{
+			desc:   "absolute value",
+			input:  "abs(x)",
+			expect: `|x|`,
+		},
+		{
+			desc:   "matrix",
+			input:  "[[a,b],[c,d]]",
+			expect: `\begin{matrix}a&b\\c&d\end{matrix}`,
+		},
+		{
+			desc:   "parenthesized matrix",
+			input:  "((1,0),(0,1))",
+			expect: `\left(\begin{matrix}1&0\\0&1\end{matrix}\right)`,
+		},
+		{
+			desc:   "aligned",
+			input:  "{:(x,=1),(y,=2):}",
+			expect: `\begin{align}x&=1\\y&=2\end{align}`,
+		},
+		{
+			desc:   "unbalanced brackets",
+			input:  "(a))",
+			expect: `(a))`,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got := Parse(tC.input)
+			want := latex.Parse(tC.expect)
+			if !got.DeepEqWith(want, latex.DeepEqCfg{SkipPos: true}) {
+				t.Errorf("AST mismatch\ngot:\n%s\nwant:\n%s", got.VisualizeTree(), want.VisualizeTree())
+			}
+		})
+	}
+}
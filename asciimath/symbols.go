@@ -0,0 +1,204 @@
+package asciimath
+
+import (
+	"sort"
+
+	"github.com/horriblename/mathcha/latex"
+)
+
+// Symbols maps AsciiMath symbol names to the LaTeX command they stand for
+var Symbols = map[string]string{
+	// greek letters
+	"alpha":      `\alpha`,
+	"beta":       `\beta`,
+	"gamma":      `\gamma`,
+	"Gamma":      `\Gamma`,
+	"delta":      `\delta`,
+	"Delta":      `\Delta`,
+	"epsilon":    `\epsilon`,
+	"varepsilon": `\varepsilon`,
+	"zeta":       `\zeta`,
+	"eta":        `\eta`,
+	"theta":      `\theta`,
+	"Theta":      `\Theta`,
+	"vartheta":   `\vartheta`,
+	"iota":       `\iota`,
+	"kappa":      `\kappa`,
+	"lambda":     `\lambda`,
+	"Lambda":     `\Lambda`,
+	"mu":         `\mu`,
+	"nu":         `\nu`,
+	"xi":         `\xi`,
+	"Xi":         `\Xi`,
+	"pi":         `\pi`,
+	"Pi":         `\Pi`,
+	"rho":        `\rho`,
+	"sigma":      `\sigma`,
+	"Sigma":      `\Sigma`,
+	"tau":        `\tau`,
+	"upsilon":    `\upsilon`,
+	"phi":        `\phi`,
+	"Phi":        `\Phi`,
+	"varphi":     `\varphi`,
+	"chi":        `\chi`,
+	"psi":        `\psi`,
+	"Psi":        `\Psi`,
+	"omega":      `\omega`,
+	"Omega":      `\Omega`,
+
+	// operators
+	"*":        `\cdot`,
+	"**":       `\ast`,
+	"***":      `\star`,
+	"//":       `\slash`,
+	`\\`:       `\backslash`,
+	"setminus": `\setminus`,
+	"xx":       `\times`,
+	"|><":      `\ltimes`,
+	"><|":      `\rtimes`,
+	"-:":       `\div`,
+	"divide":   `\div`,
+	"@":        `\circ`,
+	"o+":       `\oplus`,
+	"ox":       `\otimes`,
+	"o.":       `\odot`,
+	"sum":      `\sum`,
+	"prod":     `\prod`,
+	"^^":       `\land`,
+	"^^^":      `\bigwedge`,
+	"vv":       `\lor`,
+	"vvv":      `\bigvee`,
+	"nn":       `\cap`,
+	"nnn":      `\bigcap`,
+	"uu":       `\cup`,
+	"uuu":      `\bigcup`,
+	"+-":       `\pm`,
+	"-+":       `\mp`,
+
+	// relations
+	"!=":   `\ne`,
+	"<=":   `\le`,
+	">=":   `\ge`,
+	"lt":   `\lt`,
+	"gt":   `\gt`,
+	"-<":   `\prec`,
+	">-":   `\succ`,
+	"-<=":  `\preceq`,
+	">-=":  `\succeq`,
+	"in":   `\in`,
+	"!in":  `\notin`,
+	"sub":  `\subset`,
+	"sup":  `\supset`,
+	"sube": `\subseteq`,
+	"supe": `\supseteq`,
+	"-=":   `\equiv`,
+	"~=":   `\cong`,
+	"~~":   `\approx`,
+	"~":    `\sim`,
+	"prop": `\propto`,
+
+	// logic
+	"not": `\neg`,
+	"=>":  `\Rightarrow`,
+	"<=>": `\iff`,
+	"AA":  `\forall`,
+	"EE":  `\exists`,
+	"_|_": `\bot`,
+	"TT":  `\top`,
+	"|--": `\vdash`,
+	"|==": `\models`,
+
+	// miscellaneous
+	"int":     `\int`,
+	"oint":    `\oint`,
+	"del":     `\partial`,
+	"grad":    `\nabla`,
+	"O/":      `\emptyset`,
+	"oo":      `\infty`,
+	"aleph":   `\alef`,
+	"/_":      `\angle`,
+	":.":      `\therefore`,
+	":'":      `\because`,
+	"...":     `\ldots`,
+	"ldots":   `\ldots`,
+	"cdots":   `\cdots`,
+	"vdots":   `\vdots`,
+	"ddots":   `\ddots`,
+	"quad":    `\quad`,
+	"qquad":   `\qquad`,
+	"diamond": `\diamond`,
+	"|__":     `\lfloor`,
+	"__|":     `\rfloor`,
+	"|~":      `\lceil`,
+	"~|":      `\rceil`,
+	"CC":      `\C`,
+	"NN":      `\N`,
+	"QQ":      `\Q`,
+	"RR":      `\R`,
+	"ZZ":      `\Z`,
+
+	// arrows
+	"uarr": `\uarr`,
+	"darr": `\darr`,
+	"rarr": `\to`,
+	"->":   `\to`,
+	"|->":  `\mapsto`,
+	"larr": `\gets`,
+	"<-":   `\gets`,
+	"harr": `\harr`,
+	"rArr": `\rArr`,
+	"lArr": `\lArr`,
+	"hArr": `\hArr`,
+
+	// functions
+	"sin":    `\sin`,
+	"cos":    `\cos`,
+	"tan":    `\tan`,
+	"sec":    `\sec`,
+	"csc":    `\csc`,
+	"cot":    `\cot`,
+	"sinh":   `\sinh`,
+	"cosh":   `\cosh`,
+	"tanh":   `\tanh`,
+	"sech":   `\sech`,
+	"csch":   `\csch`,
+	"coth":   `\coth`,
+	"arcsin": `\arcsin`,
+	"arccos": `\arccos`,
+	"arctan": `\arctan`,
+	"log":    `\log`,
+	"ln":     `\ln`,
+	"det":    `\det`,
+	"dim":    `\dim`,
+	"mod":    `\mod`,
+	"gcd":    `\gcd`,
+	"lcm":    `\lcm`,
+	"min":    `\min`,
+	"max":    `\max`,
+	"lim":    `\lim`,
+}
+
+var symbolNames map[latex.LatexCmd]string
+
+// SymbolName returns the AsciiMath name of a LaTeX command, if there is one.
+// When there are several, the shortest one is picked, e.g. "->" over "rarr"
+func SymbolName(cmd latex.LatexCmd) (string, bool) {
+	if symbolNames == nil {
+		names := make([]string, 0, len(Symbols))
+		for name := range Symbols {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if len(names[i]) != len(names[j]) {
+				return len(names[i]) > len(names[j])
+			}
+			return names[i] > names[j]
+		})
+		symbolNames = make(map[latex.LatexCmd]string, len(Symbols))
+		for _, name := range names {
+			symbolNames[latex.MatchLatexCmd(Symbols[name])] = name
+		}
+	}
+	name, ok := symbolNames[cmd]
+	return name, ok
+}
@@ -0,0 +1,154 @@
+// Package asciimath reads and writes AsciiMath (http://asciimath.org), e.g.
+// "sum_(i=1)^n i^2" or "sqrt(x)/2". Formulas are parsed into the same AST
+// as LaTeX sources, see the latex package
+package asciimath
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF    tokenKind = iota
+	tokSymbol           // an entry of Symbols
+	tokNumber           // digits, optionally with a decimal point
+	tokLetter           // a single letter
+	tokText             // the content of "..." or text(...)
+	tokLeft             // opening bracket
+	tokRight            // closing bracket
+	tokUnary            // function taking one argument, e.g. sqrt
+	tokBinary           // function taking two arguments, i.e. frac
+	tokDiv              // "/"
+	tokSub              // "_"
+	tokSup              // "^"
+	tokOther            // any other single character, e.g. "+", "=", ","
+)
+
+type token struct {
+	kind tokenKind
+	lit  string
+}
+
+var brackets = map[string]tokenKind{
+	"(": tokLeft, "[": tokLeft, "{": tokLeft, "(:": tokLeft, "{:": tokLeft,
+	")": tokRight, "]": tokRight, "}": tokRight, ":)": tokRight, ":}": tokRight,
+}
+
+var functions = map[string]tokenKind{
+	"sqrt":      tokUnary,
+	"bar":       tokUnary,
+	"overline":  tokUnary,
+	"ul":        tokUnary,
+	"underline": tokUnary,
+	"abs":       tokUnary,
+	"frac":      tokBinary,
+}
+
+// every multi-character token, longest first, so that e.g. "<=>" is
+// preferred over "<="
+var names []string
+
+func init() {
+	for name := range Symbols {
+		names = append(names, name)
+	}
+	for name := range brackets {
+		names = append(names, name)
+	}
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+}
+
+func tokenize(src string) []token {
+	var toks []token
+	for len(src) > 0 {
+		r, size := utf8.DecodeRuneInString(src)
+		if unicode.IsSpace(r) {
+			src = src[size:]
+			continue
+		}
+
+		switch {
+		case r == '"':
+			text, rest := src[1:], ""
+			if end := strings.IndexByte(text, '"'); end >= 0 {
+				text, rest = text[:end], text[end+1:]
+			}
+			toks = append(toks, token{tokText, text})
+			src = rest
+			continue
+		case strings.HasPrefix(src, "text("):
+			text, rest := src[len("text("):], ""
+			if end := strings.IndexByte(text, ')'); end >= 0 {
+				text, rest = text[:end], text[end+1:]
+			}
+			toks = append(toks, token{tokText, text})
+			src = rest
+			continue
+		case unicode.IsDigit(r):
+			end := strings.IndexFunc(src, func(r rune) bool { return !unicode.IsDigit(r) })
+			if end == -1 {
+				end = len(src)
+			}
+			// a decimal point only belongs to the number if digits follow
+			if end+1 < len(src) && src[end] == '.' && unicode.IsDigit(rune(src[end+1])) {
+				frac := strings.IndexFunc(src[end+1:], func(r rune) bool { return !unicode.IsDigit(r) })
+				if frac == -1 {
+					end = len(src)
+				} else {
+					end += 1 + frac
+				}
+			}
+			toks = append(toks, token{tokNumber, src[:end]})
+			src = src[end:]
+			continue
+		}
+
+		if name := matchName(src); name != "" {
+			kind := tokSymbol
+			if k, ok := brackets[name]; ok {
+				kind = k
+			} else if k, ok := functions[name]; ok {
+				kind = k
+			}
+			toks = append(toks, token{kind, name})
+			src = src[len(name):]
+			continue
+		}
+
+		kind := tokOther
+		switch {
+		case unicode.IsLetter(r):
+			kind = tokLetter
+		case r == '/':
+			kind = tokDiv
+		case r == '_':
+			kind = tokSub
+		case r == '^':
+			kind = tokSup
+		}
+		toks = append(toks, token{kind, src[:size]})
+		src = src[size:]
+	}
+	return toks
+}
+
+func matchName(src string) string {
+	for _, name := range names {
+		if strings.HasPrefix(src, name) {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,515 @@
+// Package cas is a small computer algebra system on top of package semantic:
+// it differentiates, expands and collects polynomials and simplifies
+// fractions, e.g. \frac{x^2-1}{x+1} to x-1. Numbers are exact rationals as
+// far as possible
+package cas
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/horriblename/mathcha/semantic"
+)
+
+// Expand multiplies out products and integer powers of sums and collects like
+// terms, e.g. (x+1)^2 - x to x^2 + x + 1. Other subtrees, e.g. \sin(a+b), are
+// kept as they are, with their arguments expanded. Both sides of relations
+// are expanded
+func Expand(n semantic.Node) semantic.Node {
+	if rel, ok := n.(*semantic.Relation); ok {
+		return mapChildren(rel, Expand)
+	}
+	return toPoly(n).node()
+}
+
+// Collect expands a formula (see Expand) and groups the terms by the powers
+// of a variable, e.g. ax + bx + c to (a + b)x + c
+func Collect(n semantic.Node, x string) semantic.Node {
+	if rel, ok := n.(*semantic.Relation); ok {
+		return mapChildren(rel, func(n semantic.Node) semantic.Node { return Collect(n, x) })
+	}
+
+	key := (&semantic.Var{Name: x}).String()
+	p := toPoly(n)
+	// the coefficients of the powers of x
+	coefs := map[int]poly{}
+	var powers []int
+	for _, m := range p {
+		k := m.exp(key)
+		if coefs[k] == nil {
+			coefs[k] = poly{}
+			powers = append(powers, k)
+		}
+		coef := &monomial{coef: m.coef}
+		for _, f := range m.factors {
+			if f.key != key {
+				coef.factors = append(coef.factors, f)
+			}
+		}
+		coefs[k].addMonomial(coef)
+	}
+	sortDescending(powers)
+
+	var s sum
+	for _, k := range powers {
+		coef := coefs[k]
+		xk := monomial{coef: big.NewRat(1, 1)}
+		if k > 0 {
+			xk.factors = []factor{{key, &semantic.Var{Name: x}, k}}
+		}
+		if len(coef) == 1 {
+			// a single term, written as usual
+			for _, m := range coef {
+				m := mulMonomials(m, &xk)
+				s.add(m.coef.Sign() < 0, monomialNode(new(big.Rat).Abs(m.coef), m.factors))
+			}
+			continue
+		}
+		if k == 0 {
+			s.add(false, coef.node())
+			continue
+		}
+		s.add(false, &semantic.Binary{Op: semantic.Mul, X: coef.node(), Y: monomialNode(xk.coef, xk.factors), Implicit: true})
+	}
+	return s.node()
+}
+
+func sortDescending(a []int) {
+	for i := 1; i < len(a); i++ {
+		for j := i; j > 0 && a[j] > a[j-1]; j-- {
+			a[j], a[j-1] = a[j-1], a[j]
+		}
+	}
+}
+
+// Simplify folds constants (exactly, \frac{1}{2} + \frac{1}{3} is
+// \frac{5}{6}), removes neutral elements like x + 0 or x^1, and cancels common
+// factors of fractions (see SimplifyFraction). Unlike Expand, it keeps the
+// shape of a formula otherwise
+func Simplify(n semantic.Node) semantic.Node {
+	n = mapChildren(n, Simplify)
+	switch n := n.(type) {
+	case *semantic.Neg:
+		switch x := n.X.(type) {
+		case *semantic.Number:
+			return &semantic.Number{Value: -x.Value}
+		case *semantic.Neg:
+			return x.X
+		}
+	case *semantic.Binary:
+		return simplifyBinary(n)
+	case *semantic.Frac:
+		return SimplifyFraction(n.Num, n.Den)
+	case *semantic.Power:
+		return simplifyPower(n)
+	case *semantic.Apply:
+		return simplifyApply(n)
+	}
+	return n
+}
+
+// whether n is the number v
+func is(n semantic.Node, v float64) bool {
+	num, ok := n.(*semantic.Number)
+	return ok && num.Value == v
+}
+
+func same(a, b semantic.Node) bool {
+	return a.String() == b.String()
+}
+
+func simplifyBinary(n *semantic.Binary) semantic.Node {
+	x, y := n.X, n.Y
+	if a, ok := x.(*semantic.Number); ok {
+		if b, ok := y.(*semantic.Number); ok {
+			return fold(n.Op, a, b)
+		}
+	}
+	switch n.Op {
+	case semantic.Add:
+		switch {
+		case is(x, 0):
+			return y
+		case is(y, 0):
+			return x
+		case same(x, y):
+			return Simplify(&semantic.Binary{Op: semantic.Mul, X: &semantic.Number{Value: 2}, Y: x, Implicit: true})
+		}
+		if neg, ok := y.(*semantic.Neg); ok {
+			return simplifyBinary(&semantic.Binary{Op: semantic.Sub, X: x, Y: neg.X})
+		}
+		if num, ok := y.(*semantic.Number); ok && num.Value < 0 {
+			return &semantic.Binary{Op: semantic.Sub, X: x, Y: &semantic.Number{Value: -num.Value}}
+		}
+	case semantic.Sub:
+		switch {
+		case is(y, 0):
+			return x
+		case is(x, 0):
+			return Simplify(&semantic.Neg{X: y})
+		case same(x, y):
+			return &semantic.Number{}
+		}
+		if neg, ok := y.(*semantic.Neg); ok {
+			return simplifyBinary(&semantic.Binary{Op: semantic.Add, X: x, Y: neg.X})
+		}
+		if num, ok := y.(*semantic.Number); ok && num.Value < 0 {
+			return &semantic.Binary{Op: semantic.Add, X: x, Y: &semantic.Number{Value: -num.Value}}
+		}
+	case semantic.Mul:
+		switch {
+		case is(x, 0) || is(y, 0):
+			return &semantic.Number{}
+		case is(x, 1):
+			return y
+		case is(y, 1):
+			return x
+		case is(x, -1):
+			return Simplify(&semantic.Neg{X: y})
+		case same(x, y):
+			return &semantic.Power{Base: x, Exp: &semantic.Number{Value: 2}}
+		}
+		if neg, ok := x.(*semantic.Neg); ok {
+			return Simplify(&semantic.Neg{X: simplifyBinary(&semantic.Binary{Op: semantic.Mul, X: neg.X, Y: y, Implicit: n.Implicit})})
+		}
+		if neg, ok := y.(*semantic.Neg); ok {
+			return Simplify(&semantic.Neg{X: simplifyBinary(&semantic.Binary{Op: semantic.Mul, X: x, Y: neg.X, Implicit: n.Implicit})})
+		}
+		if _, ok := y.(*semantic.Number); ok {
+			// 2x rather than x \cdot 2
+			return simplifyBinary(&semantic.Binary{Op: semantic.Mul, X: y, Y: x, Implicit: true})
+		}
+		// 2 \cdot 3x is 6x
+		if a, ok := x.(*semantic.Number); ok {
+			if inner, ok := y.(*semantic.Binary); ok && inner.Op == semantic.Mul {
+				if b, ok := inner.X.(*semantic.Number); ok {
+					return simplifyBinary(&semantic.Binary{Op: semantic.Mul, X: fold(semantic.Mul, a, b), Y: inner.Y, Implicit: true})
+				}
+			}
+		}
+		if merged, ok := mergeFactors(n); ok {
+			return merged
+		}
+	case semantic.Div:
+		return SimplifyFraction(x, y)
+	}
+	return n
+}
+
+// merges the factors of a product with the same base into a power, e.g. 2xx
+// is 2x^2 and x^2 y x is x^3 y. The factors are kept in the order of their
+// first appearance, numbers go first. Returns false if no bases repeat
+func mergeFactors(n *semantic.Binary) (semantic.Node, bool) {
+	type power struct {
+		base semantic.Node
+		exp  semantic.Node
+	}
+	var coef semantic.Node = &semantic.Number{Value: 1}
+	var powers []*power
+	index := map[string]*power{}
+	merged := false
+	var collect func(n semantic.Node)
+	collect = func(n semantic.Node) {
+		if b, ok := n.(*semantic.Binary); ok && b.Op == semantic.Mul {
+			collect(b.X)
+			collect(b.Y)
+			return
+		}
+		if num, ok := n.(*semantic.Number); ok {
+			coef = fold(semantic.Mul, coef.(*semantic.Number), num)
+			return
+		}
+		p := &power{base: n, exp: &semantic.Number{Value: 1}}
+		if pow, ok := n.(*semantic.Power); ok {
+			if _, ok := pow.Exp.(*semantic.Number); ok {
+				p = &power{base: pow.Base, exp: pow.Exp}
+			}
+		}
+		if prev, ok := index[p.base.String()]; ok {
+			prev.exp = fold(semantic.Add, prev.exp.(*semantic.Number), p.exp.(*semantic.Number))
+			merged = true
+			return
+		}
+		index[p.base.String()] = p
+		powers = append(powers, p)
+	}
+	collect(n)
+	if _, ok := coef.(*semantic.Number); !ok || !merged {
+		return n, false
+	}
+
+	var product semantic.Node
+	if !is(coef, 1) {
+		product = coef
+	}
+	for _, p := range powers {
+		factor := simplifyPower(&semantic.Power{Base: p.base, Exp: p.exp})
+		if is(factor, 1) {
+			continue
+		}
+		if product == nil {
+			product = factor
+		} else {
+			product = &semantic.Binary{Op: semantic.Mul, X: product, Y: factor, Implicit: true}
+		}
+	}
+	if product == nil {
+		return &semantic.Number{Value: 1}, true
+	}
+	return product, true
+}
+
+// computes a \op b exactly; quotients that are not integers become fractions
+// unless a or b is written with a decimal point
+func fold(op semantic.BinaryOp, a, b *semantic.Number) semantic.Node {
+	x, y := rat(a), rat(b)
+	r := new(big.Rat)
+	switch op {
+	case semantic.Add:
+		r.Add(x, y)
+	case semantic.Sub:
+		r.Sub(x, y)
+	case semantic.Mul:
+		r.Mul(x, y)
+	case semantic.Div:
+		if y.Sign() == 0 {
+			return &semantic.Binary{Op: op, X: a, Y: b}
+		}
+		r.Quo(x, y)
+	}
+	return numberNode(r, !x.IsInt() || !y.IsInt())
+}
+
+// a rational number, written with a decimal point if decimal or as a fraction
+func numberNode(r *big.Rat, decimal bool) semantic.Node {
+	if decimal || r.IsInt() {
+		f, _ := r.Float64()
+		return &semantic.Number{Value: f}
+	}
+	return ratNode(r)
+}
+
+func simplifyPower(n *semantic.Power) semantic.Node {
+	switch {
+	case is(n.Exp, 0):
+		return &semantic.Number{Value: 1}
+	case is(n.Exp, 1):
+		return n.Base
+	case is(n.Base, 1):
+		return n.Base
+	}
+	if base, ok := n.Base.(*semantic.Number); ok {
+		if exp, ok := n.Exp.(*semantic.Number); ok && exp.Value == math.Trunc(exp.Value) && math.Abs(exp.Value) <= maxExpandPower {
+			b := rat(base)
+			if b.Sign() == 0 && exp.Value < 0 {
+				return n
+			}
+			r := big.NewRat(1, 1)
+			for i := 0; i < int(math.Abs(exp.Value)); i++ {
+				r.Mul(r, b)
+			}
+			if exp.Value < 0 {
+				r.Inv(r)
+			}
+			return numberNode(r, !b.IsInt())
+		}
+	}
+	// (x^2)^3 is x^6
+	if inner, ok := n.Base.(*semantic.Power); ok {
+		if a, ok := inner.Exp.(*semantic.Number); ok {
+			if b, ok := n.Exp.(*semantic.Number); ok {
+				return simplifyPower(&semantic.Power{Base: inner.Base, Exp: fold(semantic.Mul, a, b)})
+			}
+		}
+	}
+	return n
+}
+
+func simplifyApply(n *semantic.Apply) semantic.Node {
+	switch x := n.Arg.(type) {
+	case *semantic.Number:
+		switch n.Func {
+		case "sqrt":
+			if root := math.Sqrt(x.Value); root == math.Trunc(root) {
+				return &semantic.Number{Value: root}
+			}
+		case "abs":
+			return &semantic.Number{Value: math.Abs(x.Value)}
+		case "sin", "tan", "sinh", "tanh", "arcsin", "arctan":
+			if x.Value == 0 {
+				return x
+			}
+		case "cos", "cosh":
+			if x.Value == 0 {
+				return &semantic.Number{Value: 1}
+			}
+		case "ln", "lg", "log":
+			if x.Value == 1 {
+				return &semantic.Number{}
+			}
+		}
+	case *semantic.Var:
+		if n.Func == "ln" && x.Name == "e" {
+			return &semantic.Number{Value: 1}
+		}
+	}
+	return n
+}
+
+// SimplifyFraction cancels the common factors of a numerator and a
+// denominator: numbers, powers of atoms (e.g. \frac{x^2 y}{x} is xy) and,
+// for polynomials in a single variable, common polynomial factors, e.g.
+// \frac{x^2 - 1}{x + 1} is x - 1. The fraction is returned as it is if
+// nothing cancels
+func SimplifyFraction(num, den semantic.Node) semantic.Node {
+	if a, ok := num.(*semantic.Number); ok {
+		if b, ok := den.(*semantic.Number); ok {
+			return fold(semantic.Div, a, b)
+		}
+	}
+	unchanged := &semantic.Frac{Num: num, Den: den}
+	if is(den, 1) {
+		return num
+	}
+
+	p, d := toPoly(num), toPoly(den)
+	if len(d) == 0 || len(p) == 0 {
+		if len(p) == 0 && len(d) > 0 {
+			return &semantic.Number{}
+		}
+		return unchanged
+	}
+	cancelled := false
+
+	// exact division, e.g. \frac{x^2 - y^2}{x - y}
+	if _, ok := d.constant(); !ok {
+		if q, r := p.divide(d); len(r) == 0 {
+			return q.node()
+		}
+	}
+
+	// common polynomial factors in a single variable
+	if atoms := p.atoms(); len(atoms) == 1 && equal(atoms, d.atoms()) {
+		if g := gcdUnivariate(p, d); g.leading().degree() > 0 {
+			p, _ = p.divide(g)
+			d, _ = d.divide(g)
+			cancelled = true
+		}
+	}
+
+	// common powers of atoms
+	common := d.leading()
+	for _, q := range []poly{p, d} {
+		for _, m := range q {
+			common = gcdMonomials(common, m)
+		}
+	}
+	if len(common.factors) > 0 {
+		p, _ = p.divide(poly{common.key(): common})
+		d, _ = d.divide(poly{common.key(): common})
+		cancelled = true
+	}
+
+	// common numbers, and integer coefficients
+	if g := content(p, d); g.Cmp(big.NewRat(1, 1)) != 0 {
+		p, d = p.scale(new(big.Rat).Inv(g)), d.scale(new(big.Rat).Inv(g))
+		cancelled = true
+	}
+	if d.leading().coef.Sign() < 0 {
+		p, d = p.neg(), d.neg()
+	}
+
+	if !cancelled {
+		return unchanged
+	}
+	if c, ok := d.constant(); ok && c.Cmp(big.NewRat(1, 1)) == 0 {
+		return p.node()
+	}
+	return &semantic.Frac{Num: p.node(), Den: d.node()}
+}
+
+// the common factors of two monomials, with coefficient 1
+func gcdMonomials(a, b *monomial) *monomial {
+	g := &monomial{coef: big.NewRat(1, 1)}
+	for _, f := range a.factors {
+		if k := b.exp(f.key); k > 0 {
+			if k < f.exp {
+				f.exp = k
+			}
+			g.factors = append(g.factors, f)
+		}
+	}
+	return g
+}
+
+// the number dividing the fraction p/d to make all coefficients coprime
+// integers
+func content(p, d poly) *big.Rat {
+	lcm, gcd := big.NewInt(1), new(big.Int)
+	for _, q := range []poly{p, d} {
+		for _, m := range q {
+			den := m.coef.Denom()
+			lcm.Div(new(big.Int).Mul(lcm, den), new(big.Int).GCD(nil, nil, lcm, den))
+		}
+	}
+	for _, q := range []poly{p, d} {
+		for _, m := range q {
+			n := new(big.Int).Div(new(big.Int).Mul(m.coef.Num(), lcm), m.coef.Denom())
+			gcd.GCD(nil, nil, gcd, n.Abs(n))
+		}
+	}
+	return new(big.Rat).SetFrac(gcd, lcm)
+}
+
+// replaces the children of a node by f(child), returning a new node
+func mapChildren(n semantic.Node, f func(semantic.Node) semantic.Node) semantic.Node {
+	switch n := n.(type) {
+	case *semantic.Neg:
+		return &semantic.Neg{X: f(n.X)}
+	case *semantic.Binary:
+		return &semantic.Binary{Op: n.Op, X: f(n.X), Y: f(n.Y), Implicit: n.Implicit}
+	case *semantic.Frac:
+		return &semantic.Frac{Num: f(n.Num), Den: f(n.Den)}
+	case *semantic.Power:
+		return &semantic.Power{Base: f(n.Base), Exp: f(n.Exp)}
+	case *semantic.Apply:
+		apply := &semantic.Apply{Func: n.Func, Arg: f(n.Arg)}
+		if n.Base != nil {
+			apply.Base = f(n.Base)
+		}
+		return apply
+	case *semantic.Binomial:
+		return &semantic.Binomial{N: f(n.N), K: f(n.K)}
+	case *semantic.BigOp:
+		return &semantic.BigOp{Op: n.Op, Var: n.Var, From: f(n.From), To: f(n.To), Body: f(n.Body)}
+	case *semantic.Matrix:
+		m := &semantic.Matrix{}
+		for _, row := range n.Rows {
+			cells := make([]semantic.Node, len(row))
+			for i, cell := range row {
+				cells[i] = f(cell)
+			}
+			m.Rows = append(m.Rows, cells)
+		}
+		return m
+	case *semantic.Relation:
+		rel := &semantic.Relation{Ops: n.Ops}
+		for _, arg := range n.Args {
+			rel.Args = append(rel.Args, f(arg))
+		}
+		return rel
+	}
+	return n
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package cas
+
+import (
+	"testing"
+
+	"github.com/horriblename/mathcha/renderer"
+	"github.com/horriblename/mathcha/semantic"
+)
+
+func format(n semantic.Node) string {
+	cfg := renderer.FormatConfig{}
+	return cfg.Format(semantic.ToLatex(n))
+}
+
+func parse(t *testing.T, src string) semantic.Node {
+	t.Helper()
+	n, err := semantic.Parse(src)
+	if err != nil {
+		t.Fatalf("%s: unexpected error %s", src, err)
+	}
+	return n
+}
+
+func TestExpand(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect string
+	}{
+		{`(x+1)^2 - x`, `x^2 + x + 1`},
+		{`(a+b)(a-b)`, `a^2 - b^2`},
+		{`(x+y)^3`, `x^3 + 3x^2y + 3xy^2 + y^3`},
+		{`\frac{x+1}{2} + \frac{x}{3}`, `\frac{5x}{6} + \frac{1}{2}`},
+		{`2(x+1)\sin(x+x)`, `2\sin(2x)x + 2\sin(2x)`},
+		{`y = (x-1)^2`, `y = x^2 - 2x + 1`},
+	}
+
+	for _, tc := range testCases {
+		if got := format(Expand(parse(t, tc.input))); got != tc.expect {
+			t.Errorf("%s: got %q, expected %q", tc.input, got, tc.expect)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect string
+	}{
+		{`ax + bx + c + x^2 + 2ax^2`, `(2a + 1)x^2 + (a + b)x + c`},
+		{`(x+a)^2`, `x^2 + 2ax + a^2`},
+	}
+
+	for _, tc := range testCases {
+		if got := format(Collect(parse(t, tc.input), "x")); got != tc.expect {
+			t.Errorf("%s: got %q, expected %q", tc.input, got, tc.expect)
+		}
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect string
+	}{
+		{`\frac{x^2-1}{x+1}`, `x - 1`},
+		{`\frac{x^2-1}{x^2+2x+1}`, `\frac{x - 1}{x + 1}`},
+		{`\frac{2x}{4}`, `\frac{x}{2}`},
+		{`\frac{x^2 y}{x}`, `xy`},
+		{`\frac{x^2-y^2}{x-y}`, `x + y`},
+		{`\frac{6x+4}{2x}`, `\frac{3x + 2}{x}`},
+		{`\frac{(x+1)^2}{x}`, `\frac{(x + 1)^2}{x}`},
+		{`0.1+0.2`, `0.3`},
+		{`x + 0`, `x`},
+		{`x \cdot 2x`, `2x^2`},
+		{`x^2 y x \sin x`, `x^3y\sin x`},
+		{`\sin x \cos x`, `\sin x \cdot \cos x`},
+	}
+
+	for _, tc := range testCases {
+		if got := format(Simplify(parse(t, tc.input))); got != tc.expect {
+			t.Errorf("%s: got %q, expected %q", tc.input, got, tc.expect)
+		}
+	}
+}
+
+func TestDifferentiate(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect string
+	}{
+		{`x^3 + 2x`, `3x^2 + 2`},
+		{`\sin(x^2)`, `\cos(x^2) \cdot 2x`},
+		{`x \sin x`, `\sin x + x\cos x`},
+		{`\frac{1}{x}`, `\frac{-1}{x^2}`},
+		{`e^{2x}`, `2e^{2x}`},
+		{`x^x`, `x^x(\ln x + 1)`},
+		{`\sqrt{x}`, `\frac{1}{2\sqrt{x}}`},
+		{`y = 3x^2 + a x`, `0 = 6x + a`},
+		{`\sum_{i=1}^{n} a_i x^2`, `\sum_{i = 1}^na_i \cdot 2x`},
+		{`\sum_{x=1}^{n} x`, `0`},
+	}
+
+	for _, tc := range testCases {
+		got, err := Differentiate(parse(t, tc.input), "x")
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", tc.input, err)
+			continue
+		}
+		if format(got) != tc.expect {
+			t.Errorf("%s: got %q, expected %q", tc.input, format(got), tc.expect)
+		}
+	}
+
+	for _, input := range []string{`x!`, `\binom{x}{2}`, `\prod_{i=1}^{n} x`} {
+		if _, err := Differentiate(parse(t, input), "x"); err == nil {
+			t.Errorf("%s: expected an error", input)
+		}
+	}
+}
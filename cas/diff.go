@@ -0,0 +1,215 @@
+package cas
+
+import (
+	"fmt"
+
+	"github.com/horriblename/mathcha/semantic"
+)
+
+// Differentiate returns the derivative of a formula with respect to the
+// variable x, simplified (see Simplify). Other variables are constants. Both
+// sides of relations are differentiated, e.g. y = x^2 becomes 0 = 2x
+func Differentiate(n semantic.Node, x string) (semantic.Node, error) {
+	d, err := derivative(n, x)
+	if err != nil {
+		return nil, err
+	}
+	return Simplify(d), nil
+}
+
+func num(v float64) semantic.Node { return &semantic.Number{Value: v} }
+
+func add(a, b semantic.Node) semantic.Node {
+	return &semantic.Binary{Op: semantic.Add, X: a, Y: b}
+}
+
+func sub(a, b semantic.Node) semantic.Node {
+	return &semantic.Binary{Op: semantic.Sub, X: a, Y: b}
+}
+
+func mul(a, b semantic.Node) semantic.Node {
+	return &semantic.Binary{Op: semantic.Mul, X: a, Y: b, Implicit: true}
+}
+
+func frac(a, b semantic.Node) semantic.Node {
+	return &semantic.Frac{Num: a, Den: b}
+}
+
+func pow(a, b semantic.Node) semantic.Node {
+	return &semantic.Power{Base: a, Exp: b}
+}
+
+func apply(f string, arg semantic.Node) semantic.Node {
+	return &semantic.Apply{Func: f, Arg: arg}
+}
+
+// whether n depends on the variable x
+func depends(n semantic.Node, x string) bool {
+	switch n := n.(type) {
+	case *semantic.Var:
+		return n.Name == x
+	case *semantic.BigOp:
+		if n.Var == x {
+			return depends(n.From, x) || depends(n.To, x)
+		}
+	}
+	found := false
+	mapChildren(n, func(child semantic.Node) semantic.Node {
+		found = found || depends(child, x)
+		return child
+	})
+	return found
+}
+
+func derivative(n semantic.Node, x string) (semantic.Node, error) {
+	if !depends(n, x) {
+		if rel, ok := n.(*semantic.Relation); ok {
+			return derivativeRelation(rel, x)
+		}
+		return num(0), nil
+	}
+
+	switch n := n.(type) {
+	case *semantic.Var:
+		return num(1), nil
+	case *semantic.Neg:
+		d, err := derivative(n.X, x)
+		return &semantic.Neg{X: d}, err
+	case *semantic.Binary:
+		du, err := derivative(n.X, x)
+		if err != nil {
+			return nil, err
+		}
+		dv, err := derivative(n.Y, x)
+		if err != nil {
+			return nil, err
+		}
+		switch n.Op {
+		case semantic.Add:
+			return add(du, dv), nil
+		case semantic.Sub:
+			return sub(du, dv), nil
+		case semantic.Mul:
+			return add(mul(du, n.Y), mul(n.X, dv)), nil
+		case semantic.Div:
+			return quotientRule(n.X, n.Y, du, dv), nil
+		}
+	case *semantic.Frac:
+		du, err := derivative(n.Num, x)
+		if err != nil {
+			return nil, err
+		}
+		dv, err := derivative(n.Den, x)
+		if err != nil {
+			return nil, err
+		}
+		return quotientRule(n.Num, n.Den, du, dv), nil
+	case *semantic.Power:
+		return derivativePower(n, x)
+	case *semantic.Apply:
+		return derivativeApply(n, x)
+	case *semantic.BigOp:
+		if n.Op == semantic.Sum && !depends(n.From, x) && !depends(n.To, x) {
+			d, err := derivative(n.Body, x)
+			if err != nil {
+				return nil, err
+			}
+			return &semantic.BigOp{Op: n.Op, Var: n.Var, From: n.From, To: n.To, Body: d}, nil
+		}
+	case *semantic.Relation:
+		return derivativeRelation(n, x)
+	}
+	return nil, fmt.Errorf("cannot differentiate %s", n)
+}
+
+func derivativeRelation(n *semantic.Relation, x string) (semantic.Node, error) {
+	rel := &semantic.Relation{Ops: n.Ops}
+	for _, arg := range n.Args {
+		d, err := derivative(arg, x)
+		if err != nil {
+			return nil, err
+		}
+		rel.Args = append(rel.Args, d)
+	}
+	return rel, nil
+}
+
+// (u/v)' = (u'v - uv')/v^2
+func quotientRule(u, v, du, dv semantic.Node) semantic.Node {
+	return frac(sub(mul(du, v), mul(u, dv)), pow(v, num(2)))
+}
+
+func derivativePower(n *semantic.Power, x string) (semantic.Node, error) {
+	du, err := derivative(n.Base, x)
+	if err != nil {
+		return nil, err
+	}
+	dv, err := derivative(n.Exp, x)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case !depends(n.Exp, x):
+		// (u^c)' = c u^{c-1} u'
+		return mul(mul(n.Exp, pow(n.Base, sub(n.Exp, num(1)))), du), nil
+	case !depends(n.Base, x):
+		// (a^v)' = a^v \ln a v'
+		return mul(mul(n, apply("ln", n.Base)), dv), nil
+	}
+	// (u^v)' = u^v (v' \ln u + v u'/u)
+	return mul(n, add(mul(dv, apply("ln", n.Base)), frac(mul(n.Exp, du), n.Base))), nil
+}
+
+// the derivatives of functions at u
+var derivatives = map[string]func(u semantic.Node) semantic.Node{
+	"sin": func(u semantic.Node) semantic.Node { return apply("cos", u) },
+	"cos": func(u semantic.Node) semantic.Node { return &semantic.Neg{X: apply("sin", u)} },
+	"tan": func(u semantic.Node) semantic.Node { return frac(num(1), pow(apply("cos", u), num(2))) },
+	"sec": func(u semantic.Node) semantic.Node { return mul(apply("sec", u), apply("tan", u)) },
+	"csc": func(u semantic.Node) semantic.Node { return &semantic.Neg{X: mul(apply("csc", u), apply("cot", u))} },
+	"cot": func(u semantic.Node) semantic.Node {
+		return &semantic.Neg{X: frac(num(1), pow(apply("sin", u), num(2)))}
+	},
+	"sinh": func(u semantic.Node) semantic.Node { return apply("cosh", u) },
+	"cosh": func(u semantic.Node) semantic.Node { return apply("sinh", u) },
+	"tanh": func(u semantic.Node) semantic.Node { return frac(num(1), pow(apply("cosh", u), num(2))) },
+	"coth": func(u semantic.Node) semantic.Node {
+		return &semantic.Neg{X: frac(num(1), pow(apply("sinh", u), num(2)))}
+	},
+	"arcsin": func(u semantic.Node) semantic.Node { return frac(num(1), apply("sqrt", sub(num(1), pow(u, num(2))))) },
+	"arccos": func(u semantic.Node) semantic.Node {
+		return &semantic.Neg{X: frac(num(1), apply("sqrt", sub(num(1), pow(u, num(2)))))}
+	},
+	"arctan":  func(u semantic.Node) semantic.Node { return frac(num(1), add(num(1), pow(u, num(2)))) },
+	"arcsinh": func(u semantic.Node) semantic.Node { return frac(num(1), apply("sqrt", add(pow(u, num(2)), num(1)))) },
+	"arccosh": func(u semantic.Node) semantic.Node { return frac(num(1), apply("sqrt", sub(pow(u, num(2)), num(1)))) },
+	"arctanh": func(u semantic.Node) semantic.Node { return frac(num(1), sub(num(1), pow(u, num(2)))) },
+	"ln":      func(u semantic.Node) semantic.Node { return frac(num(1), u) },
+	"lg":      func(u semantic.Node) semantic.Node { return frac(num(1), mul(u, apply("ln", num(10)))) },
+	"sqrt":    func(u semantic.Node) semantic.Node { return frac(num(1), mul(num(2), apply("sqrt", u))) },
+	"abs":     func(u semantic.Node) semantic.Node { return frac(u, apply("abs", u)) },
+}
+
+// the chain rule, f(u)' = f'(u) u'
+func derivativeApply(n *semantic.Apply, x string) (semantic.Node, error) {
+	du, err := derivative(n.Arg, x)
+	if err != nil {
+		return nil, err
+	}
+	if n.Func == "log" {
+		// \log_b u, \log u is the decimal logarithm
+		var base semantic.Node = num(10)
+		if n.Base != nil {
+			if depends(n.Base, x) {
+				return nil, fmt.Errorf("cannot differentiate %s", n)
+			}
+			base = n.Base
+		}
+		return mul(frac(num(1), mul(n.Arg, apply("ln", base))), du), nil
+	}
+	f, ok := derivatives[n.Func]
+	if !ok {
+		return nil, fmt.Errorf("cannot differentiate %s", n)
+	}
+	return mul(f(n.Arg), du), nil
+}
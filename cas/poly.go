@@ -0,0 +1,381 @@
+package cas
+
+import (
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/horriblename/mathcha/semantic"
+)
+
+// the largest power of a sum that is expanded, e.g. (a+b)^{32}
+const maxExpandPower = 32
+
+// a polynomial with rational coefficients over atoms: variables and any
+// subtree that is not a polynomial, e.g. \sin x or \frac{1}{x}. Monomials are
+// keyed by their factors, see monomial.key
+type poly map[string]*monomial
+
+type monomial struct {
+	coef *big.Rat
+	// sorted by key, without repetitions
+	factors []factor
+}
+
+// an atom raised to a positive power
+type factor struct {
+	key  string // the String() of the atom
+	atom semantic.Node
+	exp  int
+}
+
+func (m *monomial) key() string {
+	parts := make([]string, len(m.factors))
+	for i, f := range m.factors {
+		parts[i] = f.key + "^" + strconv.Itoa(f.exp)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (m *monomial) degree() int {
+	d := 0
+	for _, f := range m.factors {
+		d += f.exp
+	}
+	return d
+}
+
+// the exponent of an atom in the monomial, 0 if it is absent
+func (m *monomial) exp(key string) int {
+	for _, f := range m.factors {
+		if f.key == key {
+			return f.exp
+		}
+	}
+	return 0
+}
+
+func constant(r *big.Rat) poly {
+	p := poly{}
+	p.addMonomial(&monomial{coef: r})
+	return p
+}
+
+func atom(n semantic.Node) poly {
+	key := n.String()
+	return poly{key + "^1": &monomial{coef: big.NewRat(1, 1), factors: []factor{{key, n, 1}}}}
+}
+
+// adds a monomial in place, dropping it if the coefficients cancel out
+func (p poly) addMonomial(m *monomial) {
+	if m.coef.Sign() == 0 {
+		return
+	}
+	key := m.key()
+	if old, ok := p[key]; ok {
+		sum := new(big.Rat).Add(old.coef, m.coef)
+		if sum.Sign() == 0 {
+			delete(p, key)
+		} else {
+			p[key] = &monomial{coef: sum, factors: old.factors}
+		}
+		return
+	}
+	p[key] = m
+}
+
+func (p poly) add(q poly) poly {
+	sum := poly{}
+	for _, m := range p {
+		sum.addMonomial(m)
+	}
+	for _, m := range q {
+		sum.addMonomial(m)
+	}
+	return sum
+}
+
+func (p poly) scale(r *big.Rat) poly {
+	scaled := poly{}
+	for _, m := range p {
+		scaled.addMonomial(&monomial{coef: new(big.Rat).Mul(m.coef, r), factors: m.factors})
+	}
+	return scaled
+}
+
+func (p poly) neg() poly {
+	return p.scale(big.NewRat(-1, 1))
+}
+
+func (p poly) sub(q poly) poly {
+	return p.add(q.neg())
+}
+
+func mulMonomials(a, b *monomial) *monomial {
+	m := &monomial{coef: new(big.Rat).Mul(a.coef, b.coef)}
+	i, j := 0, 0
+	for i < len(a.factors) || j < len(b.factors) {
+		switch {
+		case j == len(b.factors) || i < len(a.factors) && a.factors[i].key < b.factors[j].key:
+			m.factors = append(m.factors, a.factors[i])
+			i++
+		case i == len(a.factors) || b.factors[j].key < a.factors[i].key:
+			m.factors = append(m.factors, b.factors[j])
+			j++
+		default:
+			f := a.factors[i]
+			f.exp += b.factors[j].exp
+			m.factors = append(m.factors, f)
+			i++
+			j++
+		}
+	}
+	return m
+}
+
+func (p poly) mul(q poly) poly {
+	product := poly{}
+	for _, a := range p {
+		for _, b := range q {
+			product.addMonomial(mulMonomials(a, b))
+		}
+	}
+	return product
+}
+
+func (p poly) pow(k int) poly {
+	result := constant(big.NewRat(1, 1))
+	for i := 0; i < k; i++ {
+		result = result.mul(p)
+	}
+	return result
+}
+
+// the value of a constant polynomial
+func (p poly) constant() (*big.Rat, bool) {
+	switch len(p) {
+	case 0:
+		return new(big.Rat), true
+	case 1:
+		if m, ok := p[""]; ok {
+			return m.coef, true
+		}
+	}
+	return nil, false
+}
+
+// the keys of the atoms of p, sorted
+func (p poly) atoms() []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range p {
+		for _, f := range m.factors {
+			if !seen[f.key] {
+				seen[f.key] = true
+				keys = append(keys, f.key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// the monomials in graded lexicographic order: by degree, then by the
+// exponents of the atoms in the order of their keys, e.g. x^2, xy, y^2, x, 1
+func (p poly) sorted() []*monomial {
+	atoms := p.atoms()
+	monomials := make([]*monomial, 0, len(p))
+	for _, m := range p {
+		monomials = append(monomials, m)
+	}
+	sort.Slice(monomials, func(i, j int) bool {
+		return before(monomials[i], monomials[j], atoms)
+	})
+	return monomials
+}
+
+func before(a, b *monomial, atoms []string) bool {
+	if a.degree() != b.degree() {
+		return a.degree() > b.degree()
+	}
+	for _, key := range atoms {
+		if a.exp(key) != b.exp(key) {
+			return a.exp(key) > b.exp(key)
+		}
+	}
+	return false
+}
+
+// the leading monomial, see sorted
+func (p poly) leading() *monomial {
+	return p.sorted()[0]
+}
+
+// divides a monomial by another one, if the other one's factors all divide it
+func divMonomials(a, b *monomial) (*monomial, bool) {
+	q := &monomial{coef: new(big.Rat).Quo(a.coef, b.coef)}
+	for _, f := range a.factors {
+		f.exp -= b.exp(f.key)
+		if f.exp > 0 {
+			q.factors = append(q.factors, f)
+		}
+	}
+	for _, f := range b.factors {
+		if a.exp(f.key) < f.exp {
+			return nil, false
+		}
+	}
+	return q, true
+}
+
+// divides p by d, returning the quotient and the remainder (multivariate
+// division by the leading monomial)
+func (p poly) divide(d poly) (quotient, remainder poly) {
+	quotient, remainder = poly{}, poly{}
+	lead := d.leading()
+	for len(p) > 0 {
+		lt := p.leading()
+		if q, ok := divMonomials(lt, lead); ok {
+			quotient.addMonomial(q)
+			p = p.sub(d.mul(poly{q.key(): q}))
+		} else {
+			remainder.addMonomial(lt)
+			p = p.sub(poly{lt.key(): lt})
+		}
+	}
+	return quotient, remainder
+}
+
+// the greatest common divisor of two polynomials in a single atom, monic
+func gcdUnivariate(a, b poly) poly {
+	for len(b) > 0 {
+		_, r := a.divide(b)
+		a, b = b, r
+	}
+	return a.scale(new(big.Rat).Inv(a.leading().coef))
+}
+
+func rat(n *semantic.Number) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(n.Value, 'f', -1, 64))
+	return r
+}
+
+// turns a tree into a polynomial, expanding products and integer powers of
+// sums. Anything else becomes an atom, with its arguments expanded
+func toPoly(n semantic.Node) poly {
+	switch n := n.(type) {
+	case *semantic.Number:
+		return constant(rat(n))
+	case *semantic.Neg:
+		return toPoly(n.X).neg()
+	case *semantic.Binary:
+		x, y := toPoly(n.X), toPoly(n.Y)
+		switch n.Op {
+		case semantic.Add:
+			return x.add(y)
+		case semantic.Sub:
+			return x.sub(y)
+		case semantic.Mul:
+			return x.mul(y)
+		case semantic.Div:
+			return quotient(x, y)
+		}
+	case *semantic.Frac:
+		return quotient(toPoly(n.Num), toPoly(n.Den))
+	case *semantic.Power:
+		base, exp := toPoly(n.Base), toPoly(n.Exp)
+		if k, ok := exp.constant(); ok && k.IsInt() && k.Sign() >= 0 && k.Num().Int64() <= maxExpandPower {
+			return base.pow(int(k.Num().Int64()))
+		}
+		return atom(&semantic.Power{Base: base.node(), Exp: exp.node()})
+	case *semantic.Var:
+		return atom(n)
+	}
+	return atom(mapChildren(n, Expand))
+}
+
+// x/y, expanded if y is a constant
+func quotient(x, y poly) poly {
+	if c, ok := y.constant(); ok && c.Sign() != 0 {
+		return x.scale(new(big.Rat).Inv(c))
+	}
+	return atom(&semantic.Frac{Num: x.node(), Den: y.node()})
+}
+
+// writes a polynomial as a sum of monomials, see sorted
+func (p poly) node() semantic.Node {
+	var s sum
+	for _, m := range p.sorted() {
+		s.add(m.coef.Sign() < 0, monomialNode(new(big.Rat).Abs(m.coef), m.factors))
+	}
+	return s.node()
+}
+
+// a sum of terms, each added or subtracted
+type sum struct {
+	result semantic.Node
+}
+
+func (s *sum) add(negative bool, term semantic.Node) {
+	switch {
+	case s.result == nil && negative:
+		s.result = &semantic.Neg{X: term}
+	case s.result == nil:
+		s.result = term
+	case negative:
+		s.result = &semantic.Binary{Op: semantic.Sub, X: s.result, Y: term}
+	default:
+		s.result = &semantic.Binary{Op: semantic.Add, X: s.result, Y: term}
+	}
+}
+
+func (s *sum) node() semantic.Node {
+	if s.result == nil {
+		return &semantic.Number{}
+	}
+	return s.result
+}
+
+// coef \cdot factors, with a fractional coefficient written as a fraction,
+// e.g. \frac{x}{2}
+func monomialNode(coef *big.Rat, factors []factor) semantic.Node {
+	var product semantic.Node
+	for _, f := range factors {
+		var x semantic.Node = f.atom
+		if f.exp > 1 {
+			x = &semantic.Power{Base: x, Exp: &semantic.Number{Value: float64(f.exp)}}
+		}
+		if product == nil {
+			product = x
+		} else {
+			product = &semantic.Binary{Op: semantic.Mul, X: product, Y: x, Implicit: true}
+		}
+	}
+
+	num, den := new(big.Rat).SetInt(coef.Num()), new(big.Rat).SetInt(coef.Denom())
+	switch {
+	case product == nil:
+		return ratNode(coef)
+	case num.Cmp(big.NewRat(1, 1)) != 0:
+		product = &semantic.Binary{Op: semantic.Mul, X: ratNode(num), Y: product, Implicit: true}
+	}
+	if !coef.IsInt() {
+		return &semantic.Frac{Num: product, Den: ratNode(den)}
+	}
+	return product
+}
+
+// a rational number, as a Number or a Frac of Numbers
+func ratNode(r *big.Rat) semantic.Node {
+	if r.IsInt() {
+		f, _ := r.Float64()
+		return &semantic.Number{Value: f}
+	}
+	num, _ := new(big.Rat).SetInt(r.Num()).Float64()
+	den, _ := new(big.Rat).SetInt(r.Denom()).Float64()
+	if num < 0 {
+		return &semantic.Neg{X: &semantic.Frac{Num: &semantic.Number{Value: -num}, Den: &semantic.Number{Value: den}}}
+	}
+	return &semantic.Frac{Num: &semantic.Number{Value: num}, Den: &semantic.Number{Value: den}}
+}
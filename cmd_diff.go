@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/horriblename/mathcha/diff"
+	"github.com/horriblename/mathcha/latex"
+	"github.com/horriblename/mathcha/renderer"
+)
+
+const diffUsage = `usage: mathcha diff [flags] old.tex new.tex
+
+Compares two formulas structurally and reports the inserted, deleted and
+modified parts. Exits with 0 if they are equal, 1 if they differ and 2 on
+errors.
+
+`
+
+// mathcha diff, returns the exit code
+func diffMain(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), diffUsage)
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "text", "Output format: text (both formulas drawn with the changes highlighted, then a list of changes) or json")
+	colorMode := flags.String("color", "auto", "Colorize output: auto, always or never")
+	themeName := flags.String("theme", "auto", "Color theme: dark, light, auto (dark outside of the editor) or path to a theme file")
+	ascii := flags.Bool("ascii", false, "Draw formulas with ASCII characters only")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	var trees [2]*latex.UnboundCompExpr
+	for i, file := range flags.Args() {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			logf("%s\n", err.Error())
+			return 2
+		}
+		trees[i], err = latex.ParseSafe(string(src))
+		if err != nil {
+			logf("%s: %s\n", file, err.Error())
+			return 2
+		}
+	}
+	changes := diff.Diff(trees[0], trees[1])
+	report := diff.NewReport(changes)
+
+	switch *format {
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			logf("%s\n", err.Error())
+			return 2
+		}
+		fmt.Println(string(data))
+	case "text":
+		colors, err := renderer.DetectColorProfile(*colorMode, os.Stdout)
+		if err != nil {
+			logf("%s\n", err.Error())
+			return 2
+		}
+		theme, err := renderer.LoadTheme(*themeName)
+		if err != nil {
+			logf("%s\n", err.Error())
+			return 2
+		}
+		oldMarks, newMarks := diff.Marks(changes, theme)
+		for i, marks := range []map[latex.Expr]renderer.StyleSpec{oldMarks, newMarks} {
+			r := renderer.New(colors)
+			r.Theme, r.ASCII, r.Marks = theme, *ascii, marks
+			r.Load(trees[i])
+			fmt.Printf("%s %s\n%s\n\n", [2]string{"---", "+++"}[i], flags.Arg(i), r.View())
+		}
+		if report.Equal {
+			fmt.Println("no changes")
+		}
+		for _, entry := range report.Changes {
+			fmt.Println(entry)
+		}
+	default:
+		logf("unknown format %q\n", *format)
+		return 2
+	}
+
+	if report.Equal {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/horriblename/mathcha/equiv"
+)
+
+const equivUsage = `usage: mathcha equiv [flags] formula formula
+
+Checks whether two formulas are mathematically the same, e.g.
+
+	mathcha equiv '\frac{1}{2}x' '\frac{x}{2}'
+
+They are normalized symbolically first; where that is inconclusive, both are
+evaluated at random values of their variables. Exits with 0 if they are
+equal, 1 if they are not, 2 on errors and 3 if it is unknown.
+
+`
+
+// mathcha equiv, returns the exit code
+func equivMain(args []string) int {
+	flags := flag.NewFlagSet("equiv", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), equivUsage)
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "text", "Output format: text or json")
+	samples := flags.Int("samples", 100, "Number of random points for the numeric check")
+	seed := flags.Int64("seed", 0, "Seed of the random points")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	cfg := equiv.Config{Samples: *samples, Seed: *seed}
+	report, err := cfg.CheckSource(flags.Arg(0), flags.Arg(1))
+	if err != nil {
+		logf("%s\n", err.Error())
+		return 2
+	}
+
+	switch *format {
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			logf("%s\n", err.Error())
+			return 2
+		}
+		fmt.Println(string(data))
+	case "text":
+		fmt.Println(report)
+	default:
+		logf("unknown format %q\n", *format)
+		return 2
+	}
+
+	switch report.Result {
+	case equiv.Equal:
+		return 0
+	case equiv.NotEqual:
+		return 1
+	}
+	return 3
+}
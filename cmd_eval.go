@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/horriblename/mathcha/eval"
+)
+
+const evalUsage = `usage: mathcha eval [flags] [formula]
+
+Computes the numeric value of a LaTeX formula, e.g.
+
+	mathcha eval -v x=2 '\frac{1}{2} + 3x^2'
+
+Without a formula, it is read from stdin.
+
+`
+
+// variable bindings given with -v, see eval.Bind
+type bindings eval.Vars
+
+func (b bindings) String() string {
+	names := make([]string, 0, len(b))
+	for name, value := range b {
+		names = append(names, name+"="+eval.Format(value))
+	}
+	return strings.Join(names, " ")
+}
+
+func (b bindings) Set(binding string) error {
+	return eval.Bind(eval.Vars(b), binding)
+}
+
+// mathcha eval, returns the exit code
+func evalMain(args []string) int {
+	flags := flag.NewFlagSet("eval", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), evalUsage)
+		flags.PrintDefaults()
+	}
+	vars := bindings{}
+	flags.Var(vars, "v", "Bind a variable, e.g. x=2, x_1=3 or \\alpha=\\pi/2; may be repeated")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	formula := strings.Join(flags.Args(), " ")
+	if flags.NArg() == 0 {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			logf("error reading stdin: %s\n", err.Error())
+			return 2
+		}
+		formula = string(src)
+	}
+
+	value, err := eval.EvalSource(formula, eval.Vars(vars))
+	if err != nil {
+		logf("%s\n", err.Error())
+		return 1
+	}
+	fmt.Println(eval.Format(value))
+	return 0
+}
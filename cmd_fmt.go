@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/horriblename/mathcha/latex"
+	"github.com/horriblename/mathcha/renderer"
+)
+
+const fmtUsage = `usage: mathcha fmt [flags] [file...]
+
+Pretty-prints LaTeX formulas. Without files, the formula is read from stdin
+and written to stdout.
+
+`
+
+// mathcha fmt, returns the exit code
+func fmtMain(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), fmtUsage)
+		flags.PrintDefaults()
+	}
+	braces := flags.String("braces", "minimal", "Braces around superscripts and subscripts: minimal (x^2) or always (x^{2})")
+	tight := flags.Bool("tight-relations", false, "No spaces around relations, e.g. a=b")
+	write := flags.Bool("w", false, "Write the result to the files instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg := renderer.FormatConfig{TightRelations: *tight}
+	switch *braces {
+	case "minimal":
+	case "always":
+		cfg.Braces = renderer.BRACES_ALWAYS
+	default:
+		logf("unknown brace style %q\n", *braces)
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			logf("error reading stdin: %s\n", err.Error())
+			return 2
+		}
+		tree, err := latex.ParseSafe(string(src))
+		if err != nil {
+			logf("<stdin>: %s\n", err.Error())
+			return 1
+		}
+		fmt.Println(cfg.Format(tree))
+		return 0
+	}
+
+	status := 0
+	for _, file := range flags.Args() {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			logf("%s\n", err.Error())
+			status = 2
+			continue
+		}
+		tree, err := latex.ParseSafe(string(src))
+		if err != nil {
+			logf("%s: %s\n", file, err.Error())
+			if status == 0 {
+				status = 1
+			}
+			continue
+		}
+		formatted := cfg.Format(tree) + "\n"
+		if !*write {
+			fmt.Print(formatted)
+			continue
+		}
+		if formatted == string(src) {
+			continue
+		}
+		if err := os.WriteFile(file, []byte(formatted), 0o644); err != nil {
+			logf("%s\n", err.Error())
+			status = 2
+		}
+	}
+	return status
+}
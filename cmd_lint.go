@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/horriblename/mathcha/lint"
+)
+
+const lintUsage = `usage: mathcha lint [flags] [file...]
+
+Reports common mistakes in LaTeX formulas, e.g. "sin x" instead of "\sin x".
+Without files, the formula is read from stdin. Exits with 0 if there are no
+issues, 1 if there are and 2 on errors.
+
+`
+
+// an issue along with the file it was found in, for -format json
+type fileIssue struct {
+	File string `json:"file"`
+	lint.Issue
+}
+
+// mathcha lint, returns the exit code
+func lintMain(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), lintUsage)
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "text", "Output format: text or json")
+	fix := flags.Bool("fix", false, "Fix the issues that can be fixed and rewrite the files (the fixed formula goes to stdout when reading stdin); only the remaining issues are reported")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		logf("unknown format %q\n", *format)
+		return 2
+	}
+
+	type input struct{ name, src string }
+	var inputs []input
+	if flags.NArg() == 0 {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			logf("error reading stdin: %s\n", err.Error())
+			return 2
+		}
+		inputs = append(inputs, input{"<stdin>", string(src)})
+	}
+
+	status := 0
+	for _, file := range flags.Args() {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			logf("%s\n", err.Error())
+			status = 2
+			continue
+		}
+		inputs = append(inputs, input{file, string(src)})
+	}
+
+	issues := []fileIssue{}
+	for _, in := range inputs {
+		found := lint.Lint(in.src)
+		if *fix {
+			var fixed string
+			fixed, found = lint.Fix(in.src)
+			switch {
+			case flags.NArg() == 0:
+				// Fix keeps the trailing newline of the source, if any
+				fmt.Print(fixed)
+				if !strings.HasSuffix(fixed, "\n") {
+					fmt.Println()
+				}
+			case fixed != in.src:
+				if err := os.WriteFile(in.name, []byte(fixed), 0o644); err != nil {
+					logf("%s\n", err.Error())
+					status = 2
+				}
+			}
+		}
+		for _, issue := range found {
+			issues = append(issues, fileIssue{in.name, issue})
+		}
+	}
+
+	// with the fixed formula on stdout, the issues go to stderr
+	out := os.Stdout
+	if *fix && flags.NArg() == 0 {
+		out = os.Stderr
+	}
+	if *format == "json" {
+		data, err := json.MarshalIndent(issues, "", "  ")
+		if err != nil {
+			logf("%s\n", err.Error())
+			return 2
+		}
+		fmt.Fprintln(out, string(data))
+	} else {
+		for _, issue := range issues {
+			fmt.Fprintf(out, "%s:%s\n", issue.File, issue.String())
+		}
+	}
+
+	if status == 0 && len(issues) > 0 {
+		status = 1
+	}
+	return status
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLintFixKeepsNewline(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "formula.tex")
+	if err := os.WriteFile(file, []byte("a*b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// the second run has nothing left to fix and must not touch the file
+	for run := 0; run < 2; run++ {
+		lintMain([]string{"-fix", file})
+		got, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "a\\cdot b\n" {
+			t.Errorf("run %d: got %q", run+1, got)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/horriblename/mathcha/eval"
+	"github.com/horriblename/mathcha/plot"
+)
+
+const plotUsage = `usage: mathcha plot [flags] [formula]
+
+Draws the graph of a formula in one variable, e.g.
+
+	mathcha plot -x -\pi:\pi 'y = \sin x + x^2'
+
+Without a formula, it is read from stdin. Flags may follow the formula, put
+-- before a formula starting with "-".
+
+`
+
+// a range given as min:max, e.g. -\pi:\pi, unset if both are 0
+type valueRange struct {
+	min, max float64
+}
+
+func (r *valueRange) String() string {
+	if r == nil || r.min == 0 && r.max == 0 {
+		return ""
+	}
+	return eval.Format(r.min) + ":" + eval.Format(r.max)
+}
+
+func (r *valueRange) Set(s string) error {
+	bounds := strings.Split(s, ":")
+	if len(bounds) != 2 {
+		return fmt.Errorf("expected min:max, e.g. -10:10")
+	}
+	var values [2]float64
+	for i, bound := range bounds {
+		value, err := eval.EvalSource(bound, nil)
+		if err != nil {
+			return err
+		}
+		values[i] = value
+	}
+	if values[0] >= values[1] {
+		return fmt.Errorf("the minimum must be less than the maximum")
+	}
+	r.min, r.max = values[0], values[1]
+	return nil
+}
+
+// mathcha plot, returns the exit code
+func plotMain(args []string) int {
+	flags := flag.NewFlagSet("plot", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), plotUsage)
+		flags.PrintDefaults()
+	}
+	x := valueRange{-10, 10}
+	y := valueRange{}
+	flags.Var(&x, "x", "Range of the variable as min:max, e.g. -\\pi:\\pi")
+	flags.Var(&y, "y", "Range of the values as min:max (default: fitted to the values)")
+	width := flags.Int("width", 60, "Width of the chart in characters")
+	height := flags.Int("height", 15, "Height of the chart in characters")
+	ascii := flags.Bool("ascii", false, "Draw with ASCII characters ('*' for the graph, '-' and '|' for the axes) instead of braille characters")
+	vars := bindings{}
+	flags.Var(vars, "v", "Bind a variable other than the plotted one, e.g. a=2; may be repeated")
+	words, err := parseInterspersed(flags, args)
+	if err != nil {
+		return 2
+	}
+
+	formula := strings.Join(words, " ")
+	if len(words) == 0 {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			logf("error reading stdin: %s\n", err.Error())
+			return 2
+		}
+		formula = string(src)
+	}
+
+	cfg := plot.Config{
+		XMin: x.min, XMax: x.max,
+		YMin: y.min, YMax: y.max,
+		Width: *width, Height: *height,
+		ASCII: *ascii,
+		Vars:  eval.Vars(vars),
+	}
+	chart, err := cfg.PlotSource(formula)
+	if err != nil {
+		logf("%s\n", err.Error())
+		return 1
+	}
+	fmt.Println(chart)
+	return 0
+}
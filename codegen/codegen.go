@@ -0,0 +1,660 @@
+// Package codegen writes formulas as expressions of programming languages and
+// computer algebra systems, e.g. \frac{a}{b} + \sqrt{x} as
+// "(a)/(b) + math.Sqrt(x)" in Go, for transcribing formulas from papers into
+// code and handing them off to notebooks
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/horriblename/mathcha/semantic"
+)
+
+type Lang int
+
+const (
+	Go Lang = iota
+	// Python with NumPy, imported as np, and scipy.special for factorials,
+	// binomials and the gamma function. Everything works elementwise on arrays
+	Python
+	// C with math.h. \sum and \prod are written as statement expressions, a
+	// GNU extension supported by GCC and Clang
+	C
+	// SymPy, imported as sympy. The variables are symbols (or IndexedBase for
+	// x_i in sums) the notebook defines
+	SymPy
+	// Mathematica's InputForm
+	Mathematica
+)
+
+// the names of the languages, e.g. for the -format flag
+var Langs = map[string]Lang{
+	"go":          Go,
+	"python":      Python,
+	"c":           C,
+	"sympy":       SymPy,
+	"mathematica": Mathematica,
+}
+
+// Produce writes a formula as an expression. Fractions are written as
+// (a)/(b) in Go, Python and C, with integer numerators of fractions of
+// constants as floats in Go and C (1.0/2, not 1/2) and as exact rationals in
+// SymPy. \sum and \prod become loops or comprehensions (with x_i indexing an
+// array x by the loop variable i) or the systems' sums and products, the
+// variables pi and e the languages' constants. A relation y = ... with a
+// variable on the left is written as an assignment in Go, Python and C, any
+// other relation as a comparison or equation
+func Produce(lang Lang, n semantic.Node) (string, error) {
+	w := writer{lang: lang}
+	if rel, ok := n.(*semantic.Relation); ok {
+		if err := w.relation(rel); err != nil {
+			return "", err
+		}
+		return w.String(), nil
+	}
+	if err := w.node(n, precSum); err != nil {
+		return "", err
+	}
+	return w.String(), nil
+}
+
+// ProduceSource parses, interprets and writes a formula, see Produce
+func ProduceSource(lang Lang, src string) (string, error) {
+	n, err := semantic.Parse(src)
+	if err != nil {
+		return "", err
+	}
+	return Produce(lang, n)
+}
+
+func (l Lang) String() string {
+	switch l {
+	case Python:
+		return "Python"
+	case C:
+		return "C"
+	case SymPy:
+		return "SymPy"
+	case Mathematica:
+		return "Mathematica"
+	}
+	return "Go"
+}
+
+// whether the language is a computer algebra system, with exact arithmetic
+// and symbolic sums
+func (l Lang) symbolic() bool {
+	return l == SymPy || l == Mathematica
+}
+
+// precedences, from the loosest binding
+const (
+	precSum = iota
+	precProduct
+	precUnary
+	precPower // Python's **, Mathematica's ^
+	precAtom
+)
+
+type writer struct {
+	strings.Builder
+	lang Lang
+	// write integers so that dividing them doesn't truncate, e.g. 1.0 in Go
+	promote bool
+	// the variables of the enclosing \sum and \prod
+	bound []string
+}
+
+func (w *writer) prec(n semantic.Node) int {
+	switch n := n.(type) {
+	case *semantic.Binary:
+		if n.Op == semantic.Add || n.Op == semantic.Sub {
+			return precSum
+		}
+		return precProduct
+	case *semantic.Frac:
+		if w.lang == SymPy && isInteger(n.Num) && isInteger(n.Den) {
+			// sympy.Rational
+			return precAtom
+		}
+		return precProduct
+	case *semantic.Neg:
+		return precUnary
+	case *semantic.Number:
+		if n.Value < 0 {
+			return precUnary
+		}
+	case *semantic.Power:
+		if w.lang != Go && w.lang != C {
+			return precPower
+		}
+	case *semantic.Binomial:
+		if w.lang == Go || w.lang == C {
+			// written with factorials
+			return precProduct
+		}
+	}
+	return precAtom
+}
+
+// writes a node, in parentheses if it binds looser than min
+func (w *writer) node(n semantic.Node, min int) error {
+	if w.prec(n) < min {
+		w.WriteString("(")
+		defer w.WriteString(")")
+	}
+
+	switch n := n.(type) {
+	case *semantic.Number:
+		w.number(n.Value)
+	case *semantic.Var:
+		return w.variable(n.Name)
+	case *semantic.Neg:
+		w.WriteString("-")
+		// not --x, a decrement in C
+		return w.node(n.X, precUnary+1)
+	case *semantic.Binary:
+		return w.binary(n)
+	case *semantic.Frac:
+		return w.division(n.Num, n.Den, !w.lang.symbolic())
+	case *semantic.Power:
+		return w.power(n)
+	case *semantic.Apply:
+		return w.apply(n)
+	case *semantic.Binomial:
+		switch w.lang {
+		case Python:
+			return w.call("scipy.special.comb", n.N, n.K)
+		case SymPy:
+			return w.call("sympy.binomial", n.N, n.K)
+		case Mathematica:
+			return w.call("Binomial", n.N, n.K)
+		}
+		// n!/(k!(n-k)!)
+		return w.node(&semantic.Binary{
+			Op: semantic.Div,
+			X:  factorial(n.N),
+			Y: &semantic.Binary{
+				Op: semantic.Mul,
+				X:  factorial(n.K),
+				Y:  factorial(&semantic.Binary{Op: semantic.Sub, X: n.N, Y: n.K}),
+			},
+		}, min)
+	case *semantic.BigOp:
+		return w.bigOp(n)
+	case *semantic.Matrix:
+		return w.matrix(n)
+	case *semantic.Relation:
+		return fmt.Errorf("cannot write a relation inside an expression in %s", w.lang)
+	default:
+		return fmt.Errorf("cannot write %s in %s", n, w.lang)
+	}
+	return nil
+}
+
+func factorial(n semantic.Node) semantic.Node {
+	return &semantic.Apply{Func: "factorial", Arg: n}
+}
+
+func (w *writer) number(value float64) {
+	s := strconv.FormatFloat(value, 'g', -1, 64)
+	switch {
+	case !w.promote || strings.ContainsAny(s, ".e"):
+	case w.lang == SymPy:
+		s = "sympy.Integer(" + s + ")"
+	default:
+		s += ".0"
+	}
+	w.WriteString(s)
+}
+
+var constants = map[Lang]map[string]string{
+	Go:          {"pi": "math.Pi", "e": "math.E"},
+	Python:      {"pi": "np.pi", "e": "np.e"},
+	C:           {"pi": "M_PI", "e": "M_E"},
+	SymPy:       {"pi": "sympy.pi", "e": "sympy.E"},
+	Mathematica: {"pi": "Pi", "e": "E"},
+}
+
+// single letter symbols of Mathematica, which can't be variables
+var mathematicaSymbols = "CDEIKNO"
+
+// an identifier for a variable, e.g. x_10 for x_{10}
+func (w *writer) variable(name string) error {
+	if c, ok := constants[w.lang][name]; ok {
+		w.WriteString(c)
+		return nil
+	}
+	name = strings.NewReplacer("{", "", "}", "").Replace(name)
+	base, sub := name, ""
+	if i := strings.IndexByte(name, '_'); i > 0 {
+		base, sub = name[:i], name[i+1:]
+	}
+
+	switch {
+	case w.lang == Mathematica:
+		if len(base) == 1 && strings.Contains(mathematicaSymbols, base) {
+			return fmt.Errorf("cannot use %s as a variable in Mathematica, it is a builtin symbol", base)
+		}
+		if len(base) > 1 {
+			// \[Alpha]
+			base = `\[` + strings.ToUpper(base[:1]) + base[1:] + `]`
+		}
+		if sub != "" {
+			fmt.Fprintf(w, "Subscript[%s, %s]", base, sub)
+			return nil
+		}
+		name = base
+	case sub != "" && w.isBound(sub):
+		// x_i in \sum_{i=1}^{n} x_i is an element of an array
+		format := map[Lang]string{Go: "%s[int(%s)]", C: "%s[(int)%s]"}[w.lang]
+		if format == "" {
+			format = "%s[%s]"
+		}
+		fmt.Fprintf(w, format, base, sub)
+		return nil
+	case (w.lang == Python || w.lang == SymPy) && name == "lambda":
+		// a keyword
+		name += "_"
+	}
+	w.WriteString(name)
+	return nil
+}
+
+func (w *writer) isBound(name string) bool {
+	for _, v := range w.bound {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *writer) binary(n *semantic.Binary) error {
+	switch n.Op {
+	case semantic.Add, semantic.Sub:
+		if err := w.node(n.X, precSum); err != nil {
+			return err
+		}
+		w.WriteString(" " + n.Op.String() + " ")
+		// a - (b + c)
+		return w.node(n.Y, precProduct)
+	case semantic.Mul:
+		if err := w.node(n.X, precProduct); err != nil {
+			return err
+		}
+		w.WriteString("*")
+		// a*(b*c), but a*(b)/(c)
+		if _, ok := n.Y.(*semantic.Frac); ok && !w.lang.symbolic() {
+			return w.node(n.Y, precProduct)
+		}
+		return w.node(n.Y, precProduct+1)
+	}
+	return w.division(n.X, n.Y, false)
+}
+
+// x/y, or (x)/(y) with parens
+func (w *writer) division(x, y semantic.Node, parens bool) error {
+	if w.lang == SymPy && isInteger(x) && isInteger(y) {
+		return w.call("sympy.Rational", x, y)
+	}
+
+	promote := w.promote
+	// Python divides integers to floats, Mathematica to rationals
+	w.promote = promote || (w.lang == Go || w.lang == C || w.lang == SymPy) && isConstant(x) && isConstant(y)
+
+	min := precProduct
+	if parens {
+		min = precAtom + 1
+	}
+	err := w.node(x, min)
+	w.promote = promote
+	if err != nil {
+		return err
+	}
+	w.WriteString("/")
+	if parens {
+		return w.node(y, precAtom+1)
+	}
+	return w.node(y, precProduct+1)
+}
+
+// whether a node is made of numbers only, e.g. 2(3+1)
+func isConstant(n semantic.Node) bool {
+	switch n := n.(type) {
+	case *semantic.Number:
+		return true
+	case *semantic.Neg:
+		return isConstant(n.X)
+	case *semantic.Binary:
+		return isConstant(n.X) && isConstant(n.Y)
+	case *semantic.Frac:
+		return isConstant(n.Num) && isConstant(n.Den)
+	}
+	return false
+}
+
+func isInteger(n semantic.Node) bool {
+	number, ok := n.(*semantic.Number)
+	return ok && number.Value == float64(int64(number.Value))
+}
+
+func (w *writer) power(n *semantic.Power) error {
+	switch w.lang {
+	case Go:
+		return w.call("math.Pow", n.Base, n.Exp)
+	case C:
+		return w.call("pow", n.Base, n.Exp)
+	}
+	// (-x)**2, (x**2)**3, x**-1
+	promote := w.promote
+	// 2**-1 is a float in Python, sympy.Integer(2)**-1 a rational
+	w.promote = promote || w.lang == SymPy && isConstant(n.Base) && isConstant(n.Exp)
+	err := w.node(n.Base, precAtom)
+	w.promote = promote
+	if err != nil {
+		return err
+	}
+	if w.lang == Mathematica {
+		w.WriteString("^")
+	} else {
+		w.WriteString("**")
+	}
+	return w.node(n.Exp, precUnary)
+}
+
+// writes a function call, the arguments are floats anyway
+func (w *writer) call(f string, args ...semantic.Node) error {
+	promote := w.promote
+	defer func() { w.promote = promote }()
+	w.promote = false
+
+	open, close := "(", ")"
+	if w.lang == Mathematica {
+		open, close = "[", "]"
+	}
+	w.WriteString(f + open)
+	for i, arg := range args {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		if err := w.node(arg, precSum); err != nil {
+			return err
+		}
+	}
+	w.WriteString(close)
+	return nil
+}
+
+// the functions of each language, by the name of semantic.Apply. Functions
+// missing from the standard libraries are written as 1/f(x), see reciprocals
+var functions = map[Lang]map[string]string{
+	Go: {
+		"sin": "math.Sin", "cos": "math.Cos", "tan": "math.Tan",
+		"sinh": "math.Sinh", "cosh": "math.Cosh", "tanh": "math.Tanh",
+		"arcsin": "math.Asin", "arccos": "math.Acos", "arctan": "math.Atan",
+		"arcsinh": "math.Asinh", "arccosh": "math.Acosh", "arctanh": "math.Atanh",
+		"ln": "math.Log", "lg": "math.Log10", "log": "math.Log10", "log_2": "math.Log2",
+		"sqrt": "math.Sqrt", "abs": "math.Abs", "gamma": "math.Gamma",
+	},
+	Python: {
+		"sin": "np.sin", "cos": "np.cos", "tan": "np.tan",
+		"sinh": "np.sinh", "cosh": "np.cosh", "tanh": "np.tanh",
+		"arcsin": "np.arcsin", "arccos": "np.arccos", "arctan": "np.arctan",
+		"arcsinh": "np.arcsinh", "arccosh": "np.arccosh", "arctanh": "np.arctanh",
+		"ln": "np.log", "lg": "np.log10", "log": "np.log10", "log_2": "np.log2",
+		"sqrt": "np.sqrt", "abs": "np.abs",
+		"factorial": "scipy.special.factorial", "gamma": "scipy.special.gamma",
+	},
+	C: {
+		"sin": "sin", "cos": "cos", "tan": "tan",
+		"sinh": "sinh", "cosh": "cosh", "tanh": "tanh",
+		"arcsin": "asin", "arccos": "acos", "arctan": "atan",
+		"arcsinh": "asinh", "arccosh": "acosh", "arctanh": "atanh",
+		"ln": "log", "lg": "log10", "log": "log10", "log_2": "log2",
+		"sqrt": "sqrt", "abs": "fabs", "gamma": "tgamma",
+	},
+	// logarithms are sympy.log(x, b), see apply
+	SymPy: {
+		"sin": "sympy.sin", "cos": "sympy.cos", "tan": "sympy.tan",
+		"sec": "sympy.sec", "csc": "sympy.csc", "cot": "sympy.cot",
+		"sinh": "sympy.sinh", "cosh": "sympy.cosh", "tanh": "sympy.tanh", "coth": "sympy.coth",
+		"arcsin": "sympy.asin", "arccos": "sympy.acos", "arctan": "sympy.atan",
+		"arcsinh": "sympy.asinh", "arccosh": "sympy.acosh", "arctanh": "sympy.atanh",
+		"ln":   "sympy.log",
+		"sqrt": "sympy.sqrt", "abs": "sympy.Abs", "factorial": "sympy.factorial",
+	},
+	Mathematica: {
+		"sin": "Sin", "cos": "Cos", "tan": "Tan",
+		"sec": "Sec", "csc": "Csc", "cot": "Cot",
+		"sinh": "Sinh", "cosh": "Cosh", "tanh": "Tanh", "coth": "Coth",
+		"arcsin": "ArcSin", "arccos": "ArcCos", "arctan": "ArcTan",
+		"arcsinh": "ArcSinh", "arccosh": "ArcCosh", "arctanh": "ArcTanh",
+		"ln": "Log", "lg": "Log10", "log": "Log10", "log_2": "Log2",
+		"sqrt": "Sqrt", "abs": "Abs", "factorial": "Factorial",
+	},
+}
+
+// functions written as 1/f(x)
+var reciprocals = map[string]string{
+	"sec":  "cos",
+	"csc":  "sin",
+	"cot":  "tan",
+	"coth": "tanh",
+}
+
+func (w *writer) apply(n *semantic.Apply) error {
+	name := n.Func
+	if w.lang == SymPy && (name == "log" || name == "lg") {
+		var base semantic.Node = &semantic.Number{Value: 10}
+		if n.Base != nil {
+			base = n.Base
+		}
+		return w.call("sympy.log", n.Arg, base)
+	}
+	if n.Base != nil {
+		switch b, ok := n.Base.(*semantic.Number); {
+		case ok && (b.Value == 2 || b.Value == 10):
+			name += "_" + b.String()
+		case w.lang == Mathematica:
+			return w.call("Log", n.Base, n.Arg)
+		default:
+			// \log_b x = \ln x / \ln b
+			return w.node(&semantic.Binary{
+				Op: semantic.Div,
+				X:  &semantic.Apply{Func: "ln", Arg: n.Arg},
+				Y:  &semantic.Apply{Func: "ln", Arg: n.Base},
+			}, precAtom)
+		}
+	}
+	if name == "log_10" {
+		name = "log"
+	}
+
+	if f, ok := functions[w.lang][name]; ok {
+		return w.call(f, n.Arg)
+	}
+	if f, ok := reciprocals[name]; ok {
+		w.WriteString("1/")
+		return w.apply(&semantic.Apply{Func: f, Arg: n.Arg})
+	}
+	if name == "factorial" {
+		// x! = Γ(x+1)
+		return w.apply(&semantic.Apply{
+			Func: "gamma",
+			Arg:  &semantic.Binary{Op: semantic.Add, X: n.Arg, Y: &semantic.Number{Value: 1}},
+		})
+	}
+	return fmt.Errorf("cannot write \\%s in %s", n.Func, w.lang)
+}
+
+// a loop in a closure (Go) or a statement expression (C), a list
+// comprehension in Python, a symbolic sum or product in computer algebra systems
+func (w *writer) bigOp(n *semantic.BigOp) error {
+	promote := w.promote
+	defer func() { w.promote = promote }()
+	w.promote = false
+
+	acc, op, init := "sum", "+=", "0"
+	if n.Op == semantic.Prod {
+		acc, op, init = "prod", "*=", "1"
+	}
+	i := &semantic.Var{Name: n.Var}
+	w.bound = append(w.bound, n.Var)
+	defer func() { w.bound = w.bound[:len(w.bound)-1] }()
+	var parts []interface{}
+	switch w.lang {
+	case Go:
+		parts = []interface{}{
+			"func() float64 { ", acc, " := ", init, ".0; for ", i, " := float64(", n.From, "); ",
+			i, " <= ", n.To, "; ", i, "++ { ", acc, " ", op, " ", n.Body, " }; return ", acc, " }()",
+		}
+	case Python:
+		f := "np.sum"
+		if n.Op == semantic.Prod {
+			f = "np.prod"
+		}
+		// along the first axis, so that the terms may be arrays
+		parts = []interface{}{
+			f, "([", n.Body, " for ", i, " in range(", n.From, ", ", n.To, " + 1)], axis=0)",
+		}
+	case C:
+		parts = []interface{}{
+			"({ double ", acc, " = ", init, "; for (double ", i, " = ", n.From, "; ",
+			i, " <= ", n.To, "; ", i, "++) ", acc, " ", op, " ", n.Body, "; ", acc, "; })",
+		}
+	case SymPy:
+		f := "sympy.Sum"
+		if n.Op == semantic.Prod {
+			f = "sympy.Product"
+		}
+		parts = []interface{}{f, "(", n.Body, ", (", i, ", ", n.From, ", ", n.To, "))"}
+	case Mathematica:
+		f := "Sum"
+		if n.Op == semantic.Prod {
+			f = "Product"
+		}
+		parts = []interface{}{f, "[", n.Body, ", {", i, ", ", n.From, ", ", n.To, "}]"}
+	}
+	for _, part := range parts {
+		switch part := part.(type) {
+		case string:
+			w.WriteString(part)
+		case semantic.Node:
+			if err := w.node(part, precSum); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// nested lists, e.g. sympy.Matrix([[a, b], [c, d]])
+func (w *writer) matrix(n *semantic.Matrix) error {
+	open, close := "[", "]"
+	switch w.lang {
+	case Python:
+		w.WriteString("np.array(")
+		defer w.WriteString(")")
+	case SymPy:
+		w.WriteString("sympy.Matrix(")
+		defer w.WriteString(")")
+	case Mathematica:
+		open, close = "{", "}"
+	default:
+		return fmt.Errorf("cannot write a matrix in %s", w.lang)
+	}
+
+	w.WriteString(open)
+	for i, row := range n.Rows {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		w.WriteString(open)
+		for j, cell := range row {
+			if j > 0 {
+				w.WriteString(", ")
+			}
+			if err := w.node(cell, precSum); err != nil {
+				return err
+			}
+		}
+		w.WriteString(close)
+	}
+	w.WriteString(close)
+	return nil
+}
+
+// comparison operators, by the relations of semantic.Relation
+var comparisons = map[string]string{
+	"=":   "==",
+	"<":   "<",
+	">":   ">",
+	`\lt`: "<",
+	`\gt`: ">",
+	`\le`: "<=",
+	`\ge`: ">=",
+	`\ne`: "!=",
+}
+
+// SymPy's relations, by the comparison operators
+var sympyRelations = map[string]string{
+	"==": "sympy.Eq",
+	"<":  "sympy.Lt",
+	">":  "sympy.Gt",
+	"<=": "sympy.Le",
+	">=": "sympy.Ge",
+	"!=": "sympy.Ne",
+}
+
+func (w *writer) relation(n *semantic.Relation) error {
+	if _, ok := n.Args[0].(*semantic.Var); ok && len(n.Ops) == 1 && n.Ops[0] == "=" && !w.lang.symbolic() {
+		if err := w.variable(n.Args[0].(*semantic.Var).Name); err != nil {
+			return err
+		}
+		w.WriteString(" = ")
+		return w.node(n.Args[1], precSum)
+	}
+
+	if w.lang == SymPy && len(n.Ops) > 1 {
+		w.WriteString("sympy.And(")
+		defer w.WriteString(")")
+	}
+	for i, op := range n.Ops {
+		cmp, ok := comparisons[op]
+		if !ok {
+			return fmt.Errorf("cannot write %s in %s", op, w.lang)
+		}
+		if w.lang == SymPy {
+			// sympy.And(sympy.Lt(a, b), sympy.Le(b, c))
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			if err := w.call(sympyRelations[cmp], n.Args[i], n.Args[i+1]); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch {
+		case i == 0:
+			if err := w.node(n.Args[0], precSum); err != nil {
+				return err
+			}
+		case w.lang == Python || w.lang == Mathematica:
+			// a chain, a < b <= c
+		default:
+			w.WriteString(" && ")
+			if err := w.node(n.Args[i], precSum); err != nil {
+				return err
+			}
+		}
+		w.WriteString(" " + cmp + " ")
+		if err := w.node(n.Args[i+1], precSum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package codegen
+
+import "testing"
+
+func TestProduce(t *testing.T) {
+	testCases := []struct {
+		input             string
+		golang, python, c string
+	}{
+		{`\frac{a}{b}`, `(a)/(b)`, `(a)/(b)`, `(a)/(b)`},
+		{`\frac{1}{2}x`, `(1.0)/(2)*x`, `(1)/(2)*x`, `(1.0)/(2)*x`},
+		{`x^{2} + 1`, `math.Pow(x, 2) + 1`, `x**2 + 1`, `pow(x, 2) + 1`},
+		{`-x^2`, `-math.Pow(x, 2)`, `-x**2`, `-pow(x, 2)`},
+		{`(-x)^2`, `math.Pow(-x, 2)`, `(-x)**2`, `pow(-x, 2)`},
+		{`\sqrt{x_1 + \alpha}`, `math.Sqrt(x_1 + alpha)`, `np.sqrt(x_1 + alpha)`, `sqrt(x_1 + alpha)`},
+		{`a - (b + c) \cdot d`, `a - (b + c)*d`, `a - (b + c)*d`, `a - (b + c)*d`},
+		{`a(b/c)`, `a*(b/c)`, `a*(b/c)`, `a*(b/c)`},
+		{`2\pi r`, `2*math.Pi*r`, `2*np.pi*r`, `2*M_PI*r`},
+		{`\sec x + |x|`, `1/math.Cos(x) + math.Abs(x)`, `1/np.cos(x) + np.abs(x)`, `1/cos(x) + fabs(x)`},
+		{`\log_2 x + \log_b x`, `math.Log2(x) + (math.Log(x)/math.Log(b))`, `np.log2(x) + (np.log(x)/np.log(b))`, `log2(x) + (log(x)/log(b))`},
+		{`n!`, `math.Gamma(n + 1)`, `scipy.special.factorial(n)`, `tgamma(n + 1)`},
+		{`\binom{n}{k}`, `math.Gamma(n + 1)/(math.Gamma(k + 1)*math.Gamma(n - k + 1))`, `scipy.special.comb(n, k)`, `tgamma(n + 1)/(tgamma(k + 1)*tgamma(n - k + 1))`},
+		{
+			`\sum_{i=1}^{n} i^2`,
+			`func() float64 { sum := 0.0; for i := float64(1); i <= n; i++ { sum += math.Pow(i, 2) }; return sum }()`,
+			`np.sum([i**2 for i in range(1, n + 1)], axis=0)`,
+			`({ double sum = 0; for (double i = 1; i <= n; i++) sum += pow(i, 2); sum; })`,
+		},
+		{`\prod_{k=1}^{n} k`, `func() float64 { prod := 1.0; for k := float64(1); k <= n; k++ { prod *= k }; return prod }()`, `np.prod([k for k in range(1, n + 1)], axis=0)`, `({ double prod = 1; for (double k = 1; k <= n; k++) prod *= k; prod; })`},
+		{
+			`\sum_{i=1}^{n} a_i x_{i}`,
+			`func() float64 { sum := 0.0; for i := float64(1); i <= n; i++ { sum += a[int(i)]*x[int(i)] }; return sum }()`,
+			`np.sum([a[i]*x[i] for i in range(1, n + 1)], axis=0)`,
+			`({ double sum = 0; for (double i = 1; i <= n; i++) sum += a[(int)i]*x[(int)i]; sum; })`,
+		},
+		{`y = 2x + 1`, `y = 2*x + 1`, `y = 2*x + 1`, `y = 2*x + 1`},
+		{`0 < x \le 1`, `0 < x && x <= 1`, `0 < x <= 1`, `0 < x && x <= 1`},
+		{`\lambda`, `lambda`, `lambda_`, `lambda`},
+	}
+
+	for _, tc := range testCases {
+		for lang, expect := range map[Lang]string{Go: tc.golang, Python: tc.python, C: tc.c} {
+			got, err := ProduceSource(lang, tc.input)
+			if err != nil {
+				t.Errorf("%s in %s: unexpected error %s", tc.input, lang, err)
+				continue
+			}
+			if got != expect {
+				t.Errorf("%s in %s: got %q, expected %q", tc.input, lang, got, expect)
+			}
+		}
+	}
+}
+
+func TestProduceErrors(t *testing.T) {
+	testCases := map[string]string{
+		`a \approx b`: `cannot write \approx in Go`,
+		`\foo`:        `cannot interpret \foo`,
+		`a/bc`:        `ambiguous division a/bc, use \frac or parentheses`,
+	}
+	for input, expect := range testCases {
+		_, err := ProduceSource(Go, input)
+		if err == nil || err.Error() != expect {
+			t.Errorf("%s: got error %v, expected %q", input, err, expect)
+		}
+	}
+}
+
+func TestProduceAlgebra(t *testing.T) {
+	testCases := []struct {
+		input              string
+		sympy, mathematica string
+	}{
+		{`\frac{1}{2}x`, `sympy.Rational(1, 2)*x`, `1/2*x`},
+		{`\frac{a+b}{c}`, `(a + b)/c`, `(a + b)/c`},
+		{`\frac{1+2}{3}`, `(sympy.Integer(1) + sympy.Integer(2))/3`, `(1 + 2)/3`},
+		{`\sqrt{x^2 + 1}`, `sympy.sqrt(x**2 + 1)`, `Sqrt[x^2 + 1]`},
+		{`2\sin x \cos x`, `2*sympy.sin(x)*sympy.cos(x)`, `2*Sin[x]*Cos[x]`},
+		{`\log x + \log_2 x + \log_b x`, `sympy.log(x, 10) + sympy.log(x, 2) + sympy.log(x, b)`, `Log10[x] + Log2[x] + Log[b, x]`},
+		{`\sec x`, `sympy.sec(x)`, `Sec[x]`},
+		{`\binom{n}{k} + n!`, `sympy.binomial(n, k) + sympy.factorial(n)`, `Binomial[n, k] + Factorial[n]`},
+		{`\pi e^{x}`, `sympy.pi*sympy.E**x`, `Pi*E^x`},
+		{`2^{-1} + 3^{x}`, `sympy.Integer(2)**-1 + 3**x`, `2^-1 + 3^x`},
+		{`\alpha_1 + x_{10}`, `alpha_1 + x_10`, `Subscript[\[Alpha], 1] + Subscript[x, 10]`},
+		{`\sum_{i=1}^{n} x_i^2`, `sympy.Sum(x[i]**2, (i, 1, n))`, `Sum[Subscript[x, i]^2, {i, 1, n}]`},
+		{`\prod_{k=1}^{n} k`, `sympy.Product(k, (k, 1, n))`, `Product[k, {k, 1, n}]`},
+		{`\begin{matrix}a & b \\ c & d\end{matrix}`, `sympy.Matrix([[a, b], [c, d]])`, `{{a, b}, {c, d}}`},
+		{`y = x^2`, `sympy.Eq(y, x**2)`, `y == x^2`},
+		{`0 < x \le 1`, `sympy.And(sympy.Lt(0, x), sympy.Le(x, 1))`, `0 < x <= 1`},
+	}
+
+	for _, tc := range testCases {
+		for lang, expect := range map[Lang]string{SymPy: tc.sympy, Mathematica: tc.mathematica} {
+			got, err := ProduceSource(lang, tc.input)
+			if err != nil {
+				t.Errorf("%s in %s: unexpected error %s", tc.input, lang, err)
+				continue
+			}
+			if got != expect {
+				t.Errorf("%s in %s: got %q, expected %q", tc.input, lang, got, expect)
+			}
+		}
+	}
+
+	testErrors := []struct {
+		lang          Lang
+		input, expect string
+	}{
+		{SymPy, `\text{if } x`, `cannot interpret \text{if }`},
+		{Mathematica, `\foo x`, `cannot interpret \foo`},
+		{Mathematica, `N + 1`, `cannot use N as a variable in Mathematica, it is a builtin symbol`},
+		{SymPy, `a \approx b`, `cannot write \approx in SymPy`},
+		{Go, `\begin{matrix}a\end{matrix}`, `cannot write a matrix in Go`},
+	}
+	for _, tc := range testErrors {
+		_, err := ProduceSource(tc.lang, tc.input)
+		if err == nil || err.Error() != tc.expect {
+			t.Errorf("%s in %s: got error %v, expected %q", tc.input, tc.lang, err, tc.expect)
+		}
+	}
+}
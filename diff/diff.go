@@ -0,0 +1,191 @@
+// Package diff compares two formulas structurally: their syntax trees are
+// aligned node by node, and the subtrees that were inserted, deleted or
+// modified are reported, e.g. changing "\frac{a}{2}" to "\frac{b}{2}" modifies
+// the "a", nothing else
+package diff
+
+import (
+	"reflect"
+
+	"github.com/horriblename/mathcha/latex"
+)
+
+type Kind int
+
+const (
+	Inserted Kind = iota // a subtree only present in the new formula
+	Deleted              // a subtree only present in the old formula
+	Modified             // a subtree replaced by a different one of the same type
+)
+
+var kindNames = [...]string{
+	Inserted: "inserted",
+	Deleted:  "deleted",
+	Modified: "modified",
+}
+
+func (k Kind) String() string { return kindNames[k] }
+
+// A Change is one difference between the old and the new formula. Paths are
+// the child indices (see latex.ChildrenOf) leading from the root to the node.
+// Inserted changes have no Old node, deleted ones no New node, but both have
+// paths: the position the node was inserted at, or would have had
+type Change struct {
+	Kind     Kind
+	Old, New latex.Expr
+	OldPath  []int
+	NewPath  []int
+}
+
+var cmpCfg = latex.DeepEqCfg{SkipPos: true}
+
+// Diff returns the changes turning old into new, in tree order. Positions are
+// not compared, so formatting differences of the sources don't show up
+func Diff(old, new latex.Expr) []Change {
+	d := differ{}
+	d.node(old, new, nil, nil)
+	return d.changes
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(kind Kind, old, new latex.Expr, oldPath, newPath []int) {
+	d.changes = append(d.changes, Change{
+		Kind:    kind,
+		Old:     old,
+		New:     new,
+		OldPath: append([]int(nil), oldPath...),
+		NewPath: append([]int(nil), newPath...),
+	})
+}
+
+// compares two nodes that are aligned with each other
+func (d *differ) node(old, new latex.Expr, oldPath, newPath []int) {
+	switch {
+	case old == nil && new == nil:
+		return
+	case old == nil || new == nil:
+		d.add(Modified, old, new, oldPath, newPath)
+		return
+	case old.DeepEqWith(new, cmpCfg):
+		return
+	}
+
+	oldChildren, newChildren := latex.ChildrenOf(old), latex.ChildrenOf(new)
+	if label(old) != label(new) || len(oldChildren) == 0 && len(newChildren) == 0 {
+		d.add(Modified, old, new, oldPath, newPath)
+		return
+	}
+	if _, ok := old.(latex.FlexContainer); ok {
+		d.seq(oldChildren, newChildren, oldPath, newPath)
+		return
+	}
+	// arguments of commands, cells of environments of the same size
+	if len(oldChildren) != len(newChildren) {
+		d.add(Modified, old, new, oldPath, newPath)
+		return
+	}
+	for i := range oldChildren {
+		d.node(oldChildren[i], newChildren[i], append(oldPath, i), append(newPath, i))
+	}
+}
+
+// compares two lists of siblings. Unchanged nodes are matched first (a longest
+// common subsequence), the nodes in between are then paired with a node of the
+// same type where possible and compared recursively
+func (d *differ) seq(old, new []latex.Expr, oldPath, newPath []int) {
+	pairs := lcs(old, new, func(a, b latex.Expr) bool { return a.DeepEqWith(b, cmpCfg) })
+	i, j := 0, 0
+	for _, p := range append(pairs, [2]int{len(old), len(new)}) {
+		d.gap(old[i:p[0]], new[j:p[1]], i, j, oldPath, newPath)
+		i, j = p[0]+1, p[1]+1
+	}
+}
+
+// compares nodes between two unchanged ones; oldFrom and newFrom are the
+// indices of the first nodes in their parents
+func (d *differ) gap(old, new []latex.Expr, oldFrom, newFrom int, oldPath, newPath []int) {
+	pairs := lcs(old, new, sameType)
+	i, j := 0, 0
+	for _, p := range append(pairs, [2]int{len(old), len(new)}) {
+		for ; i < p[0]; i++ {
+			d.add(Deleted, old[i], nil, append(oldPath, oldFrom+i), append(newPath, newFrom+j))
+		}
+		for ; j < p[1]; j++ {
+			d.add(Inserted, nil, new[j], append(oldPath, oldFrom+i), append(newPath, newFrom+j))
+		}
+		if i < len(old) && j < len(new) {
+			d.node(old[i], new[j], append(oldPath, oldFrom+i), append(newPath, newFrom+j))
+		}
+		i, j = p[0]+1, p[1]+1
+	}
+}
+
+func sameType(a, b latex.Expr) bool {
+	return reflect.TypeOf(a) == reflect.TypeOf(b)
+}
+
+// what distinguishes a container from another one of the same type, other
+// than its children
+type nodeLabel struct {
+	kind  reflect.Type
+	cmd   latex.LatexCmd
+	left  string
+	right string
+	env   latex.EnvName
+	rows  int
+	cols  int
+}
+
+func label(node latex.Expr) nodeLabel {
+	l := nodeLabel{kind: reflect.TypeOf(node)}
+	switch n := node.(type) {
+	case latex.CmdExpr:
+		l.cmd = n.Command()
+	case *latex.ParenCompExpr:
+		l.left, l.right = n.Left, n.Right
+	case *latex.EnvExpr:
+		l.env, l.rows = n.Name, len(n.Elts)
+		if len(n.Elts) > 0 {
+			l.cols = len(n.Elts[0])
+		}
+	}
+	return l
+}
+
+// returns the index pairs of a longest common subsequence of a and b
+func lcs(a, b []latex.Expr, eq func(a, b latex.Expr) bool) [][2]int {
+	// lengths[i][j] is the length of the LCS of a[i:] and b[j:]
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if eq(a[i], b[j]) {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	var pairs [][2]int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case eq(a[i], b[j]):
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,93 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/horriblename/mathcha/latex"
+)
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		old    string
+		new    string
+		expect []string
+	}{
+		{
+			desc: "equal",
+			old:  `\frac{a}{2}`,
+			new:  `\frac {a} {2}`,
+		},
+		{
+			desc:   "modified argument",
+			old:    `\frac{a}{2} + x^2`,
+			new:    `\frac{b}{2} + x^3`,
+			expect: []string{"modified 0.0.0: a -> b", "modified 3.0.0: 2 -> 3"},
+		},
+		{
+			desc:   "inserted and deleted",
+			old:    `a + b + c`,
+			new:    `a + c - d`,
+			expect: []string{"deleted 2: b", "deleted 3: +", "inserted 3: -", "inserted 4: d"},
+		},
+		{
+			desc:   "different command",
+			old:    `\frac{n}{k}`,
+			new:    `\binom{n}{k}`,
+			expect: []string{`modified 0: \frac {n}{k} -> \binom {n}{k}`},
+		},
+		{
+			desc:   "different type",
+			old:    `x`,
+			new:    `\alpha`,
+			expect: []string{`deleted 0: x`, `inserted 0: \alpha`},
+		},
+		{
+			desc:   "matrix cell",
+			old:    `\begin{matrix}a & b \\ c & d\end{matrix}`,
+			new:    `\begin{matrix}a & b \\ c & e\end{matrix}`,
+			expect: []string{`modified 0.3.0: d -> e`},
+		},
+		{
+			desc:   "matrix size",
+			old:    `\begin{matrix}a & b\end{matrix}`,
+			new:    `\begin{matrix}a & b \\ c & d\end{matrix}`,
+			expect: []string{`modified 0: \begin{matrix} a & b \end{matrix} -> \begin{matrix} a & b\\ c & d \end{matrix}`},
+		},
+		{
+			desc:   "text",
+			old:    `\text{if }x`,
+			new:    `\text{of }x`,
+			expect: []string{`modified 0.0.0: i -> o`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			report := NewReport(Diff(latex.Parse(tc.old), latex.Parse(tc.new)))
+			var got []string
+			for _, entry := range report.Changes {
+				got = append(got, entry.String())
+			}
+			if !reflect.DeepEqual(got, tc.expect) {
+				t.Errorf("got %q, expected %q", got, tc.expect)
+			}
+			if report.Equal != (len(tc.expect) == 0) {
+				t.Errorf("Equal is %v", report.Equal)
+			}
+		})
+	}
+}
+
+func TestDiffPaths(t *testing.T) {
+	old, new := latex.Parse(`a + b`), latex.Parse(`a - b`)
+	changes := Diff(old, new)
+	if len(changes) != 1 {
+		t.Fatalf("expected a single change, got %d", len(changes))
+	}
+	c := changes[0]
+	if c.Kind != Modified || c.Old != old.Elts[1] || c.New != new.Elts[1] {
+		t.Errorf("unexpected change %+v", c)
+	}
+}
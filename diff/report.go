@@ -0,0 +1,95 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/horriblename/mathcha/latex"
+	"github.com/horriblename/mathcha/renderer"
+)
+
+// Report is the machine-readable form of a diff, e.g. for CI
+type Report struct {
+	Equal   bool          `json:"equal"`
+	Changes []ReportEntry `json:"changes"`
+}
+
+// ReportEntry describes a Change, with the nodes written as LaTeX
+type ReportEntry struct {
+	Kind    string `json:"kind"`
+	OldPath []int  `json:"old_path"`
+	NewPath []int  `json:"new_path"`
+	Old     string `json:"old,omitempty"`
+	New     string `json:"new,omitempty"`
+}
+
+func NewReport(changes []Change) Report {
+	report := Report{Equal: len(changes) == 0, Changes: make([]ReportEntry, len(changes))}
+	for i, c := range changes {
+		report.Changes[i] = ReportEntry{
+			Kind:    c.Kind.String(),
+			OldPath: c.OldPath,
+			NewPath: c.NewPath,
+			Old:     source(c.Old),
+			New:     source(c.New),
+		}
+	}
+	return report
+}
+
+func source(node latex.Expr) string {
+	if node == nil {
+		return ""
+	}
+	cfg := renderer.LatexSourceConfig{}
+	return strings.TrimSpace(cfg.ProduceLatex(node))
+}
+
+// String describes the entry on a single line, e.g. "modified 0.1: x -> y"
+func (e ReportEntry) String() string {
+	path := e.NewPath
+	if e.Kind == Deleted.String() {
+		path = e.OldPath
+	}
+	steps := make([]string, len(path))
+	for i, step := range path {
+		steps[i] = fmt.Sprint(step)
+	}
+	where := strings.Join(steps, ".")
+	if where == "" {
+		where = "root"
+	}
+
+	var change string
+	switch e.Kind {
+	case Inserted.String():
+		change = e.New
+	case Deleted.String():
+		change = e.Old
+	default:
+		change = e.Old + " -> " + e.New
+	}
+	return fmt.Sprintf("%s %s: %s", e.Kind, where, strings.ReplaceAll(change, "\n", " "))
+}
+
+// Marks returns the styles highlighting the changes in the old and the new
+// tree, for Renderer.Marks
+func Marks(changes []Change, theme *renderer.Theme) (old, new map[latex.Expr]renderer.StyleSpec) {
+	old, new = map[latex.Expr]renderer.StyleSpec{}, map[latex.Expr]renderer.StyleSpec{}
+	for _, c := range changes {
+		switch c.Kind {
+		case Inserted:
+			new[c.New] = theme.Inserted
+		case Deleted:
+			old[c.Old] = theme.Deleted
+		case Modified:
+			if c.Old != nil {
+				old[c.Old] = theme.Modified
+			}
+			if c.New != nil {
+				new[c.New] = theme.Modified
+			}
+		}
+	}
+	return old, new
+}
@@ -36,17 +36,32 @@ type Editor struct {
 	focus      bool
 	config     *EditorConfig
 	banner     string // a line of text appearing below the renderer, for debugging
+	// the selections before each ExpandSelection, see ShrinkSelection
+	selectHistory []selectionState
 }
 
 type EditorConfig struct {
 	*log.Logger
 	LatexCfg render.LatexSourceConfig
+	ASCII    bool // render with ASCII characters only
+	Colors   render.ColorProfile
+	Theme    *render.Theme
+	// semantic highlighting of numbers, greek letters, relations etc.
+	Highlight bool
+}
+
+// without escape codes, the cursor can't be styled by the theme (usually
+// reverse video) and would be invisible as a space
+func cursorSymbol(colors render.ColorProfile) string {
+	if colors == render.COLOR_NONE {
+		return "|"
+	}
+	return " "
 }
 
 func New(formula string) *Editor {
-	// TODO: detect color from tty
-	renderer := render.FromFormula(formula, true)
-	cursor := render.Cursor{Symbol: "\x1b[7m \x1b[27m"}
+	renderer := render.FromFormula(formula, render.COLOR_TRUECOLOR)
+	cursor := render.Cursor{Symbol: cursorSymbol(render.COLOR_TRUECOLOR)}
 	renderer.LatexTree.AppendChildren(&cursor)
 	return &Editor{
 		renderer:   renderer,
@@ -65,6 +80,12 @@ func New(formula string) *Editor {
 func NewWithConfig(cfg EditorConfig, formula string) *Editor {
 	editor := New(formula)
 	editor.config = &cfg
+	editor.renderer.ASCII = cfg.ASCII
+	editor.renderer.Colors = cfg.Colors
+	editor.renderer.Theme = cfg.Theme
+	editor.renderer.Highlight = cfg.Highlight
+	editor.cursor.Symbol = cursorSymbol(cfg.Colors)
+	editor.renderer.Sync(editor.getLastOnStack(), false)
 	return editor
 }
 
@@ -336,14 +357,26 @@ func (e *Editor) containerGetSiblingRow(container parser.Container, cursorLoc pa
 	return nil
 }
 
-// Navigate cursor vertically (up or down)
+// Navigate cursor vertically (up or down): into the superscript or subscript
+// next to the cursor, else to the row above or below in the closest enclosing
+// \frac, \binom, environment or script, keeping the column, see cursorX
 func (e *Editor) navigateVertical(up bool) {
+	if e.enterAdjacentScript(up) {
+		return
+	}
+
 	var targetContainer parser.Container
 	var targetRow parser.FlexContainer
 
 	stackIdx := e.findEnclosingVerticallyNavigableCommand(len(e.traceStack) - 1)
 	for ; stackIdx > 0; stackIdx = e.findEnclosingVerticallyNavigableCommand(stackIdx - 1) {
 		targetContainer = e.traceStack[stackIdx]
+		if isScript(targetContainer) {
+			if e.navigateFromScript(stackIdx, up) {
+				return
+			}
+			continue
+		}
 		cursorLoc := e.traceStack[stackIdx+1]
 		targetRow = e.containerGetSiblingRow(targetContainer, cursorLoc, up)
 		if targetRow != nil {
@@ -355,6 +388,7 @@ func (e *Editor) navigateVertical(up bool) {
 		return
 	}
 
+	x := e.cursorX(stackIdx+1) + e.rowStart(targetContainer, e.traceStack[stackIdx+1]) - e.rowStart(targetContainer, targetRow)
 	idx := e.getCursorIdxInParent()
 	e.getParent().DeleteChildren(idx, idx)
 	for i := stackIdx + 1; i < len(e.traceStack); i++ {
@@ -362,7 +396,7 @@ func (e *Editor) navigateVertical(up bool) {
 	}
 	e.traceStack = e.traceStack[:stackIdx+1]
 
-	e.enterContainerFromLeft(targetRow)
+	e.enterRowAt(targetRow, x)
 }
 
 func (e *Editor) NavigateDown() { e.navigateVertical(false) }
@@ -626,10 +660,7 @@ func (e *Editor) flattenDeleteParent() {
 			return
 		} else if c == 0 {
 			forest.Elts[r-1] = append(forest.Elts[r-1], row...)
-			for r1 := r; r < len(forest.Elts); r1++ {
-				forest.Elts[r1] = forest.Elts[r1+1]
-			}
-			forest.Elts = forest.Elts[:len(forest.Elts)-1]
+			forest.DeleteRow(r)
 		} else {
 			idx := e.getCursorIdxInParent()
 			oldCell.DeleteChildren(idx, idx)
@@ -878,12 +909,43 @@ func (e *Editor) handleRest(char rune) {
 	}
 }
 
-// not in use yet
+// handles unicode math symbols and super/subscript characters, which are
+// turned into the nodes they stand for, e.g. "α" into \alpha and "²" into
+// ^{2}. A script typed right after a script of the same kind extends it
+func (e *Editor) handleUnicode(char rune) {
+	if e.hasSelection() {
+		e.deleteSelection()
+	}
+
+	idx := e.getCursorIdxInParent()
+	node := parser.Parse(string(char)).Children()[0]
+	if script, ok := node.(*parser.Cmd1ArgExpr); ok && idx > 0 {
+		if prev, ok := e.getParent().Children()[idx-1].(*parser.Cmd1ArgExpr); ok && prev.Type == script.Type {
+			if arg, ok := prev.Arg1.(parser.FlexContainer); ok {
+				arg.AppendChildren(script.Arg1.(parser.FlexContainer).Children()...)
+				return
+			}
+		}
+	}
+	e.getParent().InsertChildren(idx, node)
+}
+
+// whether char is read as a command or script by the parser, see handleUnicode
+func isUnicodeMath(char rune) bool {
+	if _, ok := parser.UnicodeToVanilla[string(char)]; ok {
+		return true
+	}
+	_, _, ok := parser.NormalizeScript(char)
+	return ok
+}
+
+// inserts the latex source v at the cursor, see Paste
 func (e *Editor) handlePaste(v string) {
 	idx := e.getCursorIdxInParent()
 
 	// TODO error handling, when the given string is not valid latex
 	ast := parser.Parse(v)
+	formatLatexTree(ast)
 	e.getParent().InsertChildren(idx, ast.Children()...)
 }
 
@@ -922,6 +984,10 @@ func (e *Editor) findEnclosingVerticallyNavigableCommand(searchFrom int) (index
 			case parser.CMD_frac, parser.CMD_binom:
 				return searchFrom
 			}
+		case *parser.Cmd1ArgExpr:
+			if isScript(n) {
+				return searchFrom
+			}
 		case *parser.EnvExpr:
 			return searchFrom
 		}
@@ -932,17 +998,29 @@ func (e *Editor) findEnclosingVerticallyNavigableCommand(searchFrom int) (index
 const KeybindsHelp = `
 	Arrow keys - move around
 	ctrl+p / ctrl+n / ctrl+f / ctrl+b - Up / Down / Left / Right
+	Up / Down - into the superscript / subscript next to the cursor, the other row of a fraction or matrix
 	alt+p / alt+n / alt+f / alt+b - Move around without entering a node
 	alt + Left/Right - Select Text
 	alt + w/W - Select Text
+	alt + Up/Down - Expand/shrink selection to the enclosing node
+	alt + x/C/v - Cut / copy the selection, paste it keeping its structure
 
 	ctrl + u - delete to start of node
 	ctrl + k - delete to end of node
+
+	alt + m - new matrix, asks for the size, e.g. 2x3
+	alt + r/R - insert/delete matrix row
+	alt + l/L - insert/delete matrix column
+	alt + y - duplicate matrix row
+	alt + t - transpose matrix
 	`
 
 func (e Editor) Update(msg tea.Msg) (Editor, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if !msg.Alt || msg.Type != tea.KeyUp && msg.Type != tea.KeyDown {
+			e.selectHistory = nil
+		}
 		switch msg.Type {
 		case tea.KeyLeft, tea.KeyCtrlB:
 			if msg.Alt {
@@ -963,11 +1041,21 @@ func (e Editor) Update(msg tea.Msg) (Editor, tea.Cmd) {
 			}
 			e.NavigateRight()
 		case tea.KeyDown, tea.KeyCtrlN:
+			if msg.Alt {
+				e.ShrinkSelection()
+				e.renderer.Sync(e.getLastOnStack(), e.markSelect != nil)
+				return e, nil
+			}
 			if e.markSelect != nil {
 				e.cancelSelection()
 			}
 			e.NavigateDown()
 		case tea.KeyUp, tea.KeyCtrlP:
+			if msg.Alt {
+				e.ExpandSelection()
+				e.renderer.Sync(e.getLastOnStack(), e.markSelect != nil)
+				return e, nil
+			}
 			if e.markSelect != nil {
 				e.cancelSelection()
 			}
@@ -1041,10 +1129,23 @@ func (e Editor) Update(msg tea.Msg) (Editor, tea.Cmd) {
 						e.selectRight()
 					case 'W':
 						e.selectLeft()
+					case 'x':
+						e.CutSelection()
+					case 'C':
+						e.CopySelection()
+					case 'v':
+						e.Paste()
+					case 'm', 'r', 'R', 'l', 'L', 'y', 't':
+						if e.markSelect != nil {
+							e.cancelSelection()
+						}
+						e.matrixCommand(msg.Runes[0])
 					}
 					break
 				}
 				switch {
+				case e.GetState() == EDIT_EQUATION && isUnicodeMath(msg.Runes[0]):
+					e.handleUnicode(msg.Runes[0])
 				case unicode.IsLetter(msg.Runes[0]):
 					e.handleLetter(msg.Runes[0])
 				case unicode.IsDigit(msg.Runes[0]):
@@ -1073,6 +1174,16 @@ func (e *Editor) realizeCommand() {
 	}
 
 	n := cmdInput.Text
+	if cmdInput.Prefix == matrixPrompt {
+		e.exitParent(DIR_RIGHT)
+		idx := e.getCursorIdxInParent()
+		e.getParent().DeleteChildren(idx-1, idx-1)
+		if rows, cols, ok := parseMatrixSize(n.BuildString()); ok {
+			e.InsertMatrix(rows, cols)
+		}
+		return
+	}
+
 	var cmd string
 	switch cmdInput.Prefix {
 	case `\`:
@@ -1119,6 +1230,20 @@ func (e Editor) LatexSource() string {
 	return e.config.LatexCfg.ProduceLatex(e.renderer.LatexTree)
 }
 
+// the latex source of the selection, or of the whole formula if nothing is
+// selected
+func (e Editor) SelectionSource() string {
+	if !e.hasSelection() {
+		return e.LatexSource()
+	}
+	selection := &parser.UnboundCompExpr{Elts: e.selectedNodes()}
+	return e.config.LatexCfg.ProduceLatex(selection)
+}
+
+func (e Editor) TypstSource() string {
+	return render.ProduceTypst(e.renderer.LatexTree)
+}
+
 func min(a int, b int) int {
 	if a < b {
 		return a
@@ -0,0 +1,61 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// key messages for typing text, one rune each
+func typed(text string) []tea.KeyMsg {
+	var msgs []tea.KeyMsg
+	for _, r := range text {
+		if r == ' ' {
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+			continue
+		}
+		msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return msgs
+}
+
+func key(t tea.KeyType) []tea.KeyMsg {
+	return []tea.KeyMsg{{Type: t}}
+}
+
+// alt+key, e.g. alt('m') or alt(tea.KeyUp)
+func alt(k interface{}) []tea.KeyMsg {
+	switch k := k.(type) {
+	case rune:
+		return []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune{k}, Alt: true}}
+	case tea.KeyType:
+		return []tea.KeyMsg{{Type: k, Alt: true}}
+	}
+	panic("alt: unexpected key")
+}
+
+// joins the key messages of typed, key and alt
+func keys(groups ...[]tea.KeyMsg) []tea.KeyMsg {
+	var msgs []tea.KeyMsg
+	for _, g := range groups {
+		msgs = append(msgs, g...)
+	}
+	return msgs
+}
+
+// a new editor with the formula, the cursor at its end, after the messages
+func run(t *testing.T, formula string, msgs []tea.KeyMsg) Editor {
+	t.Helper()
+	e := *NewWithConfig(EditorConfig{}, formula)
+	for _, msg := range msgs {
+		e, _ = e.Update(msg)
+	}
+	return e
+}
+
+// the LaTeX source with runs of whitespace, such as the line breaks between
+// matrix rows, collapsed to one space
+func source(e Editor) string {
+	return strings.Join(strings.Fields(e.LatexSource()), " ")
+}
@@ -0,0 +1,215 @@
+package editor
+
+import (
+	"strconv"
+	"strings"
+
+	parser "github.com/horriblename/mathcha/latex"
+	render "github.com/horriblename/mathcha/renderer"
+)
+
+// Matrix editing: the operations below act on the innermost environment
+// around the cursor, e.g. a matrix, and return false if there is none
+
+// the prefix of the prompt for the size of a new matrix, see InsertMatrix
+const matrixPrompt = `matrix (rows x columns):`
+
+// finds the innermost EnvExpr on the traceStack, its index and the row and
+// column of the cell containing the cursor; env is nil if there is none
+func (e *Editor) enclosingEnv() (env *parser.EnvExpr, stackIdx, row, col int) {
+	if e.GetState() != EDIT_EQUATION {
+		return nil, -1, 0, 0
+	}
+	for i := len(e.traceStack) - 2; i >= 0; i-- {
+		if env, ok := e.traceStack[i].(*parser.EnvExpr); ok {
+			row, col := env.FindCell(e.traceStack[i+1].(*parser.UnboundCompExpr))
+			return env, i, row, col
+		}
+	}
+	return nil, -1, 0, 0
+}
+
+// moves the cursor to the start of a cell of the EnvExpr at stackIdx
+func (e *Editor) moveToCell(stackIdx int, cell *parser.UnboundCompExpr) {
+	idx := e.getCursorIdxInParent()
+	e.getParent().DeleteChildren(idx, idx)
+	for i := stackIdx + 1; i < len(e.traceStack); i++ {
+		e.traceStack[i] = nil
+	}
+	e.traceStack = append(e.traceStack[:stackIdx+1], cell)
+	cell.InsertChildren(0, e.cursor)
+}
+
+// deletes the EnvExpr at stackIdx, leaving the cursor in its place
+func (e *Editor) deleteEnv(stackIdx int) {
+	env := e.traceStack[stackIdx]
+	idx := e.getCursorIdxInParent()
+	e.getParent().DeleteChildren(idx, idx)
+	for i := stackIdx; i < len(e.traceStack); i++ {
+		e.traceStack[i] = nil
+	}
+	e.traceStack = e.traceStack[:stackIdx]
+	for i, n := range e.getParent().Children() {
+		if n == env {
+			e.getParent().DeleteChildren(i, i)
+			e.getParent().InsertChildren(i, e.cursor)
+			return
+		}
+	}
+}
+
+// InsertMatrixRow inserts an empty row below the cursor, as wide as the
+// widest one, and moves the cursor to the same column in it
+func (e *Editor) InsertMatrixRow() bool {
+	env, stackIdx, row, col := e.enclosingEnv()
+	if env == nil {
+		return false
+	}
+	env.InsertRow(row + 1)
+	env.Normalize()
+	e.moveToCell(stackIdx, env.Elts[row+1][min(col, len(env.Elts[row+1])-1)])
+	return true
+}
+
+// DeleteMatrixRow deletes the row of the cursor, or the whole environment if
+// it is the only one
+func (e *Editor) DeleteMatrixRow() bool {
+	env, stackIdx, row, col := e.enclosingEnv()
+	if env == nil {
+		return false
+	}
+	if len(env.Elts) == 1 {
+		e.deleteEnv(stackIdx)
+		return true
+	}
+	env.DeleteRow(row)
+	row = min(row, len(env.Elts)-1)
+	e.moveToCell(stackIdx, env.Elts[row][min(col, len(env.Elts[row])-1)])
+	return true
+}
+
+// InsertMatrixColumn inserts an empty column right of the cursor and moves
+// the cursor into it
+func (e *Editor) InsertMatrixColumn() bool {
+	env, stackIdx, row, col := e.enclosingEnv()
+	if env == nil {
+		return false
+	}
+	env.InsertColumn(col + 1)
+	e.moveToCell(stackIdx, env.Elts[row][col+1])
+	return true
+}
+
+// DeleteMatrixColumn deletes the column of the cursor, or the whole
+// environment if it is the only one
+func (e *Editor) DeleteMatrixColumn() bool {
+	env, stackIdx, row, col := e.enclosingEnv()
+	if env == nil {
+		return false
+	}
+	if env.Width() == 1 {
+		e.deleteEnv(stackIdx)
+		return true
+	}
+	// so that no row is left empty
+	env.Normalize()
+	env.DeleteColumn(col)
+	e.moveToCell(stackIdx, env.Elts[row][min(col, len(env.Elts[row])-1)])
+	return true
+}
+
+// TransposeMatrix swaps the rows and columns, the cursor stays in its cell
+func (e *Editor) TransposeMatrix() bool {
+	env, _, _, _ := e.enclosingEnv()
+	if env == nil {
+		return false
+	}
+	env.Transpose()
+	return true
+}
+
+// DuplicateMatrixRow inserts a copy of the row of the cursor below it
+func (e *Editor) DuplicateMatrixRow() bool {
+	env, _, row, _ := e.enclosingEnv()
+	if env == nil {
+		return false
+	}
+	for _, cell := range env.DuplicateRow(row) {
+		parser.Rewrite(cell, func(n parser.Expr) parser.Expr {
+			if _, ok := n.(*render.Cursor); ok {
+				return nil
+			}
+			return n
+		})
+	}
+	return true
+}
+
+// InsertMatrix inserts a matrix of rows×cols empty cells at the cursor and
+// moves the cursor into the first one
+func (e *Editor) InsertMatrix(rows, cols int) {
+	node := parser.NewMatrix(parser.ENV_matrix, rows, cols)
+	idx := e.getCursorIdxInParent()
+	e.getParent().DeleteChildren(idx, idx)
+	e.getParent().InsertChildren(idx, node)
+	e.enterContainerFromLeft(node)
+}
+
+// runs the matrix operation bound to alt+key, see KeybindsHelp
+func (e *Editor) matrixCommand(key rune) {
+	switch key {
+	case 'm':
+		e.promptMatrix()
+	case 'r':
+		e.InsertMatrixRow()
+	case 'R':
+		e.DeleteMatrixRow()
+	case 'l':
+		e.InsertMatrixColumn()
+	case 'L':
+		e.DeleteMatrixColumn()
+	case 'y':
+		e.DuplicateMatrixRow()
+	case 't':
+		e.TransposeMatrix()
+	}
+}
+
+// opens the prompt for the size of a new matrix, see realizeCommand
+func (e *Editor) promptMatrix() {
+	if e.GetState() != EDIT_EQUATION {
+		return
+	}
+	field := &render.LatexCmdInput{
+		Prefix: matrixPrompt,
+		Text:   new(parser.TextStringWrapper),
+	}
+	idx := e.getCursorIdxInParent()
+	e.getParent().DeleteChildren(idx, idx)
+	e.getParent().InsertChildren(idx, field)
+	e.traceStack = append(e.traceStack, field, field.Text)
+	e.getParent().AppendChildren(e.cursor)
+}
+
+// the largest matrix that can be created with the size prompt
+const maxMatrixSize = 20
+
+// parses the size of a matrix, e.g. "2x3" or "2,3"; a single number is the
+// size of a square matrix
+func parseMatrixSize(size string) (rows, cols int, ok bool) {
+	parts := strings.FieldsFunc(size, func(r rune) bool {
+		return r == 'x' || r == ',' || r == '×'
+	})
+	if len(parts) == 1 {
+		parts = append(parts, parts[0])
+	}
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	rows, errRows := strconv.Atoi(parts[0])
+	cols, errCols := strconv.Atoi(parts[1])
+	if errRows != nil || errCols != nil || rows < 1 || cols < 1 || rows > maxMatrixSize || cols > maxMatrixSize {
+		return 0, 0, false
+	}
+	return rows, cols, true
+}
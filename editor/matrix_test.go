@@ -0,0 +1,88 @@
+package editor
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestMatrix(t *testing.T) {
+	// every case types a marker after the operation to show where the cursor is
+	testCases := []struct {
+		desc    string
+		formula string
+		msgs    []tea.KeyMsg
+		expect  string
+	}{
+		{
+			desc:   "insert matrix",
+			msgs:   keys(alt('m'), typed("2x2"), key(tea.KeyEnter), typed("a")),
+			expect: `\begin{matrix} a & \\ & \end{matrix}`,
+		},
+		{
+			desc:   "square size",
+			msgs:   keys(alt('m'), typed("2"), key(tea.KeyEnter), typed("a")),
+			expect: `\begin{matrix} a & \\ & \end{matrix}`,
+		},
+		{
+			desc:   "invalid size inserts nothing",
+			msgs:   keys(alt('m'), typed("0"), key(tea.KeyEnter), typed("a")),
+			expect: `a`,
+		},
+		{
+			desc:   "insert row",
+			msgs:   keys(alt('m'), typed("2"), key(tea.KeyEnter), typed("a"), alt('r'), typed("b")),
+			expect: `\begin{matrix} a & \\ b & \\ & \end{matrix}`,
+		},
+		{
+			desc:   "delete row",
+			msgs:   keys(alt('m'), typed("2"), key(tea.KeyEnter), typed("a"), alt('R'), typed("b")),
+			expect: `\begin{matrix} b & \end{matrix}`,
+		},
+		{
+			desc:   "insert column",
+			msgs:   keys(alt('m'), typed("2"), key(tea.KeyEnter), typed("a"), alt('l'), typed("b")),
+			expect: `\begin{matrix} a & b & \\ & & \end{matrix}`,
+		},
+		{
+			desc:   "delete column",
+			msgs:   keys(alt('m'), typed("2"), key(tea.KeyEnter), typed("a"), alt('L'), typed("b")),
+			expect: `\begin{matrix} b\\ \end{matrix}`,
+		},
+		{
+			desc:   "duplicate row",
+			msgs:   keys(alt('m'), typed("2"), key(tea.KeyEnter), typed("a"), alt('y'), typed("b")),
+			expect: `\begin{matrix} ab & \\ a & \\ & \end{matrix}`,
+		},
+		{
+			desc:   "transpose",
+			msgs:   keys(alt('m'), typed("2x3"), key(tea.KeyEnter), typed("a"), alt('t'), typed("b")),
+			expect: `\begin{matrix} ab & \\ & \\ & \end{matrix}`,
+		},
+		{
+			desc:    "parsed matrix",
+			formula: `\begin{matrix}a&b\\c&d\end{matrix}`,
+			expect:  `\begin{matrix} a & b\\ c & d \end{matrix}`,
+		},
+		{
+			desc:    "enter in a cell inserts a row",
+			formula: `\begin{matrix}a&b\\c&d\end{matrix}`,
+			msgs:    keys(key(tea.KeyLeft), key(tea.KeyEnter), typed("z")),
+			expect:  `\begin{matrix} a & b\\ c & d\\ z \end{matrix}`,
+		},
+		{
+			desc:    "operations outside a matrix do nothing",
+			formula: `x`,
+			msgs:    keys(alt('r'), alt('l'), alt('t'), typed("z")),
+			expect:  `xz`,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got := source(run(t, tC.formula, tC.msgs))
+			if got != tC.expect {
+				t.Errorf("got %q, want %q", got, tC.expect)
+			}
+		})
+	}
+}
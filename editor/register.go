@@ -0,0 +1,99 @@
+package editor
+
+import (
+	parser "github.com/horriblename/mathcha/latex"
+)
+
+// The register holds the nodes copied or cut with CopySelection and
+// CutSelection. Unlike the system clipboard, which only gets the latex source,
+// it keeps the structure of the copied nodes. It is shared by all editors, so
+// that nodes can be moved from one line to another
+
+var register []parser.Expr
+
+// the selected children of the parent, without the cursor and the selection
+// mark
+func (e *Editor) selectedNodes() []parser.Expr {
+	if !e.hasSelection() {
+		return nil
+	}
+	idx, mark := e.getCursorIdxInParent(), e.getSelectionIdxInParent()
+	if mark < idx {
+		idx, mark = mark, idx
+	}
+	return e.getParent().Children()[idx+1 : mark]
+}
+
+// CopySelection copies the selected nodes into the register. Returns false if
+// nothing is selected
+func (e *Editor) CopySelection() bool {
+	nodes := e.selectedNodes()
+	if len(nodes) == 0 {
+		return false
+	}
+	register = make([]parser.Expr, len(nodes))
+	for i, n := range nodes {
+		register[i] = parser.Clone(n)
+	}
+	return true
+}
+
+// CutSelection copies the selected nodes into the register and deletes them.
+// Returns false if nothing is selected
+func (e *Editor) CutSelection() bool {
+	if !e.CopySelection() {
+		return false
+	}
+	e.deleteSelection()
+	return true
+}
+
+// Paste inserts a copy of the register at the cursor, replacing the selection.
+// In a text field, the register is inserted as its latex source, unless it
+// was copied from a text; and a text pasted into a formula is read as latex.
+// Returns false if the register is empty
+func (e *Editor) Paste() bool {
+	if len(register) == 0 {
+		return false
+	}
+	if e.hasSelection() {
+		e.deleteSelection()
+	}
+	text, isText := registerText()
+
+	if parent, ok := e.getParent().(*parser.TextStringWrapper); ok {
+		if !isText {
+			text = e.config.LatexCfg.ProduceLatex(&parser.UnboundCompExpr{Elts: register})
+		}
+		runes := make([]parser.Expr, 0, len(text))
+		for _, r := range text {
+			runes = append(runes, parser.RawRuneLit(r))
+		}
+		parent.InsertChildren(e.getCursorIdxInParent(), runes...)
+		return true
+	}
+
+	if isText {
+		e.handlePaste(text)
+		return true
+	}
+	nodes := make([]parser.Expr, len(register))
+	for i, n := range register {
+		nodes[i] = parser.Clone(n)
+	}
+	e.getParent().InsertChildren(e.getCursorIdxInParent(), nodes...)
+	return true
+}
+
+// the register as a string if it was copied from a text
+func registerText() (text string, ok bool) {
+	runes := make([]rune, 0, len(register))
+	for _, n := range register {
+		r, ok := n.(parser.RawRuneLit)
+		if !ok {
+			return "", false
+		}
+		runes = append(runes, rune(r))
+	}
+	return string(runes), true
+}
@@ -0,0 +1,75 @@
+package editor
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRegister(t *testing.T) {
+	selectLeft := alt(tea.KeyLeft)
+	testCases := []struct {
+		desc    string
+		formula string
+		msgs    []tea.KeyMsg
+		expect  string
+	}{
+		{
+			desc:    "cut",
+			formula: `a+b`,
+			msgs:    keys(selectLeft, alt('x'), typed("c")),
+			expect:  `a+c`,
+		},
+		{
+			desc:    "cut and paste elsewhere",
+			formula: `a+b`,
+			msgs:    keys(selectLeft, alt('x'), key(tea.KeyHome), alt('v')),
+			expect:  `ba+`,
+		},
+		{
+			desc:    "copy keeps the selection",
+			formula: `a+b`,
+			msgs:    keys(selectLeft, alt('C'), typed("c")),
+			expect:  `a+c`,
+		},
+		{
+			desc:    "paste replaces the selection",
+			formula: `a+b`,
+			msgs:    keys(selectLeft, alt('C'), alt('v'), typed("c")),
+			expect:  `a+bc`,
+		},
+		{
+			desc:    "paste twice",
+			formula: `a+b`,
+			msgs:    keys(selectLeft, selectLeft, alt('C'), key(tea.KeyEnd), alt('v'), alt('v')),
+			expect:  `a+b+b+b`,
+		},
+		{
+			desc:    "paste keeps the structure",
+			formula: `x^{2}`,
+			msgs:    keys(alt(tea.KeyUp), alt('C'), key(tea.KeyRight), typed("+"), alt('v'), key(tea.KeyUp), typed("z")),
+			expect:  `x^{2}+x^{2z}`,
+		},
+		{
+			desc:    "copy without a selection",
+			formula: `a+b`,
+			msgs:    keys(alt('C'), alt('v')),
+			expect:  `a+b`,
+		},
+		{
+			desc:    "empty register",
+			formula: `a+b`,
+			msgs:    keys(alt('v')),
+			expect:  `a+b`,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			register = nil
+			got := source(run(t, tC.formula, tC.msgs))
+			if got != tC.expect {
+				t.Errorf("got %q, want %q", got, tC.expect)
+			}
+		})
+	}
+}
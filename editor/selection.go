@@ -0,0 +1,198 @@
+package editor
+
+import (
+	parser "github.com/horriblename/mathcha/latex"
+	render "github.com/horriblename/mathcha/renderer"
+)
+
+// Structural selection: ExpandSelection grows the selection to the enclosing
+// node of the tree, ShrinkSelection takes the steps back
+
+// a selection to go back to with ShrinkSelection
+type selectionState struct {
+	traceStack []parser.Container
+	// positions among the parent's children without the cursor and the
+	// selection mark; mark is -1 without a selection
+	cursor, mark int
+}
+
+// the selected children of the parent, as indices among the children without
+// the cursor and the selection mark; from > to if nothing is selected
+func (e *Editor) selectionBounds() (from, to int) {
+	if !e.hasSelection() {
+		return 0, -1
+	}
+	idx, mark := e.getCursorIdxInParent(), e.getSelectionIdxInParent()
+	if mark < idx {
+		idx, mark = mark, idx
+	}
+	return idx, mark - 2
+}
+
+// removes the cursor and the selection mark from the parent
+func (e *Editor) removeCursors() {
+	if e.hasSelection() {
+		mark := e.getSelectionIdxInParent()
+		e.getParent().DeleteChildren(mark, mark)
+		e.markSelect = nil
+	}
+	idx := e.getCursorIdxInParent()
+	e.getParent().DeleteChildren(idx, idx)
+}
+
+// selects the children of the parent from..to, which must not contain the
+// cursor, with the cursor at the end
+func (e *Editor) selectRange(from, to int) {
+	e.markSelect = new(render.Cursor)
+	e.getParent().InsertChildren(to+1, e.cursor)
+	e.getParent().InsertChildren(from, e.markSelect)
+}
+
+func (e *Editor) saveSelection() {
+	state := selectionState{
+		traceStack: append([]parser.Container(nil), e.traceStack...),
+		mark:       -1,
+	}
+	idx := e.getCursorIdxInParent()
+	state.cursor = idx
+	if e.hasSelection() {
+		mark := e.getSelectionIdxInParent()
+		state.mark = mark
+		if mark < idx {
+			state.cursor--
+		} else {
+			state.mark--
+		}
+	}
+	e.selectHistory = append(e.selectHistory, state)
+}
+
+// the node next to the cursor with its token, see token, as indices among the
+// children without the cursor; from > to if the parent is empty
+func (e *Editor) tokenAtCursor() (from, to int) {
+	idx := e.getCursorIdxInParent()
+	children := e.getParent().Children()
+	nodes := append(append([]parser.Expr(nil), children[:idx]...), children[idx+1:]...)
+	if len(nodes) == 0 {
+		return 0, -1
+	}
+	k := idx - 1
+	if k < 0 {
+		k = 0
+	}
+	return token(nodes, k)
+}
+
+// the bounds of nodes[k] with the rest of its number and its scripts, or its
+// base if it is a script, e.g. 12^{3} or x_{i}
+func token(nodes []parser.Expr, k int) (from, to int) {
+	from, to = k, k
+	// from a script to its base
+	for from > 0 && isScript(nodes[from]) {
+		from--
+	}
+	if _, ok := nodes[from].(*parser.SimpleOpLit); ok && isScript(nodes[k]) {
+		from++
+	}
+	for from > 0 && isDigit(nodes[from]) && isDigit(nodes[from-1]) {
+		from--
+	}
+	for to < len(nodes)-1 && isDigit(nodes[to]) && isDigit(nodes[to+1]) {
+		to++
+	}
+	for to < len(nodes)-1 && isScript(nodes[to+1]) {
+		to++
+	}
+	return from, to
+}
+
+func isDigit(n parser.Expr) bool {
+	_, ok := n.(*parser.NumberLit)
+	return ok
+}
+
+// ExpandSelection selects the token next to the cursor, then the whole group
+// it is in, then the command or environment enclosing the group and so on up
+// to the whole line. Returns false if the whole line is selected already
+func (e *Editor) ExpandSelection() bool {
+	state := e.GetState()
+	if state == EDIT_COMMAND {
+		return false
+	}
+	from, to := e.selectionBounds()
+	count := len(e.getParent().Children()) - 1
+	if e.hasSelection() {
+		count--
+	}
+
+	switch {
+	case state == EDIT_TEXT:
+		// a text is selected as a whole
+	case !e.hasSelection():
+		if from, to = e.tokenAtCursor(); from <= to {
+			e.saveSelection()
+			e.removeCursors()
+			e.selectRange(from, to)
+			return true
+		}
+	case from > 0 || to < count-1:
+		e.saveSelection()
+		e.removeCursors()
+		e.selectRange(0, count-1)
+		return true
+	}
+
+	// the enclosing node in the closest FlexContainer
+	for i := len(e.traceStack) - 2; i >= 0; i-- {
+		parent, ok := e.traceStack[i].(parser.FlexContainer)
+		if !ok {
+			continue
+		}
+		for j, child := range parent.Children() {
+			if child != e.traceStack[i+1] {
+				continue
+			}
+			e.saveSelection()
+			e.removeCursors()
+			for k := i + 1; k < len(e.traceStack); k++ {
+				e.traceStack[k] = nil
+			}
+			e.traceStack = e.traceStack[:i+1]
+			if isScript(child) {
+				e.selectRange(token(parent.Children(), j))
+			} else {
+				e.selectRange(j, j)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// ShrinkSelection goes back to the selection before the last
+// ExpandSelection, or cancels the selection if there is none
+func (e *Editor) ShrinkSelection() {
+	if len(e.selectHistory) == 0 {
+		if e.hasSelection() {
+			e.cancelSelection()
+		}
+		return
+	}
+	state := e.selectHistory[len(e.selectHistory)-1]
+	e.selectHistory = e.selectHistory[:len(e.selectHistory)-1]
+
+	e.removeCursors()
+	e.traceStack = state.traceStack
+	if state.mark < 0 {
+		e.getParent().InsertChildren(state.cursor, e.cursor)
+		return
+	}
+	e.markSelect = new(render.Cursor)
+	if state.mark < state.cursor {
+		e.getParent().InsertChildren(state.cursor, e.cursor)
+		e.getParent().InsertChildren(state.mark, e.markSelect)
+	} else {
+		e.getParent().InsertChildren(state.mark, e.markSelect)
+		e.getParent().InsertChildren(state.cursor, e.cursor)
+	}
+}
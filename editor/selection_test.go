@@ -0,0 +1,87 @@
+package editor
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestStructuralSelection(t *testing.T) {
+	expand, shrink := alt(tea.KeyUp), alt(tea.KeyDown)
+	testCases := []struct {
+		desc    string
+		formula string
+		msgs    []tea.KeyMsg
+		expect  string // selected LaTeX source, empty without a selection
+	}{
+		{
+			desc:    "token at cursor",
+			formula: `a+bc`,
+			msgs:    keys(expand),
+			expect:  `c`,
+		},
+		{
+			desc:    "whole line",
+			formula: `a+bc`,
+			msgs:    keys(expand, expand),
+			expect:  `a+bc`,
+		},
+		{
+			desc:    "nothing beyond the root",
+			formula: `a+bc`,
+			msgs:    keys(expand, expand, expand),
+			expect:  `a+bc`,
+		},
+		{
+			desc:    "shrink",
+			formula: `a+bc`,
+			msgs:    keys(expand, expand, shrink),
+			expect:  `c`,
+		},
+		{
+			desc:    "shrink to no selection",
+			formula: `a+bc`,
+			msgs:    keys(expand, shrink),
+			expect:  ``,
+		},
+		{
+			desc:    "script, then its base",
+			formula: `a+x^{2}`,
+			msgs:    keys(key(tea.KeyUp), expand, expand),
+			expect:  `x^{2}`,
+		},
+		{
+			desc:    "out of a script to the root",
+			formula: `a+x^{2}`,
+			msgs:    keys(key(tea.KeyUp), expand, expand, expand),
+			expect:  `a+x^{2}`,
+		},
+		{
+			desc:    "shrink back into a script",
+			formula: `a+x^{2}`,
+			msgs:    keys(key(tea.KeyUp), expand, expand, expand, shrink, shrink),
+			expect:  `2`,
+		},
+		{
+			desc:    "numerator",
+			formula: `\frac{ab}{c}+1`,
+			msgs:    keys(key(tea.KeyLeft), key(tea.KeyLeft), key(tea.KeyLeft), expand, expand),
+			expect:  `ab`,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			e := run(t, tC.formula, tC.msgs)
+			got := ""
+			if e.hasSelection() {
+				got = e.SelectionSource()
+			}
+			if got != tC.expect {
+				t.Errorf("got %q, want %q", got, tC.expect)
+			}
+			if src, want := e.LatexSource(), run(t, tC.formula, nil).LatexSource(); src != want {
+				t.Errorf("selecting changed the formula: %q", src)
+			}
+		})
+	}
+}
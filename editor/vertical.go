@@ -0,0 +1,203 @@
+package editor
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	parser "github.com/horriblename/mathcha/latex"
+)
+
+// Vertical navigation keeps the cursor in about the same rendered column: the
+// cursor's x-offset in its row is measured by rendering the nodes before it,
+// and it enters the target row at the position closest to that offset
+
+// the rendered width of nodes, without the cursor
+func (e *Editor) width(nodes []parser.Expr) int {
+	visible := make([]parser.Expr, 0, len(nodes))
+	for _, n := range nodes {
+		if n != e.cursor {
+			visible = append(visible, n)
+		}
+	}
+	if len(visible) == 0 {
+		return 0
+	}
+	str, _ := e.renderer.Prerender(&parser.UnboundCompExpr{Elts: visible})
+	return lipgloss.Width(str)
+}
+
+// the approximate x-offset of the cursor from the start of the row at
+// traceStack[from]: the width of the nodes before it on each level. The
+// decorations of the containers in between, e.g. a radical sign, are ignored
+func (e *Editor) cursorX(from int) int {
+	x := 0
+	for i := from; i < len(e.traceStack); i++ {
+		row, ok := e.traceStack[i].(parser.FlexContainer)
+		if !ok {
+			continue
+		}
+		var next parser.Expr = e.cursor
+		if i+1 < len(e.traceStack) {
+			next = e.traceStack[i+1]
+		}
+		for j, child := range row.Children() {
+			if child == next {
+				x += e.width(row.Children()[:j])
+				break
+			}
+		}
+	}
+	return x
+}
+
+// inserts the cursor into row at the position closest to the x-offset and
+// pushes row on the traceStack. The old cursor MUST be removed before
+func (e *Editor) enterRowAt(row parser.FlexContainer, x int) {
+	best, bestDist := 0, -1
+	for i := 0; i <= len(row.Children()); i++ {
+		dist := e.width(row.Children()[:i]) - x
+		if dist < 0 {
+			dist = -dist
+		}
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	row.InsertChildren(best, e.cursor)
+	e.traceStack = append(e.traceStack, row)
+}
+
+// the offset of a row of a centered container, e.g. the numerator of a \frac,
+// from the container's left edge
+func (e *Editor) rowStart(container parser.Container, row parser.Expr) int {
+	cmd, ok := container.(*parser.Cmd2ArgExpr)
+	if !ok {
+		return 0
+	}
+	width := 0
+	for _, arg := range cmd.Children() {
+		if w := e.width([]parser.Expr{arg}); w > width {
+			width = w
+		}
+	}
+	return (width - e.width([]parser.Expr{row})) / 2
+}
+
+func isScript(n parser.Expr) bool {
+	script, ok := n.(*parser.Cmd1ArgExpr)
+	return ok && (script.Type == parser.CMD_superscript || script.Type == parser.CMD_subscript)
+}
+
+// the bounds of the run of scripts (e.g. the limits of \sum) around index i of
+// nodes, inclusive; from > to if nodes[i] is not a script
+func scriptRun(nodes []parser.Expr, i int) (from, to int) {
+	if i < 0 || i >= len(nodes) || !isScript(nodes[i]) {
+		return 0, -1
+	}
+	from, to = i, i
+	for from > 0 && isScript(nodes[from-1]) {
+		from--
+	}
+	for to < len(nodes)-1 && isScript(nodes[to+1]) {
+		to++
+	}
+	return from, to
+}
+
+// the script of the given kind in nodes[from:to+1], or nil
+func findScript(nodes []parser.Expr, from, to int, kind parser.LatexCmd) *parser.Cmd1ArgExpr {
+	for i := from; i <= to; i++ {
+		if script := nodes[i].(*parser.Cmd1ArgExpr); script.Type == kind {
+			return script
+		}
+	}
+	return nil
+}
+
+// enters the superscript (up) or subscript of the base next to the cursor:
+// the scripts right after the cursor, or right before it. Returns false if
+// there is none
+func (e *Editor) enterAdjacentScript(up bool) bool {
+	if e.GetState() != EDIT_EQUATION {
+		return false
+	}
+	children := e.getParent().Children()
+	idx := e.getCursorIdxInParent()
+	from, to := scriptRun(children, idx+1)
+	if from > to {
+		from, to = scriptRun(children, idx-1)
+	}
+	if from > to {
+		return false
+	}
+	kind := parser.CMD_subscript
+	if up {
+		kind = parser.CMD_superscript
+	}
+	script := findScript(children, from, to, kind)
+	if script == nil {
+		return false
+	}
+	arg, ok := script.Arg1.(parser.FlexContainer)
+	if !ok {
+		return false
+	}
+
+	x := e.width(children[:idx]) - e.width(children[:from])
+	e.getParent().DeleteChildren(idx, idx)
+	e.traceStack = append(e.traceStack, script)
+	e.enterRowAt(arg, x)
+	return true
+}
+
+// moves from the script at traceStack[stackIdx] to the other script of its
+// run, e.g. from the upper to the lower limit of a \sum, or back to the base
+// line. Returns false if moving away from the base, e.g. up from a superscript
+func (e *Editor) navigateFromScript(stackIdx int, up bool) bool {
+	script := e.traceStack[stackIdx].(*parser.Cmd1ArgExpr)
+	if (script.Type == parser.CMD_superscript) == up {
+		return false
+	}
+	parent, ok := e.traceStack[stackIdx-1].(parser.FlexContainer)
+	if !ok {
+		return false
+	}
+	children := parent.Children()
+	scriptIdx := -1
+	for i, child := range children {
+		if child == script {
+			scriptIdx = i
+		}
+	}
+	from, to := scriptRun(children, scriptIdx)
+	if from > to {
+		return false
+	}
+
+	other := parser.CMD_superscript
+	if script.Type == parser.CMD_superscript {
+		other = parser.CMD_subscript
+	}
+	x := e.cursorX(stackIdx + 1)
+	scriptWidth := e.width([]parser.Expr{script.Arg1})
+
+	idx := e.getCursorIdxInParent()
+	e.getParent().DeleteChildren(idx, idx)
+	for i := stackIdx; i < len(e.traceStack); i++ {
+		e.traceStack[i] = nil
+	}
+	e.traceStack = e.traceStack[:stackIdx]
+
+	if sibling := findScript(children, from, to, other); sibling != nil {
+		if arg, ok := sibling.Arg1.(parser.FlexContainer); ok {
+			e.traceStack = append(e.traceStack, sibling)
+			e.enterRowAt(arg, x)
+			return true
+		}
+	}
+	// back to the base line, before or after the scripts
+	if 2*x < scriptWidth {
+		parent.InsertChildren(from, e.cursor)
+	} else {
+		parent.InsertChildren(to+1, e.cursor)
+	}
+	return true
+}
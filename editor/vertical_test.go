@@ -0,0 +1,98 @@
+package editor
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestVertical(t *testing.T) {
+	// every case types a marker after moving to show where the cursor is
+	testCases := []struct {
+		desc    string
+		formula string
+		msgs    []tea.KeyMsg
+		expect  string
+	}{
+		{
+			desc:    "up into superscript",
+			formula: `x^{2}_{1}`,
+			msgs:    keys(key(tea.KeyUp), typed("a")),
+			expect:  `x^{2a}_{1}`,
+		},
+		{
+			desc:    "down into subscript",
+			formula: `x^{2}_{1}`,
+			msgs:    keys(key(tea.KeyDown), typed("a")),
+			expect:  `x^{2}_{1a}`,
+		},
+		{
+			desc:    "superscript to subscript",
+			formula: `x^{2}_{1}`,
+			msgs:    keys(key(tea.KeyUp), key(tea.KeyDown), typed("a")),
+			expect:  `x^{2}_{1a}`,
+		},
+		{
+			desc:    "subscript to superscript",
+			formula: `x^{2}_{1}`,
+			msgs:    keys(key(tea.KeyDown), key(tea.KeyUp), typed("a")),
+			expect:  `x^{2a}_{1}`,
+		},
+		{
+			desc:    "superscript back to baseline",
+			formula: `x^{2}`,
+			msgs:    keys(key(tea.KeyUp), key(tea.KeyDown), typed("a")),
+			expect:  `x^{2}a`,
+		},
+		{
+			desc:    "up from superscript stays",
+			formula: `x^{2}`,
+			msgs:    keys(key(tea.KeyUp), key(tea.KeyUp), typed("a")),
+			expect:  `x^{2a}`,
+		},
+		{
+			desc:    "cursor before scripts enters at their start",
+			formula: `x^{2}`,
+			msgs:    keys(key(tea.KeyHome), key(tea.KeyRight), key(tea.KeyUp), typed("a")),
+			expect:  `x^{a2}`,
+		},
+		{
+			desc:    "no scripts next to the cursor",
+			formula: `x^{2}+y`,
+			msgs:    keys(key(tea.KeyUp), typed("a")),
+			expect:  `x^{2}+ya`,
+		},
+		{
+			desc:    "limits of a big operator",
+			formula: `\sum_{i=1}^{n}`,
+			msgs:    keys(key(tea.KeyUp), typed("a"), key(tea.KeyDown), typed("b")),
+			expect:  `\sum _{ib=1}^{na}`,
+		},
+		{
+			desc:    "denominator to numerator",
+			formula: `\frac{abcd}{e}`,
+			msgs:    keys(key(tea.KeyLeft), key(tea.KeyLeft), key(tea.KeyUp), typed("z")),
+			expect:  `\frac {abczd}{e}`,
+		},
+		{
+			desc:    "matrix row up",
+			formula: `\begin{matrix}a&b\\c&d\end{matrix}`,
+			msgs:    keys(key(tea.KeyLeft), key(tea.KeyUp), typed("z")),
+			expect:  `\begin{matrix} a & bz\\ c & d \end{matrix}`,
+		},
+		{
+			desc:    "matrix row up and down",
+			formula: `\begin{matrix}a&b\\c&d\end{matrix}`,
+			msgs:    keys(key(tea.KeyLeft), key(tea.KeyUp), key(tea.KeyDown), typed("z")),
+			expect:  `\begin{matrix} a & b\\ c & dz \end{matrix}`,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got := source(run(t, tC.formula, tC.msgs))
+			if got != tC.expect {
+				t.Errorf("got %q, want %q", got, tC.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+// Package equiv checks whether two formulas are mathematically the same, e.g.
+// \frac{1}{2}x and \frac{x}{2}, rather than structurally (see package diff).
+// Both are normalized symbolically first (see package cas); where that is
+// inconclusive they are evaluated at random values of their variables
+package equiv
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/horriblename/mathcha/cas"
+	"github.com/horriblename/mathcha/eval"
+	"github.com/horriblename/mathcha/semantic"
+)
+
+type Result int
+
+const (
+	Unknown Result = iota
+	Equal
+	NotEqual
+)
+
+func (r Result) String() string {
+	switch r {
+	case Equal:
+		return "equal"
+	case NotEqual:
+		return "not equal"
+	}
+	return "unknown"
+}
+
+func (r Result) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// Report is the result of a check and how it was found
+type Report struct {
+	Result Result `json:"result"`
+	// e.g. "symbolically" or "at 100 random points"
+	Reason string `json:"reason"`
+	// where the formulas differ, if found numerically, and their values there
+	At    eval.Vars `json:"at,omitempty"`
+	Left  *float64  `json:"left,omitempty"`
+	Right *float64  `json:"right,omitempty"`
+}
+
+func (r Report) String() string {
+	switch {
+	case r.At == nil:
+		return r.Result.String() + " (" + r.Reason + ")"
+	case len(r.At) == 0:
+		return fmt.Sprintf("%s (%s: %s vs %s)", r.Result, r.Reason, eval.Format(*r.Left), eval.Format(*r.Right))
+	}
+	names := make([]string, 0, len(r.At))
+	for name := range r.At {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	bindings := make([]string, len(names))
+	for i, name := range names {
+		bindings[i] = name + " = " + eval.Format(r.At[name])
+	}
+	return fmt.Sprintf("%s (%s: %s vs %s at %s)", r.Result, r.Reason,
+		eval.Format(*r.Left), eval.Format(*r.Right), strings.Join(bindings, ", "))
+}
+
+// Config configures the numeric testing. The zero value uses
+// defaultSamples points and a fixed seed, so results are reproducible
+type Config struct {
+	// the number of random points
+	Samples int
+	Seed    int64
+}
+
+const defaultSamples = 100
+
+// the fewest points in the domain of both formulas for a numeric result
+const minSamples = 10
+
+// Check compares two formulas. Expressions are equal if their difference
+// normalizes to 0, or if they have the same values at all random points in
+// the domain of both. Relations are equal if their sides are, equations also
+// if one is a multiple of the other, e.g. y = 2x and 2y - 4x = 0; other
+// relations are unknown. Comparing a relation with an expression is an error
+func (c Config) Check(a, b semantic.Node) (Report, error) {
+	ra, okA := a.(*semantic.Relation)
+	rb, okB := b.(*semantic.Relation)
+	switch {
+	case okA && okB:
+		return c.relations(ra, rb), nil
+	case okA || okB:
+		return Report{}, fmt.Errorf("cannot compare a relation with an expression")
+	}
+	return c.expressions(a, b), nil
+}
+
+// CheckSource parses, interprets and compares two formulas, see Check
+func (c Config) CheckSource(a, b string) (Report, error) {
+	na, err := semantic.Parse(a)
+	if err != nil {
+		return Report{}, err
+	}
+	nb, err := semantic.Parse(b)
+	if err != nil {
+		return Report{}, err
+	}
+	return c.Check(na, nb)
+}
+
+func (c Config) expressions(a, b semantic.Node) Report {
+	switch d := normalize(&semantic.Binary{Op: semantic.Sub, X: a, Y: b}).(type) {
+	case *semantic.Number:
+		if d.Value == 0 {
+			return Report{Result: Equal, Reason: "symbolically"}
+		}
+		return Report{Result: NotEqual, Reason: "symbolically, they differ by " + eval.Format(d.Value)}
+	}
+
+	points := c.points(a, b)
+	valid := 0
+	for _, vars := range points {
+		x, errA := eval.Value(a, vars)
+		y, errB := eval.Value(b, vars)
+		if errA != nil || errB != nil || math.IsInf(x, 0) || math.IsInf(y, 0) {
+			continue
+		}
+		if !approxEqual(x, y) {
+			return Report{Result: NotEqual, Reason: "numerically", At: vars, Left: &x, Right: &y}
+		}
+		valid++
+	}
+	return c.numericReport(valid, len(points))
+}
+
+func (c Config) numericReport(valid, total int) Report {
+	if total == 1 && valid == 1 {
+		return Report{Result: Equal, Reason: "numerically"}
+	}
+	if valid < minSamples {
+		return Report{Reason: fmt.Sprintf("only %d of %d random points are in the domain of both", valid, total)}
+	}
+	return Report{Result: Equal, Reason: fmt.Sprintf("numerically, at %d random points", valid)}
+}
+
+func (c Config) relations(a, b *semantic.Relation) Report {
+	if equalStrings(a.Ops, b.Ops) {
+		same := true
+		for i := range a.Args {
+			if c.expressions(a.Args[i], b.Args[i]).Result != Equal {
+				same = false
+				break
+			}
+		}
+		if same {
+			return Report{Result: Equal, Reason: "side by side"}
+		}
+	}
+	if len(a.Ops) != 1 || len(b.Ops) != 1 || a.Ops[0] != "=" || b.Ops[0] != "=" {
+		return Report{Reason: "only equations can be compared other than side by side"}
+	}
+
+	// x = y is a multiple of u = v if (x-y)/(u-v) is a constant
+	da := &semantic.Binary{Op: semantic.Sub, X: a.Args[0], Y: a.Args[1]}
+	db := &semantic.Binary{Op: semantic.Sub, X: b.Args[0], Y: b.Args[1]}
+	// x - y = ±(u - v)
+	for _, op := range []semantic.BinaryOp{semantic.Sub, semantic.Add} {
+		if isZero(normalize(&semantic.Binary{Op: op, X: da, Y: db})) {
+			return Report{Result: Equal, Reason: "symbolically"}
+		}
+	}
+	points := c.points(da, db)
+	ratio := math.NaN()
+	valid := 0
+	for _, vars := range points {
+		x, errA := eval.Value(da, vars)
+		y, errB := eval.Value(db, vars)
+		if errA != nil || errB != nil || x == 0 || y == 0 || math.IsInf(x, 0) || math.IsInf(y, 0) {
+			continue
+		}
+		if math.IsNaN(ratio) {
+			ratio = x / y
+		} else if !approxEqual(x/y, ratio) {
+			return Report{Reason: "the equations are not multiples of each other, but may have the same solutions"}
+		}
+		valid++
+	}
+	return c.numericReport(valid, len(points))
+}
+
+// the normal form of an expression: fractions simplified, then expanded
+func normalize(n semantic.Node) semantic.Node {
+	return cas.Simplify(cas.Expand(cas.Simplify(n)))
+}
+
+func isZero(n semantic.Node) bool {
+	number, ok := n.(*semantic.Number)
+	return ok && number.Value == 0
+}
+
+func approxEqual(x, y float64) bool {
+	return math.Abs(x-y) <= 1e-9*math.Max(1, math.Max(math.Abs(x), math.Abs(y)))
+}
+
+// random values for the free variables of both formulas. Variables in the
+// bounds of \sum and \prod and in \binom are integers, other ones are
+// positive at every other point, for functions like \sqrt and \ln
+func (c Config) points(a, b semantic.Node) []eval.Vars {
+	names := map[string]bool{}
+	for _, n := range []semantic.Node{a, b} {
+		for _, name := range semantic.FreeVars(n) {
+			names[name] = true
+		}
+	}
+	// constants, see eval.Eval
+	delete(names, "pi")
+	delete(names, "e")
+	if len(names) == 0 {
+		return []eval.Vars{{}}
+	}
+	integers := map[string]bool{}
+	integerVars(a, integers)
+	integerVars(b, integers)
+
+	samples := c.Samples
+	if samples <= 0 {
+		samples = defaultSamples
+	}
+	r := rand.New(rand.NewSource(c.Seed))
+	points := make([]eval.Vars, samples)
+	for i := range points {
+		points[i] = eval.Vars{}
+		// in a fixed order, for reproducible points
+		for _, name := range sortedKeys(names) {
+			switch {
+			case integers[name]:
+				points[i][name] = float64(r.Intn(13))
+			case i%2 == 0:
+				points[i][name] = 0.1 + r.Float64()*5
+			default:
+				points[i][name] = r.Float64()*10 - 5
+			}
+		}
+	}
+	return points
+}
+
+// adds the variables that must be integers to vars
+func integerVars(n semantic.Node, vars map[string]bool) {
+	switch n := n.(type) {
+	case *semantic.Neg:
+		integerVars(n.X, vars)
+	case *semantic.Binary:
+		integerVars(n.X, vars)
+		integerVars(n.Y, vars)
+	case *semantic.Frac:
+		integerVars(n.Num, vars)
+		integerVars(n.Den, vars)
+	case *semantic.Power:
+		integerVars(n.Base, vars)
+		integerVars(n.Exp, vars)
+	case *semantic.Apply:
+		integerVars(n.Arg, vars)
+	case *semantic.Binomial:
+		for _, name := range append(semantic.FreeVars(n.N), semantic.FreeVars(n.K)...) {
+			vars[name] = true
+		}
+	case *semantic.BigOp:
+		for _, name := range append(semantic.FreeVars(n.From), semantic.FreeVars(n.To)...) {
+			vars[name] = true
+		}
+		integerVars(n.Body, vars)
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package equiv
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/horriblename/mathcha/eval"
+)
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		a, b   string
+		expect string
+	}{
+		{`\frac{1}{2}x`, `\frac{x}{2}`, `equal (symbolically)`},
+		{`(x+1)^2`, `x^2+2x+1`, `equal (symbolically)`},
+		{`\frac{x^2-1}{x+1}`, `x-1`, `equal (symbolically)`},
+		{`2 \cdot 3`, `6`, `equal (symbolically)`},
+		{`x+1`, `x+2`, `not equal (symbolically, they differ by -1)`},
+		{`\sin^2 x + \cos^2 x`, `1`, `equal (numerically, at 100 random points)`},
+		{`\sqrt{x^2}`, `|x|`, `equal (numerically, at 100 random points)`},
+		{`\sum_{i=1}^{n} i`, `\frac{n(n+1)}{2}`, `equal (numerically, at 100 random points)`},
+		{`\binom{n}{2}`, `\frac{n(n-1)}{2}`, `equal (numerically, at 100 random points)`},
+		{`\pi`, `3.14`, `not equal (numerically: 3.14159265359 vs 3.14)`},
+		{`\sqrt{-1-x^2}`, `0`, `unknown (only 0 of 100 random points are in the domain of both)`},
+		{`y = x`, `x = y`, `equal (symbolically)`},
+		{`y = 2x`, `2y - 4x = 0`, `equal (numerically, at 100 random points)`},
+		{`x < 1`, `x < 1`, `equal (side by side)`},
+		{`x = 1`, `x = 2`, `unknown (the equations are not multiples of each other, but may have the same solutions)`},
+	}
+
+	for _, tc := range testCases {
+		report, err := Config{}.CheckSource(tc.a, tc.b)
+		if err != nil {
+			t.Errorf("%s vs %s: unexpected error %s", tc.a, tc.b, err)
+			continue
+		}
+		if report.String() != tc.expect {
+			t.Errorf("%s vs %s: got %q, expected %q", tc.a, tc.b, report, tc.expect)
+		}
+	}
+}
+
+func TestCheckCounterexample(t *testing.T) {
+	report, err := Config{}.CheckSource(`\sqrt{x^2}`, `x`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, ok := report.At["x"]
+	if report.Result != NotEqual || !ok || x >= 0 || *report.Left != -x || *report.Right != x {
+		t.Errorf("expected a negative x as counterexample, got %s", report)
+	}
+}
+
+func TestReportJSON(t *testing.T) {
+	left, right := 0.0, 1.0
+	report := Report{Result: NotEqual, Reason: "numerically", At: eval.Vars{"x": 0}, Left: &left, Right: &right}
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"result":"not equal","reason":"numerically","at":{"x":0},"left":0,"right":1}`
+	if string(data) != expect {
+		t.Errorf("got %s, expected %s", data, expect)
+	}
+
+	data, err = json.Marshal(Report{Result: Equal, Reason: "symbolically"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := `{"result":"equal","reason":"symbolically"}`; string(data) != expect {
+		t.Errorf("got %s, expected %s", data, expect)
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	testCases := map[[2]string]string{
+		{`y = x`, `x`}: `cannot compare a relation with an expression`,
+		{`a/bc`, `x`}:  `ambiguous division a/bc, use \frac or parentheses`,
+	}
+	for input, expect := range testCases {
+		_, err := Config{}.CheckSource(input[0], input[1])
+		if err == nil || err.Error() != expect {
+			t.Errorf("%s vs %s: got error %v, expected %q", input[0], input[1], err, expect)
+		}
+	}
+}
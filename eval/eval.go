@@ -0,0 +1,257 @@
+// Package eval computes the numeric value of a formula, e.g. 7 for
+// "\frac{1}{2} + 3x^2" with x = 1.5, for sanity-checking typed formulas
+package eval
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/horriblename/mathcha/latex"
+	"github.com/horriblename/mathcha/semantic"
+)
+
+// Vars binds the variables of a formula to values, by their names (see
+// semantic.Var), e.g. "x", "alpha" or "x_1"
+type Vars map[string]float64
+
+// the most terms summed up by \sum or multiplied by \prod
+const maxIterations = 1_000_000
+
+// Eval computes the value of a formula, usually the root returned by
+// latex.Parse, as interpreted by semantic.FromLatex. \pi and e are constants
+// unless bound in vars. A formula ending with "=" is evaluated without it;
+// other relations are errors, as are results that are not real numbers, e.g.
+// \sqrt{-1} or \frac{1}{0}
+func Eval(node latex.Expr, vars Vars) (float64, error) {
+	if c, ok := node.(latex.FlexContainer); ok {
+		nodes := c.Children()
+		if n := len(nodes); n > 0 {
+			if op, ok := nodes[n-1].(*latex.SimpleOpLit); ok && op.Source == "=" {
+				node = &latex.UnboundCompExpr{Elts: nodes[:n-1]}
+			}
+		}
+	}
+	tree, err := semantic.FromLatex(node)
+	if err != nil {
+		return 0, err
+	}
+	return Value(tree, vars)
+}
+
+// Value computes the value of an interpreted formula, see Eval
+func Value(n semantic.Node, vars Vars) (float64, error) {
+	e := evaluator{vars: Vars{}}
+	for name, value := range vars {
+		e.vars[name] = value
+	}
+	value, err := e.eval(n)
+	switch {
+	case err != nil:
+	case math.IsNaN(value):
+		err = fmt.Errorf("the result is not a real number")
+	case math.IsInf(value, 0):
+		err = fmt.Errorf("the result is infinite: division by zero or overflow")
+	}
+	return value, err
+}
+
+// EvalSource parses and evaluates a formula, see Eval
+func EvalSource(src string, vars Vars) (float64, error) {
+	tree, err := latex.ParseSafe(src)
+	if err != nil {
+		return 0, err
+	}
+	return Eval(tree, vars)
+}
+
+// Format writes a value with up to 12 significant digits, e.g. "0.3" for
+// 0.1+0.2
+func Format(value float64) string {
+	return strconv.FormatFloat(value, 'g', 12, 64)
+}
+
+// Bind parses a binding like "x=2", "x_1 = 3" or "\alpha=\pi/2" and adds it to
+// vars. The value is itself a formula, evaluated with the bindings so far
+func Bind(vars Vars, binding string) error {
+	i := strings.IndexByte(binding, '=')
+	if i < 0 {
+		return fmt.Errorf("binding %q: expected name=value", binding)
+	}
+	name, err := semantic.VarName(binding[:i])
+	if err != nil {
+		return fmt.Errorf("binding %q: %w", binding, err)
+	}
+	value, err := EvalSource(binding[i+1:], vars)
+	if err != nil {
+		return fmt.Errorf("binding %q: %w", binding, err)
+	}
+	vars[name] = value
+	return nil
+}
+
+type evaluator struct {
+	vars Vars
+}
+
+func (e *evaluator) eval(node semantic.Node) (float64, error) {
+	switch n := node.(type) {
+	case *semantic.Number:
+		return n.Value, nil
+	case *semantic.Var:
+		if value, ok := e.vars[n.Name]; ok {
+			return value, nil
+		}
+		switch n.Name {
+		case "pi":
+			return math.Pi, nil
+		case "e":
+			return math.E, nil
+		}
+		return 0, fmt.Errorf("unbound variable %s", n.Name)
+	case *semantic.Neg:
+		x, err := e.eval(n.X)
+		return -x, err
+	case *semantic.Binary:
+		x, y, err := e.eval2(n.X, n.Y)
+		switch n.Op {
+		case semantic.Add:
+			return x + y, err
+		case semantic.Sub:
+			return x - y, err
+		case semantic.Mul:
+			return x * y, err
+		default:
+			return x / y, err
+		}
+	case *semantic.Frac:
+		x, y, err := e.eval2(n.Num, n.Den)
+		return x / y, err
+	case *semantic.Power:
+		x, y, err := e.eval2(n.Base, n.Exp)
+		return math.Pow(x, y), err
+	case *semantic.Apply:
+		return e.apply(n)
+	case *semantic.Binomial:
+		x, y, err := e.eval2(n.N, n.K)
+		if err != nil {
+			return 0, err
+		}
+		return binomial(x, y)
+	case *semantic.BigOp:
+		return e.bigOp(n)
+	case *semantic.Relation:
+		return 0, fmt.Errorf("cannot evaluate the relation %s", n.Ops[0])
+	}
+	return 0, fmt.Errorf("cannot evaluate %s", node)
+}
+
+func (e *evaluator) eval2(a, b semantic.Node) (x, y float64, err error) {
+	if x, err = e.eval(a); err != nil {
+		return 0, 0, err
+	}
+	y, err = e.eval(b)
+	return x, y, err
+}
+
+var functions = map[string]func(float64) float64{
+	"sin":     math.Sin,
+	"cos":     math.Cos,
+	"tan":     math.Tan,
+	"sec":     func(x float64) float64 { return 1 / math.Cos(x) },
+	"csc":     func(x float64) float64 { return 1 / math.Sin(x) },
+	"cot":     func(x float64) float64 { return 1 / math.Tan(x) },
+	"sinh":    math.Sinh,
+	"cosh":    math.Cosh,
+	"tanh":    math.Tanh,
+	"coth":    func(x float64) float64 { return 1 / math.Tanh(x) },
+	"arcsin":  math.Asin,
+	"arccos":  math.Acos,
+	"arctan":  math.Atan,
+	"arcsinh": math.Asinh,
+	"arccosh": math.Acosh,
+	"arctanh": math.Atanh,
+	"ln":      math.Log,
+	"lg":      math.Log10,
+	"log":     math.Log10, // without a base
+	"sqrt":    math.Sqrt,
+	"abs":     math.Abs,
+}
+
+func (e *evaluator) apply(n *semantic.Apply) (float64, error) {
+	x, err := e.eval(n.Arg)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case n.Func == "factorial":
+		if x < 0 || x != math.Trunc(x) {
+			return 0, fmt.Errorf("factorial of %s", Format(x))
+		}
+		return math.Gamma(x + 1), nil
+	case n.Base != nil:
+		base, err := e.eval(n.Base)
+		return math.Log(x) / math.Log(base), err
+	case functions[n.Func] != nil:
+		return functions[n.Func](x), nil
+	}
+	return 0, fmt.Errorf("cannot evaluate \\%s", n.Func)
+}
+
+// the variable of a sum or product is bound to each integer from the lower to
+// the upper bound in turn
+func (e *evaluator) bigOp(n *semantic.BigOp) (float64, error) {
+	first, last, err := e.eval2(n.From, n.To)
+	if err != nil {
+		return 0, err
+	}
+	if first != math.Trunc(first) || last != math.Trunc(last) {
+		return 0, fmt.Errorf("the bounds of \\sum and \\prod must be integers")
+	}
+	if last-first >= maxIterations {
+		return 0, fmt.Errorf("too many terms")
+	}
+
+	old, bound := e.vars[n.Var]
+	defer func() {
+		if bound {
+			e.vars[n.Var] = old
+		} else {
+			delete(e.vars, n.Var)
+		}
+	}()
+
+	result := 0.0
+	if n.Op == semantic.Prod {
+		result = 1
+	}
+	for i := first; i <= last; i++ {
+		e.vars[n.Var] = i
+		x, err := e.eval(n.Body)
+		if err != nil {
+			return 0, err
+		}
+		if n.Op == semantic.Prod {
+			result *= x
+		} else {
+			result += x
+		}
+	}
+	return result, nil
+}
+
+// n choose k
+func binomial(n, k float64) (float64, error) {
+	if n != math.Trunc(n) || k != math.Trunc(k) || n < 0 {
+		return 0, fmt.Errorf("binomial coefficient of non-natural numbers")
+	}
+	if k < 0 || k > n {
+		return 0, nil
+	}
+	result := 1.0
+	for i := 1.0; i <= k; i++ {
+		result = result * (n - k + i) / i
+	}
+	return result, nil
+}
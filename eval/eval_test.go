@@ -0,0 +1,127 @@
+package eval
+
+import (
+	"math"
+	"testing"
+
+	"github.com/horriblename/mathcha/latex"
+)
+
+func TestEval(t *testing.T) {
+	vars := Vars{"x": 2, "y": 3, "alpha": 0.5, "x_1": 10}
+	testCases := []struct {
+		input  string
+		expect float64
+	}{
+		{`1+2\cdot3`, 7},
+		{`2^{3^2}`, 512},
+		{`-3^2`, -9},
+		{`1 - 2 - 3`, -4},
+		{`12/4 \times 3`, 9},
+		{`3.25 \div 0.5`, 6.5},
+		{`2xy`, 12},
+		{`2(x+1)`, 6},
+		{`(x+1)(y-1)`, 6},
+		{`[x+1]^2`, 9},
+		{`|1 - y|`, 2},
+		{`\left( x + 1 \right) y`, 9},
+		{`\frac{1}{2} + 3x^2`, 12.5},
+		{`\sqrt{x^2 + 5}`, 3},
+		{`\binom{5}{2}`, 10},
+		{`4!`, 24},
+		{`2\pi`, 2 * math.Pi},
+		{`e^2`, math.E * math.E},
+		{`\sin \frac{\pi}{2}`, 1},
+		{`\sin^2 x + \cos^2 x`, 1},
+		{`\cos(0)y`, 3},
+		{`\sin^{-1} 1`, math.Pi / 2},
+		{`\tan^{-1}(x - 1)`, math.Pi / 4},
+		{`\ln e^3`, 3},
+		{`\log 1000`, 3},
+		{`\log_2 8`, 3},
+		{`2\alpha`, 1},
+		{`x_1 + x_{1} + x`, 22},
+		{`\sum_{i=1}^{4} i^2 + 1`, 31},
+		{`\sum_{k=1}^{x} \sum_{j=1}^{k} 1`, 3},
+		{`\prod_{i=1}^{4} i`, 24},
+		{`\sum_{i=3}^{1} i + 1`, 1},
+		{`x^2 =`, 4},
+	}
+
+	for _, tc := range testCases {
+		got, err := Eval(latex.Parse(tc.input), vars)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", tc.input, err)
+			continue
+		}
+		if math.Abs(got-tc.expect) > 1e-9 {
+			t.Errorf("%s: got %v, expected %v", tc.input, got, tc.expect)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect string
+	}{
+		{`x + z`, `unbound variable z`},
+		{`x_2`, `unbound variable x_2`},
+		{`\sqrt{-1}`, `the result is not a real number`},
+		{`1/0`, `the result is infinite: division by zero or overflow`},
+		{`10^{400}`, `the result is infinite: division by zero or overflow`},
+		{`x = 2`, `cannot evaluate the relation =`},
+		{`1 +`, `missing operand after +`},
+		{`2^3^2`, `double superscript, use braces to nest them`},
+		{`a/bc`, `ambiguous division a/bc, use \frac or parentheses`},
+		{`\frac{1}{}`, `missing operand`},
+		{`(1 + 2`, `unmatched (`},
+		{`\det x`, `cannot interpret \det`},
+		{`\sum_{i=1} i`, `\sum and \prod need both bounds, e.g. \sum_{i=1}^{n}`},
+		{`\sum_{i=1}^{1.5} i`, `the bounds of \sum and \prod must be integers`},
+		{`2.5!`, `factorial of 2.5`},
+		{`\cot^{-1} x`, `ambiguous \cot^{-1}, write \frac{1}{\cot x} for the reciprocal`},
+	}
+
+	for _, tc := range testCases {
+		_, err := Eval(latex.Parse(tc.input), Vars{"x": 1})
+		if err == nil || err.Error() != tc.expect {
+			t.Errorf("%s: got error %v, expected %q", tc.input, err, tc.expect)
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	vars := Vars{}
+	for _, binding := range []string{"x=2", `y = \frac{x}{4}`, `\alpha=1`, `beta=2`, `x_{1}=3`} {
+		if err := Bind(vars, binding); err != nil {
+			t.Fatalf("%s: unexpected error %s", binding, err)
+		}
+	}
+	expect := Vars{"x": 2, "y": 0.5, "alpha": 1, "beta": 2, "x_1": 3}
+	for name, value := range expect {
+		if vars[name] != value {
+			t.Errorf("%s: got %v, expected %v", name, vars[name], value)
+		}
+	}
+
+	for _, binding := range []string{"x", "xy=1", "x=z"} {
+		if err := Bind(vars, binding); err == nil {
+			t.Errorf("%s: expected an error", binding)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	testCases := map[float64]string{
+		0.1 + 0.2: "0.3",
+		1e20:      "1e+20",
+		-2:        "-2",
+		math.Pi:   "3.14159265359",
+	}
+	for value, expect := range testCases {
+		if got := Format(value); got != expect {
+			t.Errorf("%v: got %q, expected %q", value, got, expect)
+		}
+	}
+}
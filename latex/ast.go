@@ -279,7 +279,7 @@ func (x *NumberLit) End() Pos         { return x.To }
 func (x *VarLit) End() Pos            { return x.To }
 func (x *TextContainer) End() Pos     { return x.To }
 func (x *TextStringWrapper) End() Pos { return 0 }
-func (x *CompositeExpr) End() Pos     { return x.Lbrace }
+func (x *CompositeExpr) End() Pos     { return x.Rbrace }
 func (x *UnboundCompExpr) End() Pos   { return x.To }
 func (x *EnvExpr) End() Pos           { return x.To }
 func (x *ParenCompExpr) End() Pos     { return x.To }
@@ -445,19 +445,23 @@ func (e *EnvExpr) FindCell(cell0 *UnboundCompExpr) (row int, cell int) {
 	panic("EnvExpr.FindCell called with cell with no relation to the EnvExpr")
 }
 
+// InsertCell inserts an empty cell at index cell of a row, shifting the cells
+// after it to the right. Missing rows and cells before it are added empty
 func (e *EnvExpr) InsertCell(row int, cell int) *UnboundCompExpr {
-	if row >= len(e.Elts) {
-		e.Elts = append(e.Elts, make([][]*UnboundCompExpr, row-len(e.Elts)+1)...)
+	for len(e.Elts) <= row {
+		e.Elts = append(e.Elts, nil)
 	}
-	if cell >= len(e.Elts[row]) {
-		e.Elts[row] = append(e.Elts[row], make([]*UnboundCompExpr, cell-len(e.Elts[row])+1)...)
+	for len(e.Elts[row]) < cell {
+		e.Elts[row] = append(e.Elts[row], &UnboundCompExpr{})
 	}
 	newCell := &UnboundCompExpr{}
+	e.Elts[row] = append(e.Elts[row], nil)
 	copy(e.Elts[row][cell+1:], e.Elts[row][cell:])
 	e.Elts[row][cell] = newCell
 	return newCell
 }
 
+// InsertRow inserts a row with one empty cell at index row, see Normalize
 func (e *EnvExpr) InsertRow(row int) *UnboundCompExpr {
 	if row >= len(e.Elts) {
 		newRow := []*UnboundCompExpr{{}}
@@ -465,10 +469,94 @@ func (e *EnvExpr) InsertRow(row int) *UnboundCompExpr {
 		return newRow[0]
 	}
 	newRow := []*UnboundCompExpr{{}}
-	e.Elts = append(e.Elts[:row+1], append([][]*UnboundCompExpr{newRow}, e.Elts[row+1:]...)...)
+	e.Elts = append(e.Elts[:row], append([][]*UnboundCompExpr{newRow}, e.Elts[row:]...)...)
 	return newRow[0]
 }
 
+// NewMatrix returns an environment of rows×cols empty cells
+func NewMatrix(name EnvName, rows, cols int) *EnvExpr {
+	e := &EnvExpr{Name: name, Elts: make([][]*UnboundCompExpr, rows)}
+	for r := range e.Elts {
+		e.Elts[r] = make([]*UnboundCompExpr, cols)
+		for c := range e.Elts[r] {
+			e.Elts[r][c] = &UnboundCompExpr{}
+		}
+	}
+	return e
+}
+
+// Width returns the number of columns, the length of the longest row
+func (e *EnvExpr) Width() int {
+	width := 0
+	for _, row := range e.Elts {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	return width
+}
+
+// Normalize appends empty cells to the rows shorter than the longest one
+func (e *EnvExpr) Normalize() {
+	width := e.Width()
+	for r, row := range e.Elts {
+		for len(row) < width {
+			row = append(row, &UnboundCompExpr{})
+		}
+		e.Elts[r] = row
+	}
+}
+
+// InsertColumn normalizes the rows and inserts an empty cell at index col of
+// each of them
+func (e *EnvExpr) InsertColumn(col int) {
+	e.Normalize()
+	for r := range e.Elts {
+		e.InsertCell(r, col)
+	}
+}
+
+// DeleteRow deletes the row at index row
+func (e *EnvExpr) DeleteRow(row int) {
+	e.Elts = append(e.Elts[:row], e.Elts[row+1:]...)
+}
+
+// DeleteColumn deletes the cell at index col of each row that has one, and the
+// rows left empty
+func (e *EnvExpr) DeleteColumn(col int) {
+	rows := e.Elts[:0]
+	for _, row := range e.Elts {
+		if col < len(row) {
+			row = append(row[:col], row[col+1:]...)
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	e.Elts = rows
+}
+
+// DuplicateRow inserts a copy of the row at index row after it, see Clone
+func (e *EnvExpr) DuplicateRow(row int) []*UnboundCompExpr {
+	newRow := cloneRow(e.Elts[row])
+	e.Elts = append(e.Elts[:row+1], append([][]*UnboundCompExpr{newRow}, e.Elts[row+1:]...)...)
+	return newRow
+}
+
+// Transpose normalizes the rows and swaps them with the columns
+func (e *EnvExpr) Transpose() {
+	e.Normalize()
+	width := e.Width()
+	cols := make([][]*UnboundCompExpr, width)
+	for c := range cols {
+		cols[c] = make([]*UnboundCompExpr, len(e.Elts))
+		for r, row := range e.Elts {
+			cols[c][r] = row[c]
+		}
+	}
+	e.Elts = cols
+}
+
 // ----------------------------------------------------------------------------
 // VisualizeTree, naive approach, only for debugging purposes
 func (x *UnboundCompExpr) VisualizeTree() string {
@@ -0,0 +1,79 @@
+package latex
+
+import "testing"
+
+func TestEnvExpr(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		input, expect string
+		f             func(e *EnvExpr)
+	}{
+		{
+			desc:   "insert cell",
+			input:  `\begin{matrix}a & c\end{matrix}`,
+			expect: `\begin{matrix}a & & c\end{matrix}`,
+			f:      func(e *EnvExpr) { e.InsertCell(0, 1) },
+		},
+		{
+			desc:   "insert row",
+			input:  `\begin{matrix}a \\ c\end{matrix}`,
+			expect: `\begin{matrix}a \\ \\ c\end{matrix}`,
+			f:      func(e *EnvExpr) { e.InsertRow(1) },
+		},
+		{
+			desc:   "normalize",
+			input:  `\begin{matrix}a & b & c \\ d\end{matrix}`,
+			expect: `\begin{matrix}a & b & c \\ d & & \end{matrix}`,
+			f:      func(e *EnvExpr) { e.Normalize() },
+		},
+		{
+			desc:   "insert column",
+			input:  `\begin{matrix}a & b \\ c\end{matrix}`,
+			expect: `\begin{matrix}a & & b \\ c & & \end{matrix}`,
+			f:      func(e *EnvExpr) { e.InsertColumn(1) },
+		},
+		{
+			desc:   "delete row",
+			input:  `\begin{matrix}a \\ b \\ c\end{matrix}`,
+			expect: `\begin{matrix}a \\ c\end{matrix}`,
+			f:      func(e *EnvExpr) { e.DeleteRow(1) },
+		},
+		{
+			desc:   "delete column",
+			input:  `\begin{matrix}a & b \\ c \\ d & e\end{matrix}`,
+			expect: `\begin{matrix}b \\ e\end{matrix}`,
+			f:      func(e *EnvExpr) { e.DeleteColumn(0) },
+		},
+		{
+			desc:   "duplicate row",
+			input:  `\begin{matrix}a & \frac{1}{2} \\ c & d\end{matrix}`,
+			expect: `\begin{matrix}a & \frac{1}{2} \\ a & \frac{1}{2} \\ c & d\end{matrix}`,
+			f:      func(e *EnvExpr) { e.DuplicateRow(0) },
+		},
+		{
+			desc:   "transpose",
+			input:  `\begin{matrix}a & b & c \\ d\end{matrix}`,
+			expect: `\begin{matrix}a & d \\ b & \\ c & \end{matrix}`,
+			f:      func(e *EnvExpr) { e.Transpose() },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := Parse(tc.input)
+			tc.f(got.Elts[0].(*EnvExpr))
+			expect := Parse(tc.expect)
+			if !expect.DeepEqWith(got, DeepEqCfg{SkipPos: true}) {
+				t.Errorf("got:\n%s\nexpected:\n%s", got.VisualizeTree(), expect.VisualizeTree())
+			}
+		})
+	}
+}
+
+func TestNewMatrix(t *testing.T) {
+	got := &UnboundCompExpr{Elts: []Expr{NewMatrix(ENV_matrix, 2, 3)}}
+	expect := Parse(`\begin{matrix} & & \\ & & \end{matrix}`)
+	if !expect.DeepEqWith(got, DeepEqCfg{SkipPos: true}) {
+		t.Errorf("got:\n%s\nexpected:\n%s", got.VisualizeTree(), expect.VisualizeTree())
+	}
+}
@@ -0,0 +1,104 @@
+package latex
+
+// Clone returns a deep copy of a tree, sharing no nodes with it. Nodes defined
+// outside of this package (e.g. the editor's cursor) are not copied but shared;
+// use Rewrite on the copy to replace or remove them
+func Clone(node Expr) Expr {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *BadExpr:
+		c := *n
+		return &c
+	case *EmptyExpr:
+		c := *n
+		return &c
+	case *NumberLit:
+		c := *n
+		return &c
+	case *VarLit:
+		c := *n
+		return &c
+	case *SimpleOpLit:
+		c := *n
+		return &c
+	case *IncompleteCmdLit:
+		c := *n
+		return &c
+	case *UnknownCmdLit:
+		c := *n
+		return &c
+	case *SimpleCmdLit:
+		c := *n
+		return &c
+	case RawRuneLit:
+		return n
+	case *CompositeExpr:
+		c := *n
+		c.Type = Clone(n.Type)
+		c.Elts = cloneAll(n.Elts)
+		return &c
+	case *UnboundCompExpr:
+		c := *n
+		c.Elts = cloneAll(n.Elts)
+		return &c
+	case *ParenCompExpr:
+		c := *n
+		c.Elts = cloneAll(n.Elts)
+		return &c
+	case *EnvExpr:
+		c := *n
+		c.Elts = make([][]*UnboundCompExpr, len(n.Elts))
+		for i, row := range n.Elts {
+			c.Elts[i] = cloneRow(row)
+		}
+		return &c
+	case *TextContainer:
+		c := *n
+		if n.Text != nil {
+			c.Text = Clone(n.Text).(*TextStringWrapper)
+		}
+		return &c
+	case *TextStringWrapper:
+		return &TextStringWrapper{Runes: cloneAll(n.Runes)}
+	case *SuperExpr:
+		c := *n
+		c.X = Clone(n.X)
+		return &c
+	case *SubExpr:
+		c := *n
+		c.X = Clone(n.X)
+		return &c
+	case *Cmd1ArgExpr:
+		c := *n
+		c.Arg1 = Clone(n.Arg1)
+		return &c
+	case *Cmd2ArgExpr:
+		c := *n
+		c.Arg1 = Clone(n.Arg1)
+		c.Arg2 = Clone(n.Arg2)
+		return &c
+	}
+	return node
+}
+
+func cloneAll(nodes []Expr) []Expr {
+	if nodes == nil {
+		return nil
+	}
+	clones := make([]Expr, len(nodes))
+	for i, n := range nodes {
+		clones[i] = Clone(n)
+	}
+	return clones
+}
+
+func cloneRow(row []*UnboundCompExpr) []*UnboundCompExpr {
+	clones := make([]*UnboundCompExpr, len(row))
+	for i, cell := range row {
+		if cell != nil {
+			clones[i] = Clone(cell).(*UnboundCompExpr)
+		}
+	}
+	return clones
+}
@@ -0,0 +1,40 @@
+package latex
+
+import "testing"
+
+func TestClone(t *testing.T) {
+	inputs := []string{
+		`x + 2 \cdot y_{1}^{2}`,
+		`\frac{\sqrt{a}}{\binom{n}{k}} \foo`,
+		`\left( a - b \right) \text{if } x`,
+		`\begin{matrix}a & b \\ \frac{c}{d} & \alpha\end{matrix}`,
+	}
+	for _, input := range inputs {
+		tree := Parse(input)
+		clone := Clone(tree)
+		if !tree.DeepEq(clone) {
+			t.Errorf("%s: got:\n%s\nexpected:\n%s", input, clone.VisualizeTree(), tree.VisualizeTree())
+		}
+
+		nodes := map[Expr]bool{}
+		Inspect(tree, func(n Expr) bool {
+			if _, ok := n.(RawRuneLit); n != nil && !ok {
+				nodes[n] = true
+			}
+			return true
+		})
+		Inspect(clone, func(n Expr) bool {
+			if nodes[n] {
+				t.Errorf("%s: the clone shares %s", input, n.VisualizeTree())
+			}
+			return true
+		})
+	}
+
+	// nodes of other packages are shared
+	ext := &extensionNode{Text: textWrapper("ab")}
+	clone := Clone(&UnboundCompExpr{Elts: []Expr{ext}}).(*UnboundCompExpr)
+	if clone.Elts[0] != ext {
+		t.Errorf("expected the extension node to be shared")
+	}
+}
@@ -573,7 +573,7 @@ var latexCmds = map[string]LatexCmd{
 	"\\Psi":        CMD_Psi,
 	"\\Omega":      CMD_Omega,
 
-	"\\cdot ":              CMD_cdot,
+	"\\cdot":               CMD_cdot,
 	"\\sim":                CMD_sim,
 	"\\cong":               CMD_cong,
 	"\\equiv":              CMD_equiv,
@@ -1132,3 +1132,53 @@ func (cmd LatexCmd) TakesTwoArg() bool {
 func (cmd LatexCmd) IsEnclosing() bool {
 	return cmd_enclosing_beg < cmd && cmd < cmd_enclosing_end
 }
+
+// greek letters, including variants e.g. \varphi
+func (cmd LatexCmd) IsGreek() bool {
+	return CMD_alpha <= cmd && cmd <= CMD_Omega
+}
+
+var relationCmds = map[LatexCmd]bool{
+	CMD_sim: true, CMD_cong: true, CMD_equiv: true, CMD_ne: true, CMD_propto: true, CMD_asymp: true,
+	CMD_lt: true, CMD_gt: true, CMD_le: true, CMD_ge: true, CMD_ll: true, CMD_gg: true,
+	CMD_in: true, CMD_notin: true, CMD_ni: true, CMD_notni: true,
+	CMD_subset: true, CMD_supset: true, CMD_nsubset: true, CMD_nsupset: true,
+	CMD_subseteq: true, CMD_supseteq: true, CMD_nsubseteq: true, CMD_nsupseteq: true,
+	CMD_models: true, CMD_prec: true, CMD_succ: true, CMD_preceq: true, CMD_succeq: true,
+	CMD_simeq: true, CMD_mid: true, CMD_parallel: true, CMD_doteq: true, CMD_vdash: true, CMD_dashv: true,
+	CMD_sqsubset: true, CMD_sqsupset: true, CMD_sqsubseteq: true, CMD_sqsupseteq: true,
+	CMD_backsim: true, CMD_backsimeq: true, CMD_eqsim: true, CMD_ncong: true, CMD_approxeq: true,
+	CMD_bumpeq: true, CMD_Bumpeq: true, CMD_doteqdot: true, CMD_fallingdotseq: true, CMD_risingdotseq: true,
+	CMD_eqcirc: true, CMD_circeq: true, CMD_triangleq: true,
+	CMD_leqq: true, CMD_geqq: true, CMD_lneqq: true, CMD_gneqq: true, CMD_nleq: true, CMD_ngeq: true,
+	CMD_lesssim: true, CMD_gtrsim: true, CMD_lessgtr: true, CMD_gtrless: true,
+	CMD_nsim: true, CMD_nmid: true, CMD_subsetneq: true, CMD_supsetneq: true,
+}
+
+// relations such as \le or \in, i.e. symbols that compare two sides of a formula
+func (cmd LatexCmd) IsRelation() bool {
+	return relationCmds[cmd]
+}
+
+var binaryOperatorCmds = map[LatexCmd]bool{
+	CMD_pm: true, CMD_mp: true, CMD_times: true, CMD_div: true, CMD_cdot: true, CMD_ast: true,
+	CMD_circ: true, CMD_bullet: true, CMD_oplus: true, CMD_ominus: true, CMD_otimes: true, CMD_odot: true,
+	CMD_cup: true, CMD_cap: true, CMD_setminus: true, CMD_land: true, CMD_lor: true,
+	CMD_sqcup: true, CMD_sqcap: true, CMD_uplus: true,
+}
+
+// binary operators such as \times or \cup, i.e. symbols between two operands
+func (cmd LatexCmd) IsBinaryOperator() bool {
+	return binaryOperatorCmds[cmd]
+}
+
+// large operators that take limits e.g. \sum, \int, \bigcup
+func (cmd LatexCmd) IsBigOperator() bool {
+	switch cmd {
+	case CMD_sum, CMD_prod, CMD_coprod, CMD_int, CMD_oint, CMD_iint, CMD_iiint, CMD_oiint, CMD_oiiint,
+		CMD_bigcap, CMD_bigcup, CMD_bigsqcup, CMD_bigvee, CMD_bigwedge,
+		CMD_bigodot, CMD_bigotimes, CMD_bigoplus, CMD_biguplus:
+		return true
+	}
+	return false
+}
@@ -96,8 +96,10 @@ func (x *CompositeExpr) DeepEqWith(other Expr, cfg DeepEqCfg) bool {
 	if !ok {
 		return false
 	}
-	if x.Lbrace != o.Lbrace || x.Rbrace != o.Rbrace {
-		return false
+	if !cfg.SkipPos {
+		if x.Lbrace != o.Lbrace || x.Rbrace != o.Rbrace {
+			return false
+		}
 	}
 	if len(x.Elts) != len(o.Elts) {
 		return false
@@ -237,12 +239,12 @@ func (x *IncompleteCmdLit) DeepEqWith(other Expr, cfg DeepEqCfg) bool {
 	if !ok {
 		return false
 	}
-	if cfg.SkipPos {
-		if x.To != o.To {
+	if !cfg.SkipPos {
+		if x.Backslash != o.Backslash || x.To != o.To {
 			return false
 		}
 	}
-	return x.Backslash == o.Backslash && x.Source == o.Source
+	return x.Source == o.Source
 }
 
 func (x *UnknownCmdLit) DeepEq(other Expr) bool {
@@ -257,12 +259,12 @@ func (x *UnknownCmdLit) DeepEqWith(other Expr, cfg DeepEqCfg) bool {
 	if !ok {
 		return false
 	}
-	if cfg.SkipPos {
-		if x.To != o.To {
+	if !cfg.SkipPos {
+		if x.Backslash != o.Backslash || x.To != o.To {
 			return false
 		}
 	}
-	return x.Backslash == o.Backslash && x.Source == o.Source
+	return x.Source == o.Source
 }
 
 func (x RawRuneLit) DeepEq(other Expr) bool {
@@ -292,7 +294,7 @@ func (x *SimpleCmdLit) DeepEqWith(other Expr, cfg DeepEqCfg) bool {
 	if !ok {
 		return false
 	}
-	if cfg.SkipPos {
+	if !cfg.SkipPos {
 		if x.Backslash != o.Backslash || x.To != o.To {
 			return false
 		}
@@ -360,7 +362,7 @@ func (x *Cmd1ArgExpr) DeepEqWith(other Expr, cfg DeepEqCfg) bool {
 	if !ok {
 		return false
 	}
-	if cfg.SkipPos {
+	if !cfg.SkipPos {
 		if x.Backslash != o.Backslash || x.To != o.To {
 			return false
 		}
@@ -389,7 +391,7 @@ func (x *Cmd2ArgExpr) DeepEqWith(other Expr, cfg DeepEqCfg) bool {
 	if !ok {
 		return false
 	}
-	if cfg.SkipPos {
+	if !cfg.SkipPos {
 		if x.Backslash != o.Backslash || x.To != o.To {
 			return false
 		}
@@ -424,7 +426,7 @@ func (x *TextContainer) DeepEqWith(other Expr, cfg DeepEqCfg) bool {
 	if !ok {
 		return false
 	}
-	if cfg.SkipPos {
+	if !cfg.SkipPos {
 		if x.CmdText != o.CmdText || x.From != o.From || x.To != o.To {
 			return false
 		}
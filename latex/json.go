@@ -0,0 +1,380 @@
+package latex
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+/* ----------------------------------------------------------------------------
+   JSON
+
+   Every node is encoded as a JSON object tagged with its Go type name. Fields
+   that do not apply to a node type, or are empty/zero, are left out:
+
+	{
+	  "type":       "Cmd2ArgExpr",     // the node type, e.g. "VarLit", "EnvExpr"
+	  "from":       0,                 // start position, see Pos()
+	  "to":         0,                 // end position, see End()
+	  "lbrace":     0,                 // TextContainer: position of "{"
+	  "source":     "x",               // source text of literals
+	  "command":    "\\frac",          // command name, see LatexCmd.GetCmd
+	  "token":      "RBRACE",          // EmptyExpr: the token it terminates
+	  "left":       "(",               // ParenCompExpr delimiters
+	  "right":      ")",
+	  "env":        "matrix",          // EnvExpr: environment name
+	  "text":       "if ",             // TextContainer, TextStringWrapper
+	  "incomplete": true,              // CompositeExpr
+	  "children":   [ ... ],           // child nodes, in order
+	  "cells":      [[ ... ], [ ... ]] // EnvExpr: rows of UnboundCompExpr cells
+	}
+
+   The children of Cmd1ArgExpr, Cmd2ArgExpr, SuperExpr and SubExpr are their
+   arguments. RawRuneLit is encoded as {"type": "RawRuneLit", "source": "a"}.
+
+   Every node type implements json.Marshaler and json.Unmarshaler; use
+   UnmarshalExpr to decode a node of unknown type.
+*/
+
+type jsonNode struct {
+	Type       string        `json:"type"`
+	From       Pos           `json:"from,omitempty"`
+	To         Pos           `json:"to,omitempty"`
+	Lbrace     Pos           `json:"lbrace,omitempty"`
+	Source     string        `json:"source,omitempty"`
+	Command    string        `json:"command,omitempty"`
+	Token      string        `json:"token,omitempty"`
+	Left       string        `json:"left,omitempty"`
+	Right      string        `json:"right,omitempty"`
+	Env        string        `json:"env,omitempty"`
+	Text       string        `json:"text,omitempty"`
+	Incomplete bool          `json:"incomplete,omitempty"`
+	Children   []*jsonNode   `json:"children,omitempty"`
+	Cells      [][]*jsonNode `json:"cells,omitempty"`
+}
+
+func (x *BadExpr) MarshalJSON() ([]byte, error)           { return marshalNode(x) }
+func (x *EmptyExpr) MarshalJSON() ([]byte, error)         { return marshalNode(x) }
+func (x *NumberLit) MarshalJSON() ([]byte, error)         { return marshalNode(x) }
+func (x *VarLit) MarshalJSON() ([]byte, error)            { return marshalNode(x) }
+func (x *TextContainer) MarshalJSON() ([]byte, error)     { return marshalNode(x) }
+func (x *TextStringWrapper) MarshalJSON() ([]byte, error) { return marshalNode(x) }
+func (x *CompositeExpr) MarshalJSON() ([]byte, error)     { return marshalNode(x) }
+func (x *UnboundCompExpr) MarshalJSON() ([]byte, error)   { return marshalNode(x) }
+func (x *EnvExpr) MarshalJSON() ([]byte, error)           { return marshalNode(x) }
+func (x *ParenCompExpr) MarshalJSON() ([]byte, error)     { return marshalNode(x) }
+func (x RawRuneLit) MarshalJSON() ([]byte, error)         { return marshalNode(x) }
+func (x *SimpleOpLit) MarshalJSON() ([]byte, error)       { return marshalNode(x) }
+func (x *IncompleteCmdLit) MarshalJSON() ([]byte, error)  { return marshalNode(x) }
+func (x *UnknownCmdLit) MarshalJSON() ([]byte, error)     { return marshalNode(x) }
+func (x *SimpleCmdLit) MarshalJSON() ([]byte, error)      { return marshalNode(x) }
+func (x *SuperExpr) MarshalJSON() ([]byte, error)         { return marshalNode(x) }
+func (x *SubExpr) MarshalJSON() ([]byte, error)           { return marshalNode(x) }
+func (x *Cmd1ArgExpr) MarshalJSON() ([]byte, error)       { return marshalNode(x) }
+func (x *Cmd2ArgExpr) MarshalJSON() ([]byte, error)       { return marshalNode(x) }
+
+func (x *BadExpr) UnmarshalJSON(data []byte) error           { return unmarshalNode(data, x) }
+func (x *EmptyExpr) UnmarshalJSON(data []byte) error         { return unmarshalNode(data, x) }
+func (x *NumberLit) UnmarshalJSON(data []byte) error         { return unmarshalNode(data, x) }
+func (x *VarLit) UnmarshalJSON(data []byte) error            { return unmarshalNode(data, x) }
+func (x *TextContainer) UnmarshalJSON(data []byte) error     { return unmarshalNode(data, x) }
+func (x *TextStringWrapper) UnmarshalJSON(data []byte) error { return unmarshalNode(data, x) }
+func (x *CompositeExpr) UnmarshalJSON(data []byte) error     { return unmarshalNode(data, x) }
+func (x *UnboundCompExpr) UnmarshalJSON(data []byte) error   { return unmarshalNode(data, x) }
+func (x *EnvExpr) UnmarshalJSON(data []byte) error           { return unmarshalNode(data, x) }
+func (x *ParenCompExpr) UnmarshalJSON(data []byte) error     { return unmarshalNode(data, x) }
+func (x *RawRuneLit) UnmarshalJSON(data []byte) error        { return unmarshalNode(data, x) }
+func (x *SimpleOpLit) UnmarshalJSON(data []byte) error       { return unmarshalNode(data, x) }
+func (x *IncompleteCmdLit) UnmarshalJSON(data []byte) error  { return unmarshalNode(data, x) }
+func (x *UnknownCmdLit) UnmarshalJSON(data []byte) error     { return unmarshalNode(data, x) }
+func (x *SimpleCmdLit) UnmarshalJSON(data []byte) error      { return unmarshalNode(data, x) }
+func (x *SuperExpr) UnmarshalJSON(data []byte) error         { return unmarshalNode(data, x) }
+func (x *SubExpr) UnmarshalJSON(data []byte) error           { return unmarshalNode(data, x) }
+func (x *Cmd1ArgExpr) UnmarshalJSON(data []byte) error       { return unmarshalNode(data, x) }
+func (x *Cmd2ArgExpr) UnmarshalJSON(data []byte) error       { return unmarshalNode(data, x) }
+
+// UnmarshalExpr decodes a node of any type, as encoded by the MarshalJSON
+// methods
+func UnmarshalExpr(data []byte) (Expr, error) {
+	var n jsonNode
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, err
+	}
+	return n.toExpr()
+}
+
+func marshalNode(x Expr) ([]byte, error) {
+	n, err := toJSONNode(x)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(n)
+}
+
+// decodes data into dst, which must point to a node of the encoded type
+func unmarshalNode(data []byte, dst interface{}) error {
+	x, err := UnmarshalExpr(data)
+	if err != nil {
+		return err
+	}
+	src := reflect.ValueOf(x)
+	if src.Kind() == reflect.Ptr {
+		src = src.Elem()
+	}
+	target := reflect.ValueOf(dst).Elem()
+	if src.Type() != target.Type() {
+		return fmt.Errorf("cannot decode %s into %s", src.Type().Name(), target.Type().Name())
+	}
+	target.Set(src)
+	return nil
+}
+
+func toJSONNodes(exprs []Expr) ([]*jsonNode, error) {
+	nodes := make([]*jsonNode, len(exprs))
+	for i, x := range exprs {
+		n, err := toJSONNode(x)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = n
+	}
+	return nodes, nil
+}
+
+func toJSONNode(x Expr) (*jsonNode, error) {
+	var err error
+	n := &jsonNode{}
+	switch x := x.(type) {
+	case *BadExpr:
+		n.Type, n.From, n.To, n.Source = "BadExpr", x.From, x.To, x.source
+	case *EmptyExpr:
+		n.Type, n.From, n.To = "EmptyExpr", x.From, x.To
+		if x.Type != ILLEGAL {
+			n.Token = x.Type.String()
+		}
+	case *NumberLit:
+		n.Type, n.From, n.To, n.Source = "NumberLit", x.From, x.To, x.Source
+	case *VarLit:
+		n.Type, n.From, n.To, n.Source = "VarLit", x.From, x.To, x.Source
+	case *TextContainer:
+		n.Type, n.From, n.Lbrace, n.To = "TextContainer", x.CmdText, x.From, x.To
+		n.Command = x.Type.GetCmd()
+		if x.Text != nil {
+			n.Text = x.Text.BuildString()
+		}
+	case *TextStringWrapper:
+		n.Type, n.Text = "TextStringWrapper", x.BuildString()
+	case *CompositeExpr:
+		n.Type, n.From, n.To, n.Incomplete = "CompositeExpr", x.Lbrace, x.Rbrace, x.Incomplete
+		n.Children, err = toJSONNodes(x.Elts)
+	case *UnboundCompExpr:
+		n.Type, n.From, n.To = "UnboundCompExpr", x.From, x.To
+		n.Children, err = toJSONNodes(x.Elts)
+	case *ParenCompExpr:
+		n.Type, n.From, n.To, n.Left, n.Right = "ParenCompExpr", x.From, x.To, x.Left, x.Right
+		n.Children, err = toJSONNodes(x.Elts)
+	case *EnvExpr:
+		n.Type, n.From, n.To = "EnvExpr", x.From, x.To
+		if x.Name != ENV_unknown {
+			n.Env = x.Name.String()
+		}
+		n.Cells = make([][]*jsonNode, len(x.Elts))
+		for i, row := range x.Elts {
+			cells := make([]Expr, len(row))
+			for j, cell := range row {
+				cells[j] = cell
+			}
+			if n.Cells[i], err = toJSONNodes(cells); err != nil {
+				return nil, err
+			}
+		}
+	case RawRuneLit:
+		n.Type, n.Source = "RawRuneLit", string(x)
+	case *SimpleOpLit:
+		n.Type, n.From, n.To, n.Source = "SimpleOpLit", x.From, x.To, x.Source
+	case *IncompleteCmdLit:
+		n.Type, n.From, n.To, n.Source = "IncompleteCmdLit", x.Backslash, x.To, x.Source
+	case *UnknownCmdLit:
+		n.Type, n.From, n.To, n.Source = "UnknownCmdLit", x.Backslash, x.To, x.Source
+	case *SimpleCmdLit:
+		n.Type, n.From, n.To, n.Source = "SimpleCmdLit", x.Backslash, x.To, x.Source
+		if x.Type != CMD_UNKNOWN {
+			n.Command = x.Type.GetCmd()
+		}
+	case *SuperExpr:
+		n.Type, n.From, n.To = "SuperExpr", x.Symbol, x.Close
+		n.Children, err = toJSONNodes([]Expr{x.X})
+	case *SubExpr:
+		n.Type, n.From, n.To = "SubExpr", x.Symbol, x.Close
+		n.Children, err = toJSONNodes([]Expr{x.X})
+	case *Cmd1ArgExpr:
+		n.Type, n.From, n.To, n.Command = "Cmd1ArgExpr", x.Backslash, x.To, x.Type.GetCmd()
+		n.Children, err = toJSONNodes([]Expr{x.Arg1})
+	case *Cmd2ArgExpr:
+		n.Type, n.From, n.To, n.Command = "Cmd2ArgExpr", x.Backslash, x.To, x.Type.GetCmd()
+		n.Children, err = toJSONNodes([]Expr{x.Arg1, x.Arg2})
+	case nil:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("cannot encode node of type %T", x)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func toExprs(nodes []*jsonNode) ([]Expr, error) {
+	exprs := make([]Expr, len(nodes))
+	for i, n := range nodes {
+		x, err := n.toExpr()
+		if err != nil {
+			return nil, err
+		}
+		exprs[i] = x
+	}
+	return exprs, nil
+}
+
+// the arguments of commands, which must have exactly count children
+func (n *jsonNode) args(count int) ([]Expr, error) {
+	if len(n.Children) != count {
+		return nil, fmt.Errorf("%s: expected %d children, got %d", n.Type, count, len(n.Children))
+	}
+	return toExprs(n.Children)
+}
+
+func (n *jsonNode) command() (LatexCmd, error) {
+	if n.Command == "" {
+		return CMD_UNKNOWN, nil
+	}
+	cmd := MatchLatexCmd(n.Command)
+	if cmd == CMD_UNKNOWN {
+		return cmd, fmt.Errorf("%s: unknown command %q", n.Type, n.Command)
+	}
+	return cmd, nil
+}
+
+func (n *jsonNode) toExpr() (Expr, error) {
+	if n == nil {
+		return nil, nil
+	}
+	switch n.Type {
+	case "BadExpr":
+		return &BadExpr{From: n.From, To: n.To, source: n.Source}, nil
+	case "EmptyExpr":
+		x := &EmptyExpr{From: n.From, To: n.To}
+		if n.Token != "" {
+			tok, ok := lookupToken(n.Token)
+			if !ok {
+				return nil, fmt.Errorf("EmptyExpr: unknown token %q", n.Token)
+			}
+			x.Type = tok
+		}
+		return x, nil
+	case "NumberLit":
+		return &NumberLit{From: n.From, To: n.To, Source: n.Source}, nil
+	case "VarLit":
+		return &VarLit{From: n.From, To: n.To, Source: n.Source}, nil
+	case "TextContainer":
+		cmd, err := n.command()
+		if err != nil {
+			return nil, err
+		}
+		return &TextContainer{CmdText: n.From, From: n.Lbrace, To: n.To, Type: cmd, Text: textWrapper(n.Text)}, nil
+	case "TextStringWrapper":
+		return textWrapper(n.Text), nil
+	case "CompositeExpr":
+		elts, err := toExprs(n.Children)
+		return &CompositeExpr{Lbrace: n.From, Rbrace: n.To, Elts: elts, Incomplete: n.Incomplete}, err
+	case "UnboundCompExpr":
+		elts, err := toExprs(n.Children)
+		return &UnboundCompExpr{From: n.From, To: n.To, Elts: elts}, err
+	case "ParenCompExpr":
+		elts, err := toExprs(n.Children)
+		return &ParenCompExpr{From: n.From, To: n.To, Left: n.Left, Right: n.Right, Elts: elts}, err
+	case "EnvExpr":
+		x := &EnvExpr{From: n.From, To: n.To, Name: GetEnvName(n.Env), Elts: make([][]*UnboundCompExpr, len(n.Cells))}
+		if n.Env != "" && x.Name == ENV_unknown {
+			return nil, fmt.Errorf("EnvExpr: unknown environment %q", n.Env)
+		}
+		for i, row := range n.Cells {
+			x.Elts[i] = make([]*UnboundCompExpr, len(row))
+			for j, cell := range row {
+				c, err := cell.toExpr()
+				if err != nil {
+					return nil, err
+				}
+				if x.Elts[i][j], _ = c.(*UnboundCompExpr); x.Elts[i][j] == nil {
+					return nil, fmt.Errorf("EnvExpr: cells must be UnboundCompExpr, got %s", cell.Type)
+				}
+			}
+		}
+		return x, nil
+	case "RawRuneLit":
+		runes := []rune(n.Source)
+		if len(runes) != 1 {
+			return nil, fmt.Errorf("RawRuneLit: expected a single character, got %q", n.Source)
+		}
+		return RawRuneLit(runes[0]), nil
+	case "SimpleOpLit":
+		return &SimpleOpLit{From: n.From, To: n.To, Source: n.Source}, nil
+	case "IncompleteCmdLit":
+		return &IncompleteCmdLit{Backslash: n.From, To: n.To, Source: n.Source}, nil
+	case "UnknownCmdLit":
+		return &UnknownCmdLit{Backslash: n.From, To: n.To, Source: n.Source}, nil
+	case "SimpleCmdLit":
+		cmd, err := n.command()
+		return &SimpleCmdLit{Backslash: n.From, To: n.To, Source: n.Source, Type: cmd}, err
+	case "SuperExpr":
+		args, err := n.args(1)
+		if err != nil {
+			return nil, err
+		}
+		return &SuperExpr{Symbol: n.From, Close: n.To, X: args[0]}, nil
+	case "SubExpr":
+		args, err := n.args(1)
+		if err != nil {
+			return nil, err
+		}
+		return &SubExpr{Symbol: n.From, Close: n.To, X: args[0]}, nil
+	case "Cmd1ArgExpr":
+		cmd, err := n.command()
+		if err != nil {
+			return nil, err
+		}
+		args, err := n.args(1)
+		if err != nil {
+			return nil, err
+		}
+		return &Cmd1ArgExpr{Backslash: n.From, To: n.To, Type: cmd, Arg1: args[0]}, nil
+	case "Cmd2ArgExpr":
+		cmd, err := n.command()
+		if err != nil {
+			return nil, err
+		}
+		args, err := n.args(2)
+		if err != nil {
+			return nil, err
+		}
+		return &Cmd2ArgExpr{Backslash: n.From, To: n.To, Type: cmd, Arg1: args[0], Arg2: args[1]}, nil
+	}
+	return nil, fmt.Errorf("unknown node type %q", n.Type)
+}
+
+func textWrapper(text string) *TextStringWrapper {
+	w := &TextStringWrapper{Runes: []Expr{}}
+	for _, r := range text {
+		w.Runes = append(w.Runes, RawRuneLit(r))
+	}
+	return w
+}
+
+func lookupToken(name string) (Token, bool) {
+	for tok, s := range tokens {
+		if s == name && s != "" {
+			return Token(tok), true
+		}
+	}
+	return ILLEGAL, false
+}
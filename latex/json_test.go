@@ -0,0 +1,97 @@
+package latex
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONRoundtrip(t *testing.T) {
+	sources := []string{
+		`x + 1`,
+		`\alpha \le \infty`,
+		`\{ a \}`,
+		`\foo`,
+		`x^{2}_i`,
+		`\frac{1}{2} \binom{n}{k}`,
+		`\sqrt{\overline{x}}`,
+		`\left[ x - y \right]`,
+		`\text{if } x`,
+		`\begin{matrix}a & b \\ c & \end{matrix}`,
+		`\begin{align}x &= 1 \\ y &= 2\end{align}`,
+	}
+	for _, src := range sources {
+		t.Run(src, func(t *testing.T) {
+			tree := Parse(src)
+			data, err := json.Marshal(tree)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded := &UnboundCompExpr{}
+			if err := json.Unmarshal(data, decoded); err != nil {
+				t.Fatalf("%s: %s", err, data)
+			}
+			if !decoded.DeepEqWith(tree, DeepEqCfg{}) {
+				t.Errorf("decoded tree does not match\njson:     %s\ngot:      %s\nexpected: %s", data, decoded.VisualizeTree(), tree.VisualizeTree())
+			}
+		})
+	}
+}
+
+// nodes the parser does not produce, with positions
+func TestJSONRoundtripNodes(t *testing.T) {
+	nodes := []Expr{
+		&BadExpr{From: 1, To: 2, source: "?"},
+		&EmptyExpr{From: 3, To: 3, Type: RBRACE},
+		&IncompleteCmdLit{Backslash: 1, Source: `\fr`, To: 3},
+		&SuperExpr{Symbol: 1, X: &VarLit{From: 2, To: 2, Source: "n"}, Close: 2},
+		&SubExpr{Symbol: 1, X: &CompositeExpr{Lbrace: 2, Rbrace: 4, Incomplete: true}, Close: 4},
+		&TextContainer{CmdText: 1, Type: CMD_text, From: 6, To: 9, Text: textWrapper("ab")},
+		RawRuneLit('λ'),
+		&Cmd1ArgExpr{Type: CMD_sqrt, Backslash: 5, Arg1: &CompositeExpr{Elts: []Expr{&NumberLit{Source: "2"}}}, To: 12},
+	}
+	for _, node := range nodes {
+		data, err := json.Marshal(node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := UnmarshalExpr(data)
+		if err != nil {
+			t.Fatalf("%s: %s", err, data)
+		}
+		if !node.DeepEqWith(decoded, DeepEqCfg{}) {
+			t.Errorf("decoded node does not match\njson:     %s\ngot:      %#v\nexpected: %#v", data, decoded, node)
+		}
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	data, err := json.Marshal(Parse(`\frac{x}{\pi}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"type":"UnboundCompExpr","children":[{"type":"Cmd2ArgExpr","to":12,"command":"\\frac","children":[` +
+		`{"type":"CompositeExpr","from":5,"to":7,"children":[{"type":"VarLit","from":6,"to":6,"source":"x"}]},` +
+		`{"type":"CompositeExpr","from":8,"to":12,"children":[{"type":"SimpleCmdLit","from":9,"to":11,"source":"\\pi","command":"\\pi"}]}]}]}`
+	if string(data) != expect {
+		t.Errorf("got:\n%s\nexpected:\n%s", data, expect)
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	inputs := []string{
+		`{"type":"Nope"}`,
+		`{"type":"Cmd1ArgExpr","command":"\\sqrt"}`,
+		`{"type":"SimpleCmdLit","source":"\\nope","command":"\\nope"}`,
+		`{"type":"EnvExpr","env":"matrix","cells":[[{"type":"VarLit","source":"x"}]]}`,
+		`{"type":"RawRuneLit","source":"ab"}`,
+	}
+	for _, input := range inputs {
+		if x, err := UnmarshalExpr([]byte(input)); err == nil {
+			t.Errorf("%s: expected an error, got %#v", input, x)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(`{"type":"VarLit","source":"x"}`), &NumberLit{}); err == nil {
+		t.Error("decoding a VarLit into a NumberLit should fail")
+	}
+}
@@ -2,6 +2,8 @@ package latex
 
 import (
 	"fmt"
+	"unicode/utf8"
+
 	"github.com/mattn/go-runewidth"
 )
 
@@ -10,6 +12,7 @@ type Parser struct {
 	tokenizer Tokenizer
 	// Next token
 	pos       Pos      // token position
+	last      Pos      // position of the last character of the previous token
 	tok       Token    // one token look-ahead
 	lit       string   // token literal
 	expecting []string // FIXME new type?
@@ -23,6 +26,17 @@ func Parse(src string) *UnboundCompExpr {
 	return NewParser(src).GetTree()
 }
 
+// ParseSafe is Parse, except that the panics of the parser on malformed
+// input are turned into errors
+func ParseSafe(src string) (tree *UnboundCompExpr, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return Parse(src), nil
+}
+
 // TODO: don't export?
 func NewParser(src string) *Parser {
 	p := &Parser{}
@@ -36,7 +50,11 @@ func NewParser(src string) *Parser {
 func (p *Parser) GetTree() *UnboundCompExpr { return p.treeRoot }
 
 func (p *Parser) next() {
+	if p.lit != "" {
+		p.last = p.end()
+	}
 	p.tok = p.tokenizer.Peek()
+	p.pos = p.tokenizer.currPos
 	if !p.tokenizer.IsEOF() {
 		p.lit = p.tokenizer.Eat()
 	} else {
@@ -47,6 +65,11 @@ func (p *Parser) next() {
 	// 	" depth:", p.exprLev)
 }
 
+// position of the last character of the current token
+func (p *Parser) end() Pos {
+	return p.pos + Pos(len(p.lit)) - 1
+}
+
 // Note that the parser's EOF is separate from the tokenizer's.
 // the Parser's EOF should arrive one iteration of Parser.next()
 // later than the tokenizer
@@ -119,8 +142,9 @@ func (p *Parser) parseGenericOnce() Expr {
 			p.tokenizer.Cursor, p.lit, p.tok.String()))
 	}
 	// println("BadExpr!")
+	node := &BadExpr{From: p.pos, To: p.end()}
 	p.next()
-	return &BadExpr{}
+	return node
 }
 
 func (p *Parser) parseStringCmd() Expr {
@@ -134,7 +158,7 @@ func (p *Parser) parseStringCmd() Expr {
 	case kind.TakesRawStrArg():
 		leaf = p.parseTextCommand(kind)
 	case kind.IsVanillaSym():
-		leaf = &(SimpleCmdLit{Source: p.lit, Type: kind})
+		leaf = &(SimpleCmdLit{Source: p.lit, Type: kind, Backslash: p.pos, To: p.end()})
 		p.next()
 	case kind.TakesOneArg():
 		leaf = p.parseCmd1Arg(kind)
@@ -143,11 +167,11 @@ func (p *Parser) parseStringCmd() Expr {
 	case kind.IsEnclosing():
 		leaf = p.parseCmdEnclosing()
 	case kind == CMD_UNKNOWN:
-		leaf = &(UnknownCmdLit{Source: p.lit})
+		leaf = &(UnknownCmdLit{Source: p.lit, Backslash: p.pos, To: p.end()})
 		p.next()
 	default:
 		// this shouldn't be triggered
-		leaf = &(BadExpr{})
+		leaf = &(BadExpr{From: p.pos, To: p.end()})
 		p.next()
 	}
 
@@ -159,7 +183,9 @@ func (p *Parser) parseStringCmd() Expr {
 // FIXME merge into parseStringCmd?
 func (p *Parser) parseSymbolCmd() Expr {
 	leaf := SimpleCmdLit{
-		Source: p.lit,
+		Source:    p.lit,
+		Backslash: p.pos,
+		To:        p.end(),
 	}
 	p.next()
 	return &leaf
@@ -168,6 +194,8 @@ func (p *Parser) parseSymbolCmd() Expr {
 func (p *Parser) parseNumLit() Expr {
 	leaf := NumberLit{
 		Source: p.lit,
+		From:   p.pos,
+		To:     p.end(),
 	}
 	p.next()
 	return &leaf
@@ -176,24 +204,64 @@ func (p *Parser) parseNumLit() Expr {
 func (p *Parser) parseVarLit() Expr {
 	leaf := VarLit{
 		Source: p.lit,
+		From:   p.pos,
+		To:     p.end(),
 	}
 	p.next()
 	return &leaf
 }
 
 func (p *Parser) parseSimpleOpLit() Expr {
+	if cmd, ok := UnicodeToVanilla[p.lit]; ok {
+		leaf := &SimpleCmdLit{Source: cmd.GetCmd(), Type: cmd, Backslash: p.pos, To: p.end()}
+		p.next()
+		return leaf
+	}
+	if r, _ := utf8.DecodeRuneInString(p.lit); r >= utf8.RuneSelf {
+		if _, _, ok := NormalizeScript(r); ok {
+			return p.parseUnicodeScript()
+		}
+	}
 	leaf := SimpleOpLit{
 		Source: p.lit,
+		From:   p.pos,
+		To:     p.end(),
 	}
 	p.next()
 	return &leaf
 }
 
+// parse a run of unicode superscript (or subscript) characters, e.g. "²³" is
+// read as "^{23}"
+func (p *Parser) parseUnicodeScript() Expr {
+	r, _ := utf8.DecodeRuneInString(p.lit)
+	kind, _, _ := NormalizeScript(r)
+	from := p.pos
+	arg := new(CompositeExpr)
+	for p.tok == SYM {
+		r, _ := utf8.DecodeRuneInString(p.lit)
+		k, plain, ok := NormalizeScript(r)
+		if !ok || k != kind {
+			break
+		}
+		switch {
+		case '0' <= plain && plain <= '9':
+			arg.AppendChildren(&NumberLit{Source: string(plain)})
+		case 'a' <= plain && plain <= 'z':
+			arg.AppendChildren(&VarLit{Source: string(plain)})
+		default:
+			arg.AppendChildren(&SimpleOpLit{Source: string(plain)})
+		}
+		p.next()
+	}
+	return &Cmd1ArgExpr{Type: kind, Arg1: arg, Backslash: from, To: p.last}
+}
+
 func (p *Parser) parseCompositeExpr() Expr {
 	p.exprLev++
 	p.expect("}")
+	node := &CompositeExpr{Lbrace: p.pos}
 	p.next() // skip "{"
-	node := new(CompositeExpr)
 	for !p.IsEOF() && p.tok != RBRACE {
 		node.AppendChildren(p.parseGenericOnce())
 		// println("add child to node; depth: ", p.exprLev)
@@ -202,6 +270,7 @@ func (p *Parser) parseCompositeExpr() Expr {
 		// FIXME error handling
 		panic("expecting '}' got EOF")
 	}
+	node.Rbrace = p.pos
 	p.next() // skip "}"
 	p.dropExpect("}")
 	p.exprLev--
@@ -210,12 +279,14 @@ func (p *Parser) parseCompositeExpr() Expr {
 
 func (p *Parser) parseTextCommand(kind LatexCmd) Expr {
 	p.exprLev++
+	node := &TextContainer{Text: &TextStringWrapper{}, Type: kind, CmdText: p.pos}
 	p.next() // skip command
-	node := &TextContainer{Text: &TextStringWrapper{}, Type: kind}
+	node.From = p.pos
 	if p.tok != LBRACE {
 		runes := make([]Expr, 1)
 		runes[0] = RawRuneLit(p.lit[0])
 		node.Text = &TextStringWrapper{Runes: runes}
+		node.To = p.pos
 		p.next()
 		return node
 	}
@@ -227,6 +298,8 @@ func (p *Parser) parseTextCommand(kind LatexCmd) Expr {
 	}
 	node.Text.Runes = runeLiterals
 
+	// the tokenizer skipped the text, "{" is at node.From
+	node.To = node.From + Pos(len(text)) + 1
 	p.next() // skip }
 	p.exprLev--
 	return node
@@ -244,9 +317,10 @@ func maybeWrapWithCompositeExpr(e Expr) *CompositeExpr {
 // parse a Command that takes one arguement
 func (p *Parser) parseCmd1Arg(kind LatexCmd) Expr {
 	p.exprLev++
+	node := &Cmd1ArgExpr{Type: kind, Backslash: p.pos}
 	p.next() // skip command
-	node := &Cmd1ArgExpr{Type: kind}
 	node.Arg1 = maybeWrapWithCompositeExpr(p.parseGenericOnce())
+	node.To = p.last
 
 	p.exprLev--
 	return node
@@ -255,10 +329,11 @@ func (p *Parser) parseCmd1Arg(kind LatexCmd) Expr {
 // parse a Command that takes two arguement
 func (p *Parser) parseCmd2Arg(kind LatexCmd) Expr {
 	p.exprLev++
+	node := &Cmd2ArgExpr{Type: kind, Backslash: p.pos}
 	p.next() // skip "\command"
-	node := &Cmd2ArgExpr{Type: kind}
 	node.Arg1 = maybeWrapWithCompositeExpr(p.parseGenericOnce())
 	node.Arg2 = maybeWrapWithCompositeExpr(p.parseGenericOnce())
+	node.To = p.last
 
 	p.exprLev--
 	return node
@@ -267,8 +342,8 @@ func (p *Parser) parseCmd2Arg(kind LatexCmd) Expr {
 func (p *Parser) parseCmdEnclosing() Expr {
 	p.exprLev++
 	p.expect("\\right")
+	node := &ParenCompExpr{From: p.pos}
 	p.next() // skip "\left"
-	node := new(ParenCompExpr)
 	switch p.lit {
 	case "(", "[", "\\{":
 	default:
@@ -293,6 +368,7 @@ func (p *Parser) parseCmdEnclosing() Expr {
 		panic("\\right expected '\\}' but got " + p.lit)
 	}
 	node.Right = p.lit
+	node.To = p.end()
 	p.next()
 	p.dropExpect("\\right")
 	p.exprLev--
@@ -300,7 +376,7 @@ func (p *Parser) parseCmdEnclosing() Expr {
 }
 
 func (p *Parser) parseEnvExpr() Expr {
-	from := p.tokenizer.Cursor
+	from := p.pos
 	p.exprLev++
 	p.next() // skip "\begin"
 
@@ -369,7 +445,7 @@ loop:
 	} else {
 	}
 
-	node.To = p.tokenizer.Cursor
+	node.To = p.last
 	node.Elts = table
 
 	p.exprLev--
@@ -25,8 +25,8 @@ func TestParser(t *testing.T) {
 			expect: &UnboundCompExpr{
 				Elts: []Expr{
 					&NumberLit{From: 0, To: 0, Source: "1"},
-					&NumberLit{From: 0, To: 0, Source: "2"},
-					&NumberLit{From: 0, To: 0, Source: "3"},
+					&NumberLit{From: 1, To: 1, Source: "2"},
+					&NumberLit{From: 2, To: 2, Source: "3"},
 				},
 			},
 		},
@@ -45,8 +45,8 @@ func TestParser(t *testing.T) {
 			expect: &UnboundCompExpr{
 				Elts: []Expr{
 					&VarLit{From: 0, To: 0, Source: "x"},
-					&VarLit{From: 0, To: 0, Source: "y"},
-					&VarLit{From: 0, To: 0, Source: "z"},
+					&VarLit{From: 1, To: 1, Source: "y"},
+					&VarLit{From: 2, To: 2, Source: "z"},
 				},
 			},
 		},
@@ -65,8 +65,8 @@ func TestParser(t *testing.T) {
 			expect: &UnboundCompExpr{
 				Elts: []Expr{
 					&SimpleOpLit{From: 0, To: 0, Source: "+"},
-					&SimpleOpLit{From: 0, To: 0, Source: "-"},
-					&SimpleOpLit{From: 0, To: 0, Source: "="},
+					&SimpleOpLit{From: 1, To: 1, Source: "-"},
+					&SimpleOpLit{From: 2, To: 2, Source: "="},
 				},
 			},
 		},
@@ -75,7 +75,7 @@ func TestParser(t *testing.T) {
 			input: "\\times",
 			expect: &UnboundCompExpr{
 				Elts: []Expr{
-					&SimpleCmdLit{Backslash: 0, Source: "\\times", Type: CMD_times, To: 0},
+					&SimpleCmdLit{Backslash: 0, Source: "\\times", Type: CMD_times, To: 5},
 				},
 			},
 		},
@@ -84,7 +84,18 @@ func TestParser(t *testing.T) {
 			input: "\\pi",
 			expect: &UnboundCompExpr{
 				Elts: []Expr{
-					&SimpleCmdLit{Backslash: 0, Source: "\\pi", Type: CMD_pi, To: 0},
+					&SimpleCmdLit{Backslash: 0, Source: "\\pi", Type: CMD_pi, To: 2},
+				},
+			},
+		},
+		{
+			desc:  "SimpleCmdLit - cdot",
+			input: "a\\cdot b",
+			expect: &UnboundCompExpr{
+				Elts: []Expr{
+					&VarLit{From: 0, To: 0, Source: "a"},
+					&SimpleCmdLit{Backslash: 1, Source: "\\cdot", Type: CMD_cdot, To: 5},
+					&VarLit{From: 7, To: 7, Source: "b"},
 				},
 			},
 		},
@@ -96,9 +107,9 @@ func TestParser(t *testing.T) {
 					&CompositeExpr{
 						Lbrace: 0,
 						Elts: []Expr{
-							&VarLit{From: 0, To: 0, Source: "x"},
+							&VarLit{From: 1, To: 1, Source: "x"},
 						},
-						Rbrace: 0,
+						Rbrace: 2,
 					},
 				},
 			},
@@ -111,11 +122,11 @@ func TestParser(t *testing.T) {
 					&CompositeExpr{
 						Lbrace: 0,
 						Elts: []Expr{
-							&VarLit{From: 0, To: 0, Source: "a"},
-							&SimpleOpLit{From: 0, To: 0, Source: "+"},
-							&VarLit{From: 0, To: 0, Source: "b"},
+							&VarLit{From: 1, To: 1, Source: "a"},
+							&SimpleOpLit{From: 3, To: 3, Source: "+"},
+							&VarLit{From: 5, To: 5, Source: "b"},
 						},
-						Rbrace: 0,
+						Rbrace: 6,
 					},
 				},
 			},
@@ -128,15 +139,15 @@ func TestParser(t *testing.T) {
 					&VarLit{From: 0, To: 0, Source: "x"},
 					&Cmd1ArgExpr{
 						Type:      CMD_superscript,
-						Backslash: 0,
+						Backslash: 1,
 						Arg1: &CompositeExpr{
 							Lbrace: 0,
 							Elts: []Expr{
-								&NumberLit{From: 0, To: 0, Source: "2"},
+								&NumberLit{From: 2, To: 2, Source: "2"},
 							},
 							Rbrace: 0,
 						},
-						To: 0,
+						To: 2,
 					},
 				},
 			},
@@ -149,15 +160,15 @@ func TestParser(t *testing.T) {
 					&VarLit{From: 0, To: 0, Source: "x"},
 					&Cmd1ArgExpr{
 						Type:      CMD_subscript,
-						Backslash: 0,
+						Backslash: 1,
 						Arg1: &CompositeExpr{
 							Lbrace: 0,
 							Elts: []Expr{
-								&NumberLit{From: 0, To: 0, Source: "1"},
+								&NumberLit{From: 2, To: 2, Source: "1"},
 							},
 							Rbrace: 0,
 						},
-						To: 0,
+						To: 2,
 					},
 				},
 			},
@@ -171,13 +182,13 @@ func TestParser(t *testing.T) {
 						Type:      CMD_sqrt,
 						Backslash: 0,
 						Arg1: &CompositeExpr{
-							Lbrace: 0,
+							Lbrace: 5,
 							Elts: []Expr{
-								&VarLit{From: 0, To: 0, Source: "x"},
+								&VarLit{From: 6, To: 6, Source: "x"},
 							},
-							Rbrace: 0,
+							Rbrace: 7,
 						},
-						To: 0,
+						To: 7,
 					},
 				},
 			},
@@ -191,13 +202,13 @@ func TestParser(t *testing.T) {
 						Type:      CMD_underline,
 						Backslash: 0,
 						Arg1: &CompositeExpr{
-							Lbrace: 0,
+							Lbrace: 10,
 							Elts: []Expr{
-								&VarLit{From: 0, To: 0, Source: "x"},
+								&VarLit{From: 11, To: 11, Source: "x"},
 							},
-							Rbrace: 0,
+							Rbrace: 12,
 						},
-						To: 0,
+						To: 12,
 					},
 				},
 			},
@@ -211,20 +222,20 @@ func TestParser(t *testing.T) {
 						Type:      CMD_frac,
 						Backslash: 0,
 						Arg1: &CompositeExpr{
-							Lbrace: 0,
+							Lbrace: 5,
 							Elts: []Expr{
-								&NumberLit{From: 0, To: 0, Source: "1"},
+								&NumberLit{From: 6, To: 6, Source: "1"},
 							},
-							Rbrace: 0,
+							Rbrace: 7,
 						},
 						Arg2: &CompositeExpr{
-							Lbrace: 0,
+							Lbrace: 8,
 							Elts: []Expr{
-								&NumberLit{From: 0, To: 0, Source: "2"},
+								&NumberLit{From: 9, To: 9, Source: "2"},
 							},
-							Rbrace: 0,
+							Rbrace: 10,
 						},
-						To: 0,
+						To: 10,
 					},
 				},
 			},
@@ -238,20 +249,20 @@ func TestParser(t *testing.T) {
 						Type:      CMD_binom,
 						Backslash: 0,
 						Arg1: &CompositeExpr{
-							Lbrace: 0,
+							Lbrace: 6,
 							Elts: []Expr{
-								&VarLit{From: 0, To: 0, Source: "a"},
+								&VarLit{From: 7, To: 7, Source: "a"},
 							},
-							Rbrace: 0,
+							Rbrace: 8,
 						},
 						Arg2: &CompositeExpr{
-							Lbrace: 0,
+							Lbrace: 9,
 							Elts: []Expr{
-								&VarLit{From: 0, To: 0, Source: "b"},
+								&VarLit{From: 10, To: 10, Source: "b"},
 							},
-							Rbrace: 0,
+							Rbrace: 11,
 						},
-						To: 0,
+						To: 11,
 					},
 				},
 			},
@@ -266,9 +277,9 @@ func TestParser(t *testing.T) {
 						Left:  "(",
 						Right: ")",
 						Elts: []Expr{
-							&VarLit{From: 0, To: 0, Source: "x"},
+							&VarLit{From: 7, To: 7, Source: "x"},
 						},
-						To: 0,
+						To: 15,
 					},
 				},
 			},
@@ -283,9 +294,9 @@ func TestParser(t *testing.T) {
 						Left:  "[",
 						Right: "]",
 						Elts: []Expr{
-							&VarLit{From: 0, To: 0, Source: "x"},
+							&VarLit{From: 7, To: 7, Source: "x"},
 						},
-						To: 0,
+						To: 15,
 					},
 				},
 			},
@@ -298,8 +309,8 @@ func TestParser(t *testing.T) {
 					&TextContainer{
 						CmdText: 0,
 						Type:    CMD_text,
-						From:    0,
-						To:      0,
+						From:    5,
+						To:      11,
 						Text: &TextStringWrapper{
 							Runes: []Expr{
 								RawRuneLit('h'), RawRuneLit('e'), RawRuneLit('l'), RawRuneLit('l'), RawRuneLit('o'),
@@ -315,17 +326,17 @@ func TestParser(t *testing.T) {
 			expect: &UnboundCompExpr{
 				Elts: []Expr{
 					&EnvExpr{
-						From: 7,
-						To:   42,
+						From: 0,
+						To:   41,
 						Name: ENV_matrix,
 						Elts: [][]*UnboundCompExpr{
 							{
-								{Elts: []Expr{&VarLit{From: 0, To: 0, Source: "a"}}},
-								{Elts: []Expr{&VarLit{From: 0, To: 0, Source: "b"}}},
+								{From: 15, To: 17, Elts: []Expr{&VarLit{From: 15, To: 15, Source: "a"}}},
+								{From: 19, To: 21, Elts: []Expr{&VarLit{From: 19, To: 19, Source: "b"}}},
 							},
 							{
-								{Elts: []Expr{&VarLit{From: 0, To: 0, Source: "c"}}},
-								{Elts: []Expr{&VarLit{From: 0, To: 0, Source: "d"}}},
+								{From: 24, To: 26, Elts: []Expr{&VarLit{From: 24, To: 24, Source: "c"}}},
+								{From: 28, To: 30, Elts: []Expr{&VarLit{From: 28, To: 28, Source: "d"}}},
 							},
 						},
 					},
@@ -339,11 +350,11 @@ func TestParser(t *testing.T) {
 				Elts: []Expr{
 					&EnvExpr{
 						Name: ENV_matrix,
-						From: 7,
-						To:   29,
+						From: 0,
+						To:   28,
 						Elts: [][]*UnboundCompExpr{
 							{
-								{Elts: []Expr{&VarLit{From: 0, To: 0, Source: "x"}}},
+								{From: 15, To: 17, Elts: []Expr{&VarLit{From: 15, To: 15, Source: "x"}}},
 							},
 						},
 					},
@@ -357,13 +368,13 @@ func TestParser(t *testing.T) {
 				Elts: []Expr{
 					&EnvExpr{
 						Name: ENV_matrix,
-						From: 7,
-						To:   35,
+						From: 0,
+						To:   34,
 						Elts: [][]*UnboundCompExpr{
 							{
-								{Elts: []Expr{&VarLit{From: 0, To: 0, Source: "x"}}},
-								{Elts: []Expr{}},
-								{Elts: []Expr{&VarLit{From: 0, To: 0, Source: "z"}}},
+								{From: 15, To: 17, Elts: []Expr{&VarLit{From: 15, To: 15, Source: "x"}}},
+								{From: 19, To: 19, Elts: []Expr{}},
+								{From: 21, To: 23, Elts: []Expr{&VarLit{From: 21, To: 21, Source: "z"}}},
 							},
 						},
 					},
@@ -376,8 +387,8 @@ func TestParser(t *testing.T) {
 			expect: &UnboundCompExpr{
 				Elts: []Expr{
 					&VarLit{From: 0, To: 0, Source: "x"},
-					&SimpleOpLit{From: 0, To: 0, Source: "+"},
-					&NumberLit{From: 0, To: 0, Source: "1"},
+					&SimpleOpLit{From: 2, To: 2, Source: "+"},
+					&NumberLit{From: 4, To: 4, Source: "1"},
 				},
 			},
 		},
@@ -398,3 +409,36 @@ func TestParser(t *testing.T) {
 		})
 	}
 }
+
+func TestParseUnicode(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect string
+	}{
+		{"α + β ≤ ∞", `\alpha + \beta \le \infty`},
+		{"x²", "x^2"},
+		{"x²³ + y₁₀", "x^{23} + y_{10}"},
+		{"eⁱ⁽ⁿ⁺¹⁾", "e^{i(n+1)}"},
+		{"ε ∈ ℝ", `\varepsilon \in \Reals`},
+		{"a → b", `a \to b`},
+		{"é", "é"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			tree := Parse(tc.input)
+			expect := Parse(tc.expect)
+			if !tree.DeepEqWith(expect, DeepEqCfg{SkipPos: true}) {
+				t.Errorf("parsed tree does not match expected\ngot:      %s\nexpected: %s", tree.VisualizeTree(), expect.VisualizeTree())
+			}
+		})
+	}
+}
+
+func TestUnicodeToVanilla(t *testing.T) {
+	for s, cmd := range UnicodeToVanilla {
+		if VanillaToUnicode[cmd] != s {
+			t.Errorf("%q maps to %s, which is displayed as %q", s, cmd.GetCmd(), VanillaToUnicode[cmd])
+		}
+	}
+}
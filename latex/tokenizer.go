@@ -10,6 +10,7 @@ import (
 	re "regexp"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 type Pos int // TODO remove
@@ -89,8 +90,9 @@ type Tokenizer struct {
 	Cursor Pos
 	Stream string
 
-	curr string
-	tok  Token
+	curr    string
+	currPos Pos // position of curr
+	tok     Token
 
 	strCmdRegex *re.Regexp // for matching commands that consist of alphabets
 	symCmdRegex *re.Regexp // for matching commands that consist of symbols e.g. "\;"
@@ -157,11 +159,14 @@ func (t *Tokenizer) Eat() string {
 	}
 	stream := t.Stream[t.Cursor:]
 	curr := t.curr
-	tok := SYM  // ensure a new token is assigned each call
-	length := 1 // default value to catch-all
+	tok := SYM // ensure a new token is assigned each call
+	// default value to catch-all, a whole character so that unicode symbols
+	// are not split
+	_, length := utf8.DecodeRuneInString(stream)
 
 	defer func() {
 		t.curr = stream[0:length]
+		t.currPos = t.Cursor
 		t.tok = tok
 		t.Cursor = Pos(int(t.Cursor) + length)
 		//fmt.Printf("stream '\x1b[31m%s\x1b[0m%s'\n", t.curr, stream[length:])
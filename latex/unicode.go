@@ -0,0 +1,524 @@
+package latex
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// VanillaToUnicode is how vanilla symbols are displayed. It is also read
+// backwards, to turn unicode input (e.g. "α") into commands, see UnicodeToVanilla
+var VanillaToUnicode = map[LatexCmd]string{
+	// escaped symbols
+	CMD_SPACE: ` `,
+	// vanilla symbols
+	CMD_alpha: `α`,
+	CMD_beta:  `β`,
+	CMD_gamma: `γ`,
+	CMD_delta: `δ`,
+	CMD_zeta:  `ζ`,
+	CMD_eta:   `η`,
+	CMD_theta: `θ`,
+	CMD_iota:  `ι`,
+	CMD_kappa: `κ`,
+	CMD_mu:    `μ`,
+	CMD_nu:    `ν`,
+	CMD_xi:    `ξ`,
+	CMD_rho:   `ρ`,
+	CMD_sigma: `σ`,
+	CMD_tau:   `τ`,
+	CMD_chi:   `χ`,
+	CMD_psi:   `ψ`,
+	CMD_omega: `ω`,
+
+	CMD_phi:    `ϕ`,
+	CMD_phiv:   `φ`,
+	CMD_varphi: `φ`,
+
+	CMD_epsilon:    `ϵ`,
+	CMD_epsiv:      `ε`,
+	CMD_varepsilon: `ε`,
+
+	CMD_piv:   `ϖ`,
+	CMD_varpi: `ϖ`,
+
+	CMD_sigmaf:   `ς`,
+	CMD_sigmav:   `ς`,
+	CMD_varsigma: `ς`,
+
+	CMD_thetav:   `ϑ`,
+	CMD_vartheta: `ϑ`,
+	CMD_thetasym: `ϑ`,
+
+	CMD_upsilon: `υ`,
+	CMD_upsi:    `υ`,
+
+	CMD_gammad:  `ϝ`,
+	CMD_Gammad:  `ϝ`,
+	CMD_digamma: `ϝ`,
+
+	CMD_kappav:   `ϰ`,
+	CMD_varkappa: `ϰ`,
+
+	CMD_rhov:   `ϱ`,
+	CMD_varrho: `ϱ`,
+
+	CMD_pi:     `π`,
+	CMD_lambda: `λ`,
+
+	CMD_Upsilon: `ϒ`,
+	CMD_Upsi:    `ϒ`,
+	CMD_upsih:   `ϒ`,
+	CMD_Upsih:   `ϒ`,
+
+	CMD_Gamma:  `Γ`,
+	CMD_Delta:  `Δ`,
+	CMD_Theta:  `Θ`,
+	CMD_Lambda: `Λ`,
+	CMD_Xi:     `Ξ`,
+	CMD_Pi:     `Π`,
+	CMD_Sigma:  `Σ`,
+	CMD_Phi:    `Φ`,
+	CMD_Psi:    `Ψ`,
+	CMD_Omega:  `Ω`,
+
+	CMD_cdot:      `·`,
+	CMD_sim:       `∼`,
+	CMD_cong:      `≅`,
+	CMD_equiv:     `≡`,
+	CMD_oplus:     `⊕`,
+	CMD_otimes:    `⊗`,
+	CMD_times:     `×`,
+	CMD_div:       `÷`,
+	CMD_ne:        `≠`,
+	CMD_pm:        `±`, // added temporarily, reorder later?
+	CMD_mp:        `∓`, // added temporarily, reorder later?
+	CMD_ast:       `∗`,
+	CMD_therefore: `∴`,
+	CMD_because:   `∵`,
+
+	CMD_propto: `∝`,
+	CMD_asymp:  `≈`,
+
+	CMD_lt:    `<`,
+	CMD_gt:    `>`,
+	CMD_le:    `≤`,
+	CMD_ge:    `≥`,
+	CMD_in:    `∈`,
+	CMD_notin: `∉`,
+	CMD_ni:    `∋`,
+	CMD_notni: `∌`,
+
+	CMD_subset:  `⊂`,
+	CMD_supset:  `⊃`,
+	CMD_nsubset: `⊄`,
+	CMD_nsupset: `⊅`,
+
+	CMD_subseteq: `⊆`,
+	CMD_supseteq: `⊇`,
+
+	CMD_nsubseteq: `⊈`,
+	CMD_nsupseteq: `⊉`,
+
+	CMD_sum:    `∑`,
+	CMD_prod:   `∏`,
+	CMD_coprod: `∐`,
+	CMD_int:    `∫`,
+
+	CMD_N:       `ℕ`,
+	CMD_P:       `ℙ`,
+	CMD_Z:       `ℤ`,
+	CMD_Q:       `ℚ`,
+	CMD_Reals:   `ℝ`,
+	CMD_Complex: `ℂ`,
+	CMD_H:       `ℍ`,
+
+	CMD_quad:  `    `,
+	CMD_emsp:  `    `,
+	CMD_qquad: `        `,
+
+	CMD_diamond:         `◇`,
+	CMD_bigtriangleup:   `△`,
+	CMD_ominus:          `⊖`,
+	CMD_uplus:           `⊎`,
+	CMD_bigtriangledown: `▽`,
+	CMD_sqcap:           `⊓`,
+	CMD_triangleleft:    `⊲`,
+	CMD_sqcup:           `⊔`,
+	CMD_triangleright:   `⊳`,
+	CMD_odot:            `⊙`,
+	CMD_bigcirc:         `◯`,
+	CMD_dagger:          `†`,
+	CMD_ddagger:         `‡`,
+	CMD_wr:              `≀`,
+	CMD_amalg:           `∐`,
+
+	CMD_models:     `⊨`,
+	CMD_prec:       `≺`,
+	CMD_succ:       `≻`,
+	CMD_preceq:     `≼`,
+	CMD_succeq:     `≽`,
+	CMD_simeq:      `≃`,
+	CMD_mid:        `∣`,
+	CMD_ll:         `≪`,
+	CMD_gg:         `≫`,
+	CMD_parallel:   `∥`,
+	CMD_bowtie:     `⋈`,
+	CMD_sqsubset:   `⊏`,
+	CMD_sqsupset:   `⊐`,
+	CMD_smile:      `⌣`,
+	CMD_sqsubseteq: `⊑`,
+	CMD_sqsupseteq: `⊒`,
+	CMD_doteq:      `≐`,
+	CMD_frown:      `⌢`,
+	CMD_vdash:      `⊦`,
+	CMD_dashv:      `⊣`,
+
+	CMD_longleftarrow:      `←`,
+	CMD_longrightarrow:     `→`,
+	CMD_Longleftarrow:      `⇐`,
+	CMD_Longrightarrow:     `⇒`,
+	CMD_longleftrightarrow: `↔`,
+	CMD_updownarrow:        `↕`,
+	CMD_Longleftrightarrow: `⇔`,
+	CMD_Updownarrow:        `⇕`,
+	CMD_mapsto:             `↦`,
+	CMD_nearrow:            `↗`,
+	CMD_hookleftarrow:      `↩`,
+	CMD_hookrightarrow:     `↪`,
+	CMD_searrow:            `↘`,
+	CMD_leftharpoonup:      `↼`,
+	CMD_rightharpoonup:     `⇀`,
+	CMD_swarrow:            `↙`,
+	CMD_leftharpoondown:    `↽`,
+	CMD_rightharpoondown:   `⇁`,
+	CMD_nwarrow:            `↖`,
+
+	CMD_ldots:       `…`,
+	CMD_cdots:       `⋯`,
+	CMD_vdots:       `⋮`,
+	CMD_ddots:       `⋰`,
+	CMD_surd:        `√`,
+	CMD_triangle:    `▵`,
+	CMD_ell:         `ℓ`,
+	CMD_top:         `⊤`,
+	CMD_flat:        `♭`,
+	CMD_natural:     `♮`,
+	CMD_sharp:       `♯`,
+	CMD_wp:          `℘`,
+	CMD_bot:         `⊥`,
+	CMD_clubsuit:    `♣`,
+	CMD_diamondsuit: `♢`,
+	CMD_heartsuit:   `♡`,
+	CMD_spadesuit:   `♠`,
+
+	CMD_oint:      `∮`,
+	CMD_bigcap:    `∩`,
+	CMD_bigcup:    `∪`,
+	CMD_bigsqcup:  `⊔`,
+	CMD_bigvee:    `∨`,
+	CMD_bigwedge:  `∧`,
+	CMD_bigodot:   `⊙`,
+	CMD_bigotimes: `⊗`,
+	CMD_bigoplus:  `⊕`,
+	CMD_biguplus:  `⊎`,
+
+	CMD_lfloor:          `⌊`,
+	CMD_rfloor:          `⌋`,
+	CMD_lceil:           `⌈`,
+	CMD_rceil:           `⌉`,
+	CMD_slash:           `/`,
+	CMD_opencurlybrace:  `{`,
+	CMD_closecurlybrace: `}`,
+
+	CMD_caret:         `^`,
+	CMD_underscore:    `_`,
+	CMD_backslash:     `\`,
+	CMD_vert:          `|`,
+	CMD_perp:          `⊥`,
+	CMD_nabla:         `∇`,
+	CMD_hbar:          `ℏ`,
+	CMD_AA:            `Å`,
+	CMD_circ:          `∘`,
+	CMD_bullet:        `•`,
+	CMD_setminus:      `∖`,
+	CMD_smallsetminus: `∖`,
+	CMD_neg:           `¬`,
+	CMD_dots:          `…`,
+
+	CMD_darr: `↓`,
+	CMD_dArr: `⇓`,
+	CMD_uarr: `↑`,
+	CMD_uArr: `⇑`,
+	CMD_to:   `→`,
+	CMD_rArr: `⇒`,
+	CMD_gets: `←`,
+	CMD_lArr: `⇐`,
+	CMD_harr: `↔`,
+	CMD_hArr: `⇔`,
+
+	CMD_Re:      `ℜ`,
+	CMD_Im:      `ℑ`,
+	CMD_partial: `∂`,
+
+	CMD_infty: `∞`,
+	CMD_alef:  `ℵ`,
+
+	CMD_forall: `∀`,
+	CMD_exists: `∃`,
+	CMD_land:   `∧`,
+	CMD_lor:    `∨`,
+
+	CMD_emptyset: `∅`,
+	CMD_cup:      `∪`,
+	CMD_cap:      `∩`,
+
+	CMD_degree: `°`,
+	CMD_angle:  `∠`,
+
+	CMD_ln:   `ln `,
+	CMD_lg:   `lg `,
+	CMD_log:  `log `,
+	CMD_span: `span `,
+	CMD_proj: `proj `,
+	CMD_det:  `det `,
+	CMD_dim:  `dim `,
+	CMD_min:  `min `,
+	CMD_max:  `max `,
+	CMD_mod:  `mod `,
+	CMD_lcm:  `lcm `,
+	CMD_gcd:  `gcd `,
+	CMD_gcf:  `gcf `,
+	CMD_hcf:  `hcf `,
+	CMD_lim:  `lim `,
+
+	CMD_sin:   `sin `,
+	CMD_cos:   `cos `,
+	CMD_tan:   `tan `,
+	CMD_sec:   `sec `,
+	CMD_cosec: `cosec `,
+	CMD_csc:   `csc `,
+	CMD_cotan: `cotan `,
+	CMD_cot:   `cot `,
+
+	CMD_sinh:   `sinh `,
+	CMD_cosh:   `cosh `,
+	CMD_tanh:   `tanh `,
+	CMD_sech:   `sech `,
+	CMD_cosech: `cosech `,
+	CMD_csch:   `csch `,
+	CMD_cotanh: `cotanh `,
+	CMD_coth:   `coth `,
+
+	CMD_asin:   `asin `,
+	CMD_acos:   `acos `,
+	CMD_atan:   `atan `,
+	CMD_asec:   `asec `,
+	CMD_acosec: `acosec `,
+	CMD_acsc:   `acsc `,
+	CMD_acotan: `acotan `,
+	CMD_acot:   `acot `,
+
+	CMD_asinh:   `asinh `,
+	CMD_acosh:   `acosh `,
+	CMD_atanh:   `atanh `,
+	CMD_asech:   `asech `,
+	CMD_acosech: `acosech `,
+	CMD_acsch:   `acsch `,
+	CMD_acotanh: `acotanh `,
+	CMD_acoth:   `acoth `,
+
+	CMD_arcsin:   `arcsin `,
+	CMD_arccos:   `arccos `,
+	CMD_arctan:   `arctan `,
+	CMD_arcsec:   `arcsec `,
+	CMD_arccosec: `arccosec `,
+	CMD_arccsc:   `arccsc `,
+	CMD_arccotan: `arccotan `,
+	CMD_arccot:   `arccot `,
+
+	CMD_arcsinh:   `arcsinh `,
+	CMD_arccosh:   `arccosh `,
+	CMD_arctanh:   `arctanh `,
+	CMD_arcsech:   `arcsech `,
+	CMD_arccosech: `arccosech `,
+	CMD_arccsch:   `arccsch `,
+	CMD_arccotanh: `arccotanh `,
+	CMD_arccoth:   `arccoth `,
+	// extended symbols by pie framework
+	CMD_complement:       `∁`,
+	CMD_nexists:          `∄`,
+	CMD_sphericalangle:   `∢`,
+	CMD_iint:             `∬`,
+	CMD_iiint:            `∭`,
+	CMD_oiint:            `∯`,
+	CMD_oiiint:           `∰`,
+	CMD_backsim:          `∽`,
+	CMD_backsimeq:        `⋍`,
+	CMD_eqsim:            `≂`,
+	CMD_ncong:            `≇`,
+	CMD_approxeq:         `≊`,
+	CMD_bumpeq:           `≏`,
+	CMD_Bumpeq:           `≎`,
+	CMD_doteqdot:         `≑`,
+	CMD_fallingdotseq:    `≒`,
+	CMD_risingdotseq:     `≓`,
+	CMD_eqcirc:           `≖`,
+	CMD_circeq:           `≗`,
+	CMD_triangleq:        `≜`,
+	CMD_leqq:             `≦`,
+	CMD_geqq:             `≧`,
+	CMD_lneqq:            `≨`,
+	CMD_gneqq:            `≩`,
+	CMD_between:          `≬`,
+	CMD_nleq:             `≰`,
+	CMD_ngeq:             `≱`,
+	CMD_lesssim:          `≲`,
+	CMD_gtrsim:           `≳`,
+	CMD_lessgtr:          `≶`,
+	CMD_gtrless:          `≷`,
+	CMD_preccurlyeq:      `≼`,
+	CMD_succcurlyeq:      `≽`,
+	CMD_precsim:          `≾`,
+	CMD_succsim:          `≿`,
+	CMD_nprec:            `⊀`,
+	CMD_nsucc:            `⊁`,
+	CMD_subsetneq:        `⊊`,
+	CMD_supsetneq:        `⊋`,
+	CMD_vDash:            `⊨`,
+	CMD_Vdash:            `⊩`,
+	CMD_Vvdash:           `⊪`,
+	CMD_VDash:            `⊫`,
+	CMD_nvdash:           `⊬`,
+	CMD_nvDash:           `⊭`,
+	CMD_nVdash:           `⊮`,
+	CMD_nVDash:           `⊯`,
+	CMD_vartriangleleft:  `⊲`,
+	CMD_vartriangleright: `⊳`,
+	CMD_trianglelefteq:   `⊴`,
+	CMD_trianglerighteq:  `⊵`,
+	CMD_multimap:         `⊸`,
+	CMD_Subset:           `⋐`,
+	CMD_Supset:           `⋑`,
+	CMD_Cap:              `⋒`,
+	CMD_Cup:              `⋓`,
+	CMD_pitchfork:        `⋔`,
+	CMD_lessdot:          `⋖`,
+	CMD_gtrdot:           `⋗`,
+	CMD_lll:              `⋘`,
+	CMD_ggg:              `⋙`,
+	CMD_lesseqgtr:        `⋚`,
+	CMD_gtreqless:        `⋛`,
+	CMD_curlyeqprec:      `⋞`,
+	CMD_curlyeqsucc:      `⋟`,
+	CMD_nsim:             `≁`,
+	CMD_lnsim:            `⋦`,
+	CMD_gnsim:            `⋧`,
+	CMD_precnsim:         `⋨`,
+	CMD_succnsim:         `⋩`,
+	CMD_ntriangleleft:    `⋪`,
+	CMD_ntriangleright:   `⋫`,
+	CMD_ntrianglelefteq:  `⋬`,
+	CMD_ntrianglerighteq: `⋭`,
+	CMD_blacksquare:      `∎`,
+	CMD_colon:            `∶`,
+	CMD_llcorner:         `∟`,
+	CMD_dotplus:          `∔`,
+	CMD_nmid:             `∤`,
+	CMD_intercal:         `⊺`,
+	CMD_veebar:           `⊻`,
+	CMD_barwedge:         `⊼`,
+	CMD_ltimes:           `⋉`,
+	CMD_rtimes:           `⋊`,
+	CMD_leftthreetimes:   `⋋`,
+	CMD_rightthreetimes:  `⋌`,
+	CMD_curlyvee:         `⋎`,
+	CMD_curlywedge:       `⋏`,
+	CMD_circledcirc:      `⊚`,
+	CMD_circledast:       `⊛`,
+	CMD_circleddash:      `⊝`,
+	CMD_boxplus:          `⊞`,
+	CMD_boxminus:         `⊟`,
+	CMD_boxtimes:         `⊠`,
+	CMD_boxdot:           `⊡`,
+}
+
+// commands to pick when several of them are displayed the same way
+var unicodePreferred = map[string]LatexCmd{
+	`ε`: CMD_varepsilon,
+	`ς`: CMD_varsigma,
+	`υ`: CMD_upsilon,
+	`φ`: CMD_varphi,
+	`ϑ`: CMD_vartheta,
+	`ϒ`: CMD_Upsilon,
+	`ϖ`: CMD_varpi,
+	`ϝ`: CMD_digamma,
+	`ϰ`: CMD_varkappa,
+	`ϱ`: CMD_varrho,
+	`…`: CMD_ldots,
+	`←`: CMD_gets,
+	`→`: CMD_to,
+	`↔`: CMD_harr,
+	`⇐`: CMD_lArr,
+	`⇒`: CMD_rArr,
+	`⇔`: CMD_hArr,
+	`∐`: CMD_coprod,
+	`∖`: CMD_setminus,
+	`∧`: CMD_land,
+	`∨`: CMD_lor,
+	`∩`: CMD_cap,
+	`∪`: CMD_cup,
+	`≼`: CMD_preceq,
+	`≽`: CMD_succeq,
+	`⊎`: CMD_uplus,
+	`⊔`: CMD_sqcup,
+	`⊕`: CMD_oplus,
+	`⊗`: CMD_otimes,
+	`⊙`: CMD_odot,
+	`⊥`: CMD_perp,
+	`⊨`: CMD_models,
+	`⊲`: CMD_triangleleft,
+	`⊳`: CMD_triangleright,
+}
+
+// UnicodeToVanilla maps single unicode symbols back to the command that is
+// displayed as that symbol (VanillaToUnicode in reverse). ASCII characters
+// are left out, they are SimpleOpLits
+var UnicodeToVanilla = map[string]LatexCmd{}
+
+func init() {
+	for cmd, s := range VanillaToUnicode {
+		r, size := utf8.DecodeRuneInString(s)
+		if size != len(s) || r < utf8.RuneSelf || unicode.IsSpace(r) {
+			continue
+		}
+		if preferred, ok := unicodePreferred[s]; ok {
+			cmd = preferred
+		}
+		UnicodeToVanilla[s] = cmd
+	}
+}
+
+// Unicode superscript and subscript characters, e.g. "x²" is read as "x^{2}"
+var (
+	unicodeSuperscripts = map[rune]rune{
+		'⁰': '0', '¹': '1', '²': '2', '³': '3', '⁴': '4', '⁵': '5', '⁶': '6', '⁷': '7', '⁸': '8', '⁹': '9',
+		'⁺': '+', '⁻': '-', '⁼': '=', '⁽': '(', '⁾': ')', 'ⁿ': 'n', 'ⁱ': 'i',
+	}
+	unicodeSubscripts = map[rune]rune{
+		'₀': '0', '₁': '1', '₂': '2', '₃': '3', '₄': '4', '₅': '5', '₆': '6', '₇': '7', '₈': '8', '₉': '9',
+		'₊': '+', '₋': '-', '₌': '=', '₍': '(', '₎': ')',
+	}
+)
+
+// NormalizeScript returns the superscript (CMD_superscript) or subscript
+// (CMD_subscript) command r stands for, and the plain character in the
+// script, e.g. '²' gives CMD_superscript and '2'
+func NormalizeScript(r rune) (LatexCmd, rune, bool) {
+	if plain, ok := unicodeSuperscripts[r]; ok {
+		return CMD_superscript, plain, true
+	}
+	if plain, ok := unicodeSubscripts[r]; ok {
+		return CMD_subscript, plain, true
+	}
+	return CMD_UNKNOWN, 0, false
+}
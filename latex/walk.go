@@ -0,0 +1,150 @@
+package latex
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Expr) (w Visitor)
+}
+
+// Walk traverses a tree in depth-first order: it starts by calling
+// v.Visit(node); node must not be nil. If the visitor w returned by
+// v.Visit(node) is not nil, Walk is invoked recursively with visitor
+// w for each of the non-nil children of node, followed by a call of
+// w.Visit(nil).
+//
+// The children of a node are the ones listed by ChildrenOf, so that EnvExpr
+// cells, the text of a TextContainer and nodes defined outside of this
+// package (e.g. the editor's) are visited too
+func Walk(v Visitor, node Expr) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	for _, child := range ChildrenOf(node) {
+		if child != nil {
+			Walk(v, child)
+		}
+	}
+	v.Visit(nil)
+}
+
+type inspector func(Expr) bool
+
+func (f inspector) Visit(node Expr) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses a tree in depth-first order: it starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the non-nil children of node, followed by a
+// call of f(nil).
+func Inspect(node Expr, f func(Expr) bool) {
+	Walk(inspector(f), node)
+}
+
+// ChildrenOf returns the direct children of node: the cells of an EnvExpr, row
+// by row, the argument of a SuperExpr/SubExpr and the Children() of any other
+// Container. Literals have no children
+func ChildrenOf(node Expr) []Expr {
+	switch n := node.(type) {
+	case *SuperExpr:
+		return []Expr{n.X}
+	case *SubExpr:
+		return []Expr{n.X}
+	case *TextContainer:
+		if n.Text == nil {
+			return nil
+		}
+		return []Expr{n.Text}
+	case Container:
+		// includes EnvExpr, whose Children() are its cells
+		return n.Children()
+	}
+	return nil
+}
+
+// Rewrite replaces every node of a tree by f(node), bottom-up: the children of
+// a node are rewritten before the node itself, and f sees the node with its
+// rewritten children. The (possibly new) root is returned.
+//
+// Returning nil from f removes the node from its FlexContainer; anywhere else,
+// e.g. as a command argument or EnvExpr cell, it is replaced by an empty
+// group. A replacement for an EnvExpr cell that is not an UnboundCompExpr is
+// wrapped in one, and the text of a TextContainer is emptied if it is not
+// replaced by a TextStringWrapper. Replacements for the arguments of other
+// FixedContainers must be accepted by their SetArg
+func Rewrite(node Expr, f func(Expr) Expr) Expr {
+	if node == nil {
+		return nil
+	}
+	switch n := node.(type) {
+	case *EnvExpr:
+		for _, row := range n.Elts {
+			for j, cell := range row {
+				if cell == nil {
+					continue
+				}
+				switch x := Rewrite(cell, f).(type) {
+				case *UnboundCompExpr:
+					row[j] = x
+				case nil:
+					row[j] = &UnboundCompExpr{}
+				default:
+					row[j] = &UnboundCompExpr{Elts: []Expr{x}}
+				}
+			}
+		}
+	case *SuperExpr:
+		n.X = rewriteArg(n.X, f)
+	case *SubExpr:
+		n.X = rewriteArg(n.X, f)
+	case *TextContainer:
+		if n.Text != nil {
+			text, _ := Rewrite(n.Text, f).(*TextStringWrapper)
+			if text == nil {
+				text = &TextStringWrapper{}
+			}
+			n.Text = text
+		}
+	case FlexContainer:
+		children := n.Children()
+		rewritten := make([]Expr, 0, len(children))
+		changed := false
+		for _, child := range children {
+			x := Rewrite(child, f)
+			if x != child {
+				changed = true
+			}
+			if x != nil {
+				rewritten = append(rewritten, x)
+			}
+		}
+		if changed {
+			if len(children) > 0 {
+				n.DeleteChildren(0, len(children)-1)
+			}
+			n.AppendChildren(rewritten...)
+		}
+	case FixedContainer:
+		for i, child := range n.Children() {
+			if x := rewriteArg(child, f); x != child {
+				n.SetArg(i, x)
+			}
+		}
+	}
+	return f(node)
+}
+
+// rewrites a node that cannot be removed, see Rewrite
+func rewriteArg(node Expr, f func(Expr) Expr) Expr {
+	if node == nil {
+		return nil
+	}
+	if x := Rewrite(node, f); x != nil {
+		return x
+	}
+	return &CompositeExpr{}
+}
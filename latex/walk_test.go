@@ -0,0 +1,161 @@
+package latex
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// a node defined outside of this package, like the editor's
+type extensionNode struct {
+	Text *TextStringWrapper
+}
+
+func (x *extensionNode) Pos() Pos                                { return 0 }
+func (x *extensionNode) End() Pos                                { return 0 }
+func (x *extensionNode) VisualizeTree() string                   { return "extension" }
+func (x *extensionNode) DeepEq(other Expr) bool                  { return x == other }
+func (x *extensionNode) DeepEqWith(other Expr, _ DeepEqCfg) bool { return x == other }
+func (x *extensionNode) Children() []Expr                        { return []Expr{x.Text} }
+func (x *extensionNode) Parameters() int                         { return 1 }
+func (x *extensionNode) SetArg(_ int, expr Expr)                 { x.Text = expr.(*TextStringWrapper) }
+
+// lists the nodes in visiting order, literals by their content
+func visitOrder(node Expr) string {
+	var names []string
+	Inspect(node, func(n Expr) bool {
+		switch n := n.(type) {
+		case nil:
+		case Literal:
+			names = append(names, n.Content())
+		case *extensionNode:
+			names = append(names, "ext")
+		default:
+			names = append(names, strings.TrimPrefix(fmt.Sprintf("%T", n), "*latex."))
+		}
+		return true
+	})
+	return strings.Join(names, " ")
+}
+
+func TestInspect(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect string
+	}{
+		{`x+1`, `UnboundCompExpr x + 1`},
+		{`\frac{a}{b}`, `UnboundCompExpr Cmd2ArgExpr CompositeExpr a CompositeExpr b`},
+		{`\begin{matrix}a & b \\ c & d\end{matrix}`, `UnboundCompExpr EnvExpr UnboundCompExpr a UnboundCompExpr b UnboundCompExpr c UnboundCompExpr d`},
+		{`\text{hi}`, `UnboundCompExpr TextContainer TextStringWrapper h i`},
+	}
+	for _, tc := range testCases {
+		if got := visitOrder(Parse(tc.input)); got != tc.expect {
+			t.Errorf("%s: got %q, expected %q", tc.input, got, tc.expect)
+		}
+	}
+
+	tree := &UnboundCompExpr{Elts: []Expr{&extensionNode{Text: textWrapper("ab")}}}
+	if got, expect := visitOrder(tree), "UnboundCompExpr ext TextStringWrapper a b"; got != expect {
+		t.Errorf("extension node: got %q, expected %q", got, expect)
+	}
+}
+
+func TestInspectSkip(t *testing.T) {
+	count := 0
+	Inspect(Parse(`x^{2} + \sqrt{y}`), func(n Expr) bool {
+		if n != nil {
+			count++
+		}
+		_, isCmd := n.(*Cmd1ArgExpr)
+		return !isCmd
+	})
+	// $, x, ^, +, \sqrt
+	if count != 5 {
+		t.Errorf("visited %d nodes, expected 5", count)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		input  string
+		f      func(Expr) Expr
+		expect string
+	}{
+		{
+			desc:  "replace literals everywhere",
+			input: `x + \frac{x}{2} + \begin{matrix}x & y\end{matrix}`,
+			f: func(n Expr) Expr {
+				if v, ok := n.(*VarLit); ok && v.Source == "x" {
+					return &SimpleCmdLit{Source: `\alpha`, Type: CMD_alpha}
+				}
+				return n
+			},
+			expect: `\alpha + \frac{\alpha}{2} + \begin{matrix}\alpha & y\end{matrix}`,
+		},
+		{
+			desc:  "remove nodes",
+			input: `a + b \cdot c`,
+			f: func(n Expr) Expr {
+				if op, ok := n.(*SimpleOpLit); ok && op.Source == "+" {
+					return nil
+				}
+				if v, ok := n.(*VarLit); ok && v.Source == "b" {
+					return nil
+				}
+				return n
+			},
+			expect: `a \cdot c`,
+		},
+		{
+			desc:  "removed arguments and cells become empty",
+			input: `\sqrt{x} \begin{matrix}x & y\end{matrix}`,
+			f: func(n Expr) Expr {
+				switch n.(type) {
+				case *CompositeExpr:
+					return nil
+				case *UnboundCompExpr:
+					if len(n.(*UnboundCompExpr).Elts) == 1 && n.(*UnboundCompExpr).Elts[0].(*VarLit).Source == "x" {
+						return nil
+					}
+				}
+				return n
+			},
+			expect: `\sqrt{} \begin{matrix} & y\end{matrix}`,
+		},
+		{
+			desc:  "cells are wrapped",
+			input: `\begin{matrix}x & y\end{matrix}`,
+			f: func(n Expr) Expr {
+				if u, ok := n.(*UnboundCompExpr); ok && len(u.Elts) == 1 {
+					if v, ok := u.Elts[0].(*VarLit); ok {
+						return v
+					}
+				}
+				return n
+			},
+			expect: `\begin{matrix}x & y\end{matrix}`,
+		},
+		{
+			desc:  "text",
+			input: `\text{ab}`,
+			f: func(n Expr) Expr {
+				if r, ok := n.(RawRuneLit); ok && r == 'a' {
+					return RawRuneLit('c')
+				}
+				return n
+			},
+			expect: `\text{cb}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := Rewrite(Parse(tc.input), tc.f)
+			expect := Parse(tc.expect)
+			if !expect.DeepEqWith(got, DeepEqCfg{SkipPos: true}) {
+				t.Errorf("got:\n%s\nexpected:\n%s", got.VisualizeTree(), expect.VisualizeTree())
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Package lint finds common mistakes in LaTeX formulas, e.g. "sin x" written
+// without a backslash or "x^2^3", and fixes the ones that have an unambiguous
+// fix
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/horriblename/mathcha/latex"
+)
+
+type Rule string
+
+const (
+	UnmatchedLeftRight Rule = "unmatched-left-right" // \left without \right or vice versa
+	ParseError         Rule = "parse-error"          // the formula could not be parsed
+	UnknownCommand     Rule = "unknown-command"      // e.g. \foo
+	FunctionLetters    Rule = "function-letters"     // sin x instead of \sin x
+	Asterisk           Rule = "asterisk"             // a*b instead of a \cdot b
+	AmbiguousDivision  Rule = "ambiguous-division"   // a/bc, a/(b)c or a/b c?
+	DoubleScript       Rule = "double-script"        // x^2^3
+	RaggedMatrix       Rule = "ragged-matrix"        // rows of a matrix of different lengths
+)
+
+// An Issue is a mistake found in a formula, at Line and Column (starting at
+// 1). Issues found in the syntax tree also have a Path, the child indices (see
+// latex.ChildrenOf) leading from the root to the offending node. A formula
+// that cannot be parsed has a single issue without a position
+type Issue struct {
+	Rule    Rule   `json:"rule"`
+	Message string `json:"message"`
+	Path    []int  `json:"path,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	// the offending part of the formula
+	Source  string `json:"source"`
+	Fixable bool   `json:"fixable"`
+
+	// offset of an issue found in the syntax tree in the parsed source and
+	// its fix, if it is fixable
+	offset int
+	fix    *edit
+}
+
+// String describes the issue on a single line, e.g.
+// "1:2: use \cdot instead of * (asterisk)"
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s (%s)", i.Where(), i.Message, i.Rule)
+}
+
+// Where is the location of the issue: "line:column" or "root" if it has none
+func (i Issue) Where() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("%d:%d", i.Line, i.Column)
+	}
+	return "root"
+}
+
+// Lint returns the issues of a formula, in source order. If the formula cannot
+// be parsed, the issues found in the syntax tree are missing
+func Lint(src string) []Issue {
+	issues, fixed, removed := scanLeftRight(src)
+	tree, err := latex.ParseSafe(fixed)
+	if err != nil {
+		if len(issues) == 0 {
+			issues = append(issues, Issue{Rule: ParseError, Message: err.Error()})
+		}
+		return issues
+	}
+	l := linter{src: fixed}
+	l.node(tree, nil)
+	for _, issue := range l.issues {
+		issue.Line, issue.Column = position(src, originalOffset(removed, issue.offset))
+		issues = append(issues, issue)
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return issues
+}
+
+// Fix applies the fixes of all fixable issues and returns the fixed formula
+// along with the issues that are left. Only the offending parts of the formula
+// are rewritten, the rest is kept as is
+func Fix(src string) (string, []Issue) {
+	issues := Lint(src)
+	fixable := false
+	for _, issue := range issues {
+		fixable = fixable || issue.Fixable
+	}
+	if !fixable {
+		return src, issues
+	}
+
+	_, fixed, _ := scanLeftRight(src)
+	// fixes may contain each other, e.g. a * in a double script, the inner
+	// one is left for the next pass
+	for pass := 0; pass < maxFixPasses; pass++ {
+		tree, err := latex.ParseSafe(fixed)
+		if err != nil {
+			break
+		}
+		l := linter{src: fixed}
+		l.node(tree, nil)
+		var edits []edit
+		for _, issue := range l.issues {
+			if issue.Fixable {
+				edits = append(edits, *issue.fix)
+			}
+		}
+		if len(edits) == 0 {
+			break
+		}
+		fixed = apply(fixed, edits)
+	}
+	return fixed, Lint(fixed)
+}
+
+const maxFixPasses = 8
+
+// An edit replaces src[start:end] with text
+type edit struct {
+	start, end int
+	text       string
+}
+
+// applies the edits that don't overlap a previous one
+func apply(src string, edits []edit) string {
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var b strings.Builder
+	last := 0
+	for _, e := range edits {
+		if e.start < last {
+			continue
+		}
+		b.WriteString(src[last:e.start])
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.WriteString(src[last:])
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect []string
+	}{
+		{`\sin x + \frac{a}{b}`, nil},
+		{`\left(x`, []string{`1:1: \left without \right (unmatched-left-right)`}},
+		{"x +\n y\\right)", []string{`2:3: \right without \left (unmatched-left-right)`}},
+		{`\left( x \right]`, []string{`root: \right expected ')' but got ] (parse-error)`}},
+		{`\foo`, []string{`1:1: unknown command \foo (unknown-command)`}},
+		{`sin x + \frac{cos y}{2}`, []string{
+			`1:1: use \sin instead of sin (function-letters)`,
+			`1:15: use \cos instead of cos (function-letters)`,
+		}},
+		{`using`, nil},
+		{`cost + sinx`, nil},
+		{"{a*b} +\n sin x", []string{
+			`1:3: use \cdot instead of * (asterisk)`,
+			`2:2: use \sin instead of sin (function-letters)`,
+		}},
+		{`\left(a*b`, []string{
+			`1:1: \left without \right (unmatched-left-right)`,
+			`1:8: use \cdot instead of * (asterisk)`,
+		}},
+		{`a*b`, []string{`1:2: use \cdot instead of * (asterisk)`}},
+		{`a/bc`, []string{`1:2: ambiguous division, use \frac or parentheses (ambiguous-division)`}},
+		{`1/2x`, []string{`1:2: ambiguous division, use \frac or parentheses (ambiguous-division)`}},
+		{`a/(b+c) + 12/34 + x/y^2`, nil},
+		{`x^2^3`, []string{`1:2: double superscript, use braces to nest them (double-script)`}},
+		{`x_1^2`, nil},
+		{`\begin{matrix}a & b \\ c\end{matrix}`, []string{`1:1: the rows of the matrix have different lengths (ragged-matrix)`}},
+		{`\begin{align}a & = b \\ c\end{align}`, nil},
+	}
+
+	for _, tc := range testCases {
+		var got []string
+		for _, issue := range Lint(tc.input) {
+			got = append(got, issue.String())
+		}
+		if !reflect.DeepEqual(got, tc.expect) {
+			t.Errorf("%q: got %q, expected %q", tc.input, got, tc.expect)
+		}
+	}
+}
+
+func TestFix(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect string
+		left   int
+	}{
+		{`\left(x`, `(x`, 0},
+		{`sin x*y`, `\sin x\cdot y`, 0},
+		{`a * b`, `a \cdot b`, 0},
+		{`cost*y`, `cost\cdot y`, 0},
+		{`x^2^3^4`, `x^{2^{3^4}}`, 0},
+		{`x_{a*b}_2 + 1`, `x_{a\cdot b_2} + 1`, 0},
+		{`\begin{matrix}a&b\\c\end{matrix}`, `\begin{matrix}a&b\\c &\end{matrix}`, 0},
+		{"\\begin{matrix}\n  a & b & c \\\\\n  d\n\\end{matrix}", "\\begin{matrix}\n  a & b & c \\\\\n  d & &\n\\end{matrix}", 0},
+		{`\foo * a/bc`, `\foo \cdot a/bc`, 2},
+		// nothing to fix, the source is kept as is
+		{`a/bc`, `a/bc`, 1},
+	}
+
+	for _, tc := range testCases {
+		got, issues := Fix(tc.input)
+		if got != tc.expect {
+			t.Errorf("%q: got %q, expected %q", tc.input, got, tc.expect)
+		}
+		if len(issues) != tc.left {
+			t.Errorf("%q: %d issues left, expected %d: %v", tc.input, len(issues), tc.left, issues)
+		}
+	}
+}
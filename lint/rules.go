@@ -0,0 +1,379 @@
+package lint
+
+import (
+	"strings"
+
+	"github.com/horriblename/mathcha/latex"
+)
+
+// finds \left without \right and vice versa in the source, which the parser
+// cannot cope with. The fix drops the unmatched command and keeps the
+// delimiter as is, fixed is the source with all fixes applied and removed
+// are the spans of src that were dropped, in order
+func scanLeftRight(src string) (issues []Issue, fixed string, removed []span) {
+	type command struct {
+		name       string
+		start, end int
+	}
+	var open, unmatched []command
+	for i := 0; i < len(src); i++ {
+		if src[i] != '\\' {
+			continue
+		}
+		j := i + 1
+		for j < len(src) && isLetter(src[j]) {
+			j++
+		}
+		if j == i+1 {
+			// a symbol, e.g. \\ or \{
+			i++
+			continue
+		}
+		cmd := command{name: src[i:j], start: i, end: j}
+		switch cmd.name {
+		case `\left`:
+			open = append(open, cmd)
+		case `\right`:
+			if len(open) == 0 {
+				unmatched = append(unmatched, cmd)
+			} else {
+				open = open[:len(open)-1]
+			}
+		}
+		i = j - 1
+	}
+	unmatched = append(unmatched, open...)
+	if len(unmatched) == 0 {
+		return nil, src, nil
+	}
+
+	ends := make(map[int]int, len(unmatched))
+	for _, cmd := range unmatched {
+		ends[cmd.start] = cmd.end
+	}
+	var b strings.Builder
+	for i := 0; i < len(src); i++ {
+		if end, ok := ends[i]; ok {
+			line, col := position(src, i)
+			message := `\left without \right`
+			if src[i:end] == `\right` {
+				message = `\right without \left`
+			}
+			issues = append(issues, Issue{
+				Rule:    UnmatchedLeftRight,
+				Message: message,
+				Line:    line,
+				Column:  col,
+				Source:  src[i:end],
+				Fixable: true,
+			})
+			removed = append(removed, span{i, end})
+			i = end - 1
+			continue
+		}
+		b.WriteByte(src[i])
+	}
+	return issues, b.String(), removed
+}
+
+type span struct{ start, end int }
+
+// maps an offset in the source with the spans removed back to the original
+func originalOffset(removed []span, offset int) int {
+	for _, s := range removed {
+		if s.start > offset {
+			break
+		}
+		offset += s.end - s.start
+	}
+	return offset
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// line and column (in runes) of a byte offset, starting at 1
+func position(src string, offset int) (line, col int) {
+	before := src[:offset]
+	line = strings.Count(before, "\n") + 1
+	col = len([]rune(before[strings.LastIndexByte(before, '\n')+1:])) + 1
+	return line, col
+}
+
+// functions that are commonly typed as letters
+var functionNames = map[string]bool{
+	"arcsin": true, "arccos": true, "arctan": true,
+	"sinh": true, "cosh": true, "tanh": true, "coth": true,
+	"sin": true, "cos": true, "tan": true, "sec": true, "csc": true, "cot": true,
+	"log": true, "lim": true, "max": true, "min": true, "det": true, "dim": true, "gcd": true,
+	"ln": true,
+}
+
+type linter struct {
+	src    string // the parsed source
+	issues []Issue
+}
+
+// adds an issue at the position of node, with fix as its fix if it is fixable
+func (l *linter) add(issue Issue, node latex.Expr, path []int, fix edit) {
+	issue.Path = append([]int(nil), path...)
+	issue.offset = int(node.Pos())
+	if issue.Fixable {
+		issue.fix = &fix
+	}
+	l.issues = append(l.issues, issue)
+}
+
+// the source of a node or a run of siblings
+func (l *linter) source(first, last latex.Expr) string {
+	return l.src[first.Pos() : last.End()+1]
+}
+
+func (l *linter) node(node latex.Expr, path []int) {
+	switch n := node.(type) {
+	case *latex.UnknownCmdLit:
+		l.add(Issue{Rule: UnknownCommand, Message: "unknown command " + n.Source, Source: n.Source}, n, path, edit{})
+	case *latex.SimpleOpLit:
+		if n.Source == "*" {
+			l.add(Issue{Rule: Asterisk, Message: `use \cdot instead of *`, Source: n.Source, Fixable: true},
+				n, path, l.replaceAsterisk(n))
+		}
+	case *latex.EnvExpr:
+		if n.Name == latex.ENV_matrix && ragged(n) {
+			l.add(Issue{
+				Rule:    RaggedMatrix,
+				Message: "the rows of the matrix have different lengths",
+				Source:  l.source(n, n),
+				Fixable: true,
+			}, n, path, l.padRows(n))
+		}
+	}
+
+	children := latex.ChildrenOf(node)
+	if _, ok := node.(latex.FlexContainer); ok {
+		l.siblings(children, path)
+	}
+	for i, child := range children {
+		if child != nil {
+			l.node(child, append(path, i))
+		}
+	}
+}
+
+// rules concerning a run of siblings, reported at the first one
+func (l *linter) siblings(nodes []latex.Expr, path []int) {
+	for i := 0; i < len(nodes); i++ {
+		if name := functionAt(nodes, i); name != "" {
+			l.add(Issue{
+				Rule:    FunctionLetters,
+				Message: `use \` + name + " instead of " + name,
+				Source:  name,
+				Fixable: true,
+			}, nodes[i], append(path, i), edit{start: int(nodes[i].Pos()), end: int(nodes[i].Pos()), text: `\`})
+			i += len(name) - 1
+			continue
+		}
+		if isScript(nodes[i]) && i+1 < len(nodes) && sameScript(nodes[i], nodes[i+1]) {
+			j := i + 1
+			for j+1 < len(nodes) && sameScript(nodes[j], nodes[j+1]) {
+				j++
+			}
+			fix, fixable := l.nestScripts(nodes[i : j+1])
+			l.add(Issue{
+				Rule:    DoubleScript,
+				Message: "double " + scriptName(nodes[i]) + ", use braces to nest them",
+				Source:  l.source(nodes[i], nodes[i+1]),
+				Fixable: fixable,
+			}, nodes[i], append(path, i), fix)
+			i = j
+			continue
+		}
+		if op, ok := nodes[i].(*latex.SimpleOpLit); ok && op.Source == "/" {
+			if n := divisorLength(nodes[i+1:]); operands(nodes[i+1:i+1+n]) > 1 {
+				l.add(Issue{
+					Rule:    AmbiguousDivision,
+					Message: "ambiguous division, use \\frac or parentheses",
+					Source:  l.source(nodes[i], nodes[i+n]),
+				}, op, append(path, i), edit{})
+			}
+		}
+	}
+}
+
+// the function spelled by the word starting at nodes[i], if any. A word is a
+// run of letters without spaces in between, "sinx" or "cost" are not functions
+func functionAt(nodes []latex.Expr, i int) string {
+	if !isVar(nodes[i]) || i > 0 && isVar(nodes[i-1]) && adjacent(nodes[i-1], nodes[i]) {
+		return ""
+	}
+	var word strings.Builder
+	for j := i; j < len(nodes) && isVar(nodes[j]) && (j == i || adjacent(nodes[j-1], nodes[j])); j++ {
+		word.WriteString(nodes[j].(*latex.VarLit).Source)
+	}
+	if functionNames[word.String()] {
+		return word.String()
+	}
+	return ""
+}
+
+func adjacent(a, b latex.Expr) bool {
+	return a.End()+1 == b.Pos()
+}
+
+func isVar(node latex.Expr) bool {
+	_, ok := node.(*latex.VarLit)
+	return ok
+}
+
+func isScript(node latex.Expr) bool {
+	cmd, ok := node.(*latex.Cmd1ArgExpr)
+	return ok && (cmd.Type == latex.CMD_superscript || cmd.Type == latex.CMD_subscript)
+}
+
+func sameScript(a, b latex.Expr) bool {
+	return isScript(b) && a.(*latex.Cmd1ArgExpr).Type == b.(*latex.Cmd1ArgExpr).Type
+}
+
+func scriptName(node latex.Expr) string {
+	if node.(*latex.Cmd1ArgExpr).Type == latex.CMD_subscript {
+		return "subscript"
+	}
+	return "superscript"
+}
+
+// the number of nodes following a "/" that belong to the divisor: everything
+// up to the next operator, relation or punctuation outside of parentheses
+func divisorLength(nodes []latex.Expr) int {
+	depth := 0
+	for i, node := range nodes {
+		switch n := node.(type) {
+		case *latex.SimpleOpLit:
+			switch n.Source {
+			case "(", "[":
+				depth++
+				continue
+			case ")", "]":
+				if depth > 0 {
+					depth--
+					continue
+				}
+			}
+			if depth == 0 {
+				return i
+			}
+		case *latex.SimpleCmdLit:
+			cmd := n.Command()
+			if depth == 0 && (cmd.IsRelation() || cmd.IsBinaryOperator() || cmd == latex.CMD_SPACE) {
+				return i
+			}
+		}
+	}
+	return len(nodes)
+}
+
+// counts the operands of a divisor that are multiplied implicitly: digits of
+// a number, scripts and anything in parentheses belong to the preceding operand
+func operands(nodes []latex.Expr) int {
+	count, depth := 0, 0
+	for i, node := range nodes {
+		switch n := node.(type) {
+		case *latex.SimpleOpLit:
+			switch n.Source {
+			case "(", "[":
+				if depth == 0 {
+					count++
+				}
+				depth++
+			case ")", "]":
+				depth--
+			}
+			continue
+		case *latex.NumberLit:
+			if i > 0 && depth == 0 {
+				if _, ok := nodes[i-1].(*latex.NumberLit); ok {
+					continue
+				}
+			}
+		}
+		if depth == 0 && !isScript(node) {
+			count++
+		}
+	}
+	return count
+}
+
+func ragged(env *latex.EnvExpr) bool {
+	for _, row := range env.Elts {
+		if len(row) != len(env.Elts[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// replaces a * with \cdot, keeping it apart from a following letter
+func (l *linter) replaceAsterisk(op *latex.SimpleOpLit) edit {
+	text := `\cdot`
+	if end := int(op.To) + 1; end < len(l.src) && isLetter(l.src[end]) {
+		text += " "
+	}
+	return edit{start: int(op.From), end: int(op.To) + 1, text: text}
+}
+
+// x^2^3 becomes x^{2^{3}}, nesting from the right. Scripts written as unicode
+// characters, e.g. x²^3, are not fixed
+func (l *linter) nestScripts(scripts []latex.Expr) (edit, bool) {
+	var b strings.Builder
+	for i, node := range scripts {
+		script := node.(*latex.Cmd1ArgExpr)
+		symbol := l.src[script.Backslash]
+		if symbol != '^' && symbol != '_' {
+			return edit{}, false
+		}
+		b.WriteByte(symbol)
+		if i == len(scripts)-1 {
+			b.WriteString(l.src[script.Backslash+1 : script.To+1])
+			break
+		}
+		b.WriteString("{")
+		if arg, ok := script.Arg1.(*latex.CompositeExpr); ok && arg.Rbrace > arg.Lbrace {
+			b.WriteString(l.src[arg.Lbrace+1 : arg.Rbrace])
+		} else {
+			b.WriteString(l.src[script.Backslash+1 : script.To+1])
+		}
+	}
+	b.WriteString(strings.Repeat("}", len(scripts)-1))
+	first, last := scripts[0], scripts[len(scripts)-1]
+	return edit{start: int(first.Pos()), end: int(last.End()) + 1, text: b.String()}, true
+}
+
+// fills up the rows of a matrix with empty cells, the cells are added after
+// the contents of the last cell of a row
+func (l *linter) padRows(env *latex.EnvExpr) edit {
+	cols := 0
+	for _, row := range env.Elts {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	src := []byte(l.source(env, env))
+	var b strings.Builder
+	last := 0
+	for _, row := range env.Elts {
+		if len(row) == cols {
+			continue
+		}
+		cell := row[len(row)-1]
+		at := int(cell.To)
+		if len(cell.Elts) > 0 {
+			at = int(cell.Elts[len(cell.Elts)-1].End()) + 1
+		}
+		at -= int(env.From)
+		b.Write(src[last:at])
+		b.WriteString(strings.Repeat(" &", cols-len(row)))
+		last = at
+	}
+	b.Write(src[last:])
+	return edit{start: int(env.From), end: int(env.To) + 1, text: b.String()}
+}
@@ -5,6 +5,7 @@ import (
 	// tea "github.com/charmbracelet/bubbletea"
 	// parser "github.com/horriblename/mathcha/latex"
 	// render "github.com/horriblename/mathcha/renderer"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
@@ -15,10 +16,15 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/derekparker/trie"
+	"github.com/horriblename/mathcha/asciimath"
+	"github.com/horriblename/mathcha/codegen"
 	"github.com/horriblename/mathcha/editor"
 	ed "github.com/horriblename/mathcha/editor"
+	"github.com/horriblename/mathcha/eval"
 	"github.com/horriblename/mathcha/latex"
+	"github.com/horriblename/mathcha/plot"
 	"github.com/horriblename/mathcha/renderer"
+	"github.com/horriblename/mathcha/semantic"
 )
 
 type model struct {
@@ -31,6 +37,12 @@ type model struct {
 	compMatches  []string
 	editorConfig *ed.EditorConfig
 	showHelp     bool
+	// draw the focused formula under it, see plot
+	showPlot bool
+	// the action waiting for a variable, see actions
+	pending rune
+	// shown under the editors, e.g. the error of the last action
+	message string
 }
 
 // some CLI flags are not present here cuz they don't matter to model init
@@ -39,6 +51,13 @@ type cliFlags struct {
 	printOut  *bool
 	logFile   *string
 	debugTree *bool
+	// format copied to the clipboard, one of copyFormats
+	copyFormat *string
+	// variables for the value shown under the focused editor
+	vars bindings
+	// whether to plot the focused formula at start and its range
+	plot  *bool
+	plotX *valueRange
 }
 
 func (m model) Init() tea.Cmd {
@@ -54,6 +73,7 @@ func initialModel(c cliFlags, editorCfg ed.EditorConfig, initFormula string) mod
 		editors:      []ed.Editor{*editor}, // TODO should prolly make this slice of pointers to Editors
 		compList:     latex.NewCompletion(),
 		editorConfig: &editorCfg,
+		showPlot:     *c.plot,
 	}
 }
 
@@ -74,12 +94,45 @@ func (m model) latex() string {
 	return latex
 }
 
-func (m model) CopyLatex() {
+func (m model) typst() string {
+	lines := make([]string, 0, len(m.editors))
+	for _, editor := range m.editors {
+		lines = append(lines, editor.TypstSource())
+	}
+	return strings.Join(lines, ` \`+"\n")
+}
+
+// each line as an expression of a programming language, lines that cannot be
+// written as one are replaced with a comment saying why, e.g. "// %s"
+func (m model) code(lang codegen.Lang, comment string) string {
+	lines := make([]string, 0, len(m.editors))
+	for _, editor := range m.editors {
+		line, err := codegen.ProduceSource(lang, editor.LatexSource())
+		if err != nil {
+			line = fmt.Sprintf(comment, err.Error())
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formats that can be copied to the clipboard, see the -copy flag
+var copyFormats = map[string]func(model) string{
+	"latex":       model.latex,
+	"typst":       model.typst,
+	"go":          func(m model) string { return m.code(codegen.Go, "// %s") },
+	"python":      func(m model) string { return m.code(codegen.Python, "# %s") },
+	"c":           func(m model) string { return m.code(codegen.C, "// %s") },
+	"sympy":       func(m model) string { return m.code(codegen.SymPy, "# %s") },
+	"mathematica": func(m model) string { return m.code(codegen.Mathematica, "(* %s *)") },
+}
+
+func (m model) Copy() {
 	// wayland clipboard support: https://github.com/golang-design/clipboard/issues/6
-	latex := m.latex()
+	source := copyFormats[*m.copyFormat](m)
 
 	cmd := exec.Command("wl-copy")
-	cmd.Stdin = strings.NewReader(latex)
+	cmd.Stdin = strings.NewReader(source)
 	cmd.Run()
 }
 
@@ -88,6 +141,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.pending != 0 {
+			m.pendingKey(msg)
+			return m, nil
+		}
+		m.message = ""
+		if msg.Type == tea.KeyRunes && msg.Alt && len(msg.Runes) == 1 && m.startAction(msg.Runes[0]) {
+			return m, nil
+		}
 		switch msg.Type {
 		// case tea.KeyEnter:
 		// 	editor := ed.NewWithConfig(*m.editorConfig, "")
@@ -111,10 +172,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.editors[m.focus].SetFocus(true)
 			}
 		case tea.KeyCtrlY:
-			m.CopyLatex()
+			m.Copy()
 			return m, nil
 		case tea.KeyCtrlC: // chain tea command?
-			m.CopyLatex()
+			m.Copy()
 			return m, tea.Quit
 		case tea.KeyTab, tea.KeyShiftTab:
 			if m.editors[m.focus].GetState() != ed.EDIT_COMMAND {
@@ -127,6 +188,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyF1:
 			m.showHelp = !m.showHelp
 			return m, nil
+		case tea.KeyF2:
+			m.showPlot = !m.showPlot
+			return m, nil
 
 		default:
 			keyPressed = msg.String()
@@ -144,21 +208,35 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m model) View() string {
 	editorsView := make([]string, 0, len(m.editors))
-	for _, editor := range m.editors {
+	for i, editor := range m.editors {
 		editorsView = append(editorsView, editor.View())
+		if i == m.focus {
+			editorsView = append(editorsView, m.readout(editor))
+			if m.showPlot {
+				editorsView = append(editorsView, m.plot(editor))
+			}
+		}
+	}
+
+	// blue symbol, yellow command name
+	symColor, nameColor := "\x1b[34m", "\x1b[33m"
+	if m.editorConfig.Colors == renderer.COLOR_NONE {
+		symColor, nameColor = "", ""
 	}
 
 	var compDisplay strings.Builder
 	var displayLen int
 	for _, match := range m.compMatches {
 		cmd := latex.MatchLatexCmd("\\" + match)
-		compDisplay.WriteString("\x1b[34m")
-		if cmd.IsVanillaSym() {
+		compDisplay.WriteString(symColor)
+		if cmd.IsVanillaSym() && m.editorConfig.ASCII {
+			compDisplay.WriteString(renderer.GetASCIIString(cmd))
+		} else if cmd.IsVanillaSym() {
 			compDisplay.WriteString(renderer.GetVanillaString(cmd))
 		} else {
 			compDisplay.WriteRune(' ')
 		}
-		compDisplay.WriteString(" \x1b[33m")
+		compDisplay.WriteString(" " + nameColor)
 		compDisplay.WriteString(match)
 		compDisplay.WriteString("   ")
 
@@ -175,19 +253,67 @@ func (m model) View() string {
 	}
 
 	return fmt.Sprintf(
-		"\n%s\n\n%s\n%s\n%s",
+		"\n%s\n%s\n%s\n%s\n%s",
 		strings.Join(editorsView, "\n"),
+		m.message,
 		compDisplay.String(),
 		m.helpSection(),
 		tree,
 	) + "\n"
 }
 
+// the value of the editor's formula, e.g. "= 2", or an empty line if it has
+// none (yet)
+func (m model) readout(editor ed.Editor) string {
+	value, err := eval.EvalSource(editor.LatexSource(), eval.Vars(m.vars))
+	if err != nil {
+		return ""
+	}
+	return "= " + eval.Format(value)
+}
+
+// the graph of the editor's formula, or an empty line if it has none (yet)
+func (m model) plot(editor ed.Editor) string {
+	cfg := plot.Config{
+		XMin:   m.plotX.min,
+		XMax:   m.plotX.max,
+		Height: 10,
+		ASCII:  m.editorConfig.ASCII,
+		Vars:   eval.Vars(m.vars),
+	}
+	chart, err := cfg.PlotSource(editor.LatexSource())
+	if err != nil {
+		return ""
+	}
+	return chart
+}
+
 func logf(s string, args ...interface{}) error {
 	_, err := fmt.Fprintf(os.Stderr, s, args...)
 	return err
 }
 
+// parses flags before, between and after the arguments of a subcommand, e.g.
+// "plot 'x^2' -width 30". Everything after "--" is an argument, e.g. a formula
+// starting with "-"
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if n := len(args) - flags.NArg(); n > 0 && args[n-1] == "--" {
+			return append(rest, flags.Args()...), nil
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return rest, nil
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+}
+
 var extendedHelp = `
 Editor
 ------
@@ -196,10 +322,18 @@ Editor
 General
 -------
 	F1 toggles keybinds help
+	F2 toggles the plot of the formula
 	ctrl+c to quit
 	ctrl+k previous line
 	ctrl+j next line
-	ctrl+y Copy Latex to clipboard (via wl-copy)
+	ctrl+y Copy Latex (or the format given by -copy) to clipboard (via wl-copy)
+
+Algebra (on the selection or the whole line, the result goes to a new line)
+-------
+	alt+d then a letter - differentiate with respect to that variable
+	alt+c then a letter - collect the powers of that variable
+	alt+e - expand products and powers
+	alt+s - simplify, e.g. cancel common factors of fractions
 `
 
 const defaultHelpText = "press F1 to keybinds help"
@@ -212,23 +346,75 @@ func (m model) helpSection() string {
 	}
 }
 
+// subcommands, e.g. "mathcha diff a.tex b.tex", each returning the exit code
+var subcommands = map[string]func(args []string) int{
+	"diff":  diffMain,
+	"equiv": equivMain,
+	"eval":  evalMain,
+	"fmt":   fmtMain,
+	"lint":  lintMain,
+	"plot":  plotMain,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(cmd(os.Args[2:]))
+		}
+	}
+
 	var useUnicode bool
 	cliFlags := cliFlags{}
 	flag.BoolVar(&useUnicode, "symbols", false, `Use unicode symbols in latex output wherever possible. e.g. output "α" in place of "\alpha"`)
 	flag.BoolVar(&useUnicode, "s", false, `Use unicode symbols in latex output wherever possible. e.g. output "α" in place of "\alpha"`)
 	render := flag.Bool("render", false, `Render equation and exit`)
+	format := flag.String("format", "text", "Output format of -render: text (terminal drawing), svg, html (MathML), typst, asciimath, latex, go, python, c, sympy or mathematica")
+	inline := flag.Bool("inline", false, `Produce inline instead of block (display) math with -format html`)
+	ascii := flag.Bool("ascii", false, "Draw formulas with ASCII characters only, for terminals/fonts without unicode math symbols")
+	themeName := flag.String("theme", "auto", "Color theme: dark, light, auto (pick by terminal background in the editor, dark otherwise) or path to a theme file")
+	highlight := flag.Bool("highlight", false, "Semantic highlighting: color numbers, variables, greek letters, relations etc. differently")
+	colorMode := flag.String("color", "auto", "Colorize output: auto, always or never. auto respects NO_COLOR and COLORTERM")
 	file := flag.String("f", "", "Read initial formula from file; use '-' to read from stdin")
+	ast := flag.String("ast", "", "Print the syntax tree of the formula read from -f or stdin and exit; the only format is json")
+	from := flag.String("from", "latex", "Syntax of the formula read with -f or -render: latex or asciimath")
 	cliFlags.helpText = flag.String("helptext", defaultHelpText, "Help text to print below the editor")
 	cliFlags.printOut = flag.Bool("printout", false, "Internal flag for communicating with the nvim plugin")
 	cliFlags.logFile = flag.String("logfile", "", "Print debug logs to file")
 	cliFlags.debugTree = flag.Bool("debugtree", false, "Print AST representation")
+	cliFlags.copyFormat = flag.String("copy", "latex", "Format copied to the clipboard with ctrl+y: latex, typst, go, python, c, sympy or mathematica")
+	cliFlags.vars = bindings{}
+	flag.Var(cliFlags.vars, "v", "Bind a variable for the value shown under the editor, e.g. x=2; may be repeated")
+	cliFlags.plot = flag.Bool("plot", false, "Plot the focused formula under the editor, toggled with F2")
+	cliFlags.plotX = &valueRange{-10, 10}
+	flag.Var(cliFlags.plotX, "plot-x", "Range of the variable of the plot as min:max, e.g. -\\pi:\\pi")
 	flag.Parse()
 
+	if _, ok := copyFormats[*cliFlags.copyFormat]; !ok {
+		logf("unknown copy format %q\n", *cliFlags.copyFormat)
+		os.Exit(2)
+	}
+
+	// the editor is drawn on stderr, see tea.WithOutput below
+	colors, err := renderer.DetectColorProfile(*colorMode, os.Stderr)
+	if err != nil {
+		logf("%s\n", err.Error())
+		os.Exit(2)
+	}
+
+	theme, err := renderer.LoadTheme(*themeName)
+	if err != nil {
+		logf("%s\n", err.Error())
+		os.Exit(2)
+	}
+
 	editorCfg := ed.EditorConfig{
 		LatexCfg: renderer.LatexSourceConfig{
 			UseUnicode: useUnicode,
 		},
+		ASCII:     *ascii,
+		Colors:    colors,
+		Theme:     theme,
+		Highlight: *highlight,
 	}
 
 	if *cliFlags.logFile != "" {
@@ -241,16 +427,16 @@ func main() {
 		editorCfg.Logger = log.New(f, "", log.LstdFlags)
 	}
 
-	var latex string
+	var formula string
 	switch *file {
 	case "":
-		if *render {
+		if *render || *ast != "" {
 			l, err := io.ReadAll(os.Stdin)
 			if err != nil {
 				logf("error reading stdin: %s", err.Error())
 			}
-			latex = string(l)
-			if latex == "" {
+			formula = string(l)
+			if formula == "" {
 				logf("warn: -render flag used but stdin is empty")
 			}
 		}
@@ -259,24 +445,90 @@ func main() {
 		if err != nil {
 			panic("error reading stdin: " + err.Error())
 		}
-		latex = string(l)
+		formula = string(l)
 	default:
 		l, err := os.ReadFile(*file)
 		if err != nil {
 			panic("error reading " + *file + ": " + err.Error())
 		}
-		latex = string(l)
+		formula = string(l)
+	}
+
+	switch *from {
+	case "latex":
+	case "asciimath":
+		cfg := renderer.LatexSourceConfig{}
+		formula = cfg.ProduceLatex(asciimath.Parse(formula))
+	default:
+		logf("unknown input syntax %q\n", *from)
+		os.Exit(2)
+	}
+
+	switch *ast {
+	case "":
+	case "json":
+		tree, err := latex.ParseSafe(formula)
+		if err != nil {
+			logf("%s\n", err.Error())
+			os.Exit(1)
+		}
+		data, err := json.MarshalIndent(tree, "", "  ")
+		if err != nil {
+			logf("%s\n", err.Error())
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	default:
+		logf("unknown syntax tree format %q\n", *ast)
+		os.Exit(2)
 	}
 
 	if *render {
-		// TODO: detect color from tty
-		r := renderer.FromFormula(latex, false)
-		r.Sync(nil, false)
-		fmt.Print(r.Buffer)
+		colors, _ := renderer.DetectColorProfile(*colorMode, os.Stdout)
+		r := renderer.FromFormula(formula, colors)
+		switch *format {
+		case "text":
+			r.Theme = theme
+			r.Highlight = *highlight
+			r.ASCII = *ascii
+			r.Sync(nil, false)
+			fmt.Print(r.Buffer)
+		case "svg":
+			cfg := renderer.SVGConfig{}
+			fmt.Print(cfg.ProduceSVG(r.LatexTree))
+		case "html":
+			cfg := renderer.HTMLConfig{Inline: *inline}
+			fmt.Println(cfg.ProduceHTML(r.LatexTree))
+		case "typst":
+			fmt.Println(renderer.ProduceTypst(r.LatexTree))
+		case "asciimath":
+			fmt.Println(renderer.ProduceAsciiMath(r.LatexTree))
+		case "latex":
+			cfg := renderer.LatexSourceConfig{UseUnicode: useUnicode}
+			fmt.Println(cfg.ProduceLatex(r.LatexTree))
+		case "go", "python", "c", "sympy", "mathematica":
+			n, err := semantic.FromLatex(r.LatexTree)
+			if err == nil {
+				formula, err = codegen.Produce(codegen.Langs[*format], n)
+			}
+			if err != nil {
+				logf("%s\n", err.Error())
+				os.Exit(1)
+			}
+			fmt.Println(formula)
+		default:
+			logf("unknown format %q\n", *format)
+			os.Exit(2)
+		}
 		return
 	}
 
-	e := initialModel(cliFlags, editorCfg, latex)
+	// only the editor queries the terminal for its background
+	if *themeName == "auto" {
+		editorCfg.Theme = renderer.AutoTheme()
+	}
+	e := initialModel(cliFlags, editorCfg, formula)
 
 	p := tea.NewProgram(e,
 		tea.WithInputTTY(),
@@ -0,0 +1,315 @@
+// Package plot draws the graph of a single-variable formula as text, with
+// braille characters (2×4 dots per character) or ASCII
+package plot
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/horriblename/mathcha/eval"
+	"github.com/horriblename/mathcha/semantic"
+)
+
+// Config configures a plot. Zero values are replaced by the defaults: x from
+// -10 to 10, y fitted to the values and a 60×15 characters chart
+type Config struct {
+	XMin, XMax float64
+	// both 0 to fit the values
+	YMin, YMax float64
+	// the size of the chart in characters, without the axis labels
+	Width, Height int
+	// draw the graph with '*' and the axes with '-' and '|' instead of braille
+	// characters
+	ASCII bool
+	// values of the variables other than the plotted one
+	Vars eval.Vars
+}
+
+const (
+	defaultWidth  = 60
+	defaultHeight = 15
+)
+
+// Function returns the expression plotted for a formula and its variable: the
+// right side of y = ..., or the formula itself. The variable is the one free
+// variable not bound in vars, or x if there is none
+func Function(n semantic.Node, vars eval.Vars) (body semantic.Node, x string, err error) {
+	body = n
+	if rel, ok := n.(*semantic.Relation); ok {
+		if _, ok := rel.Args[0].(*semantic.Var); !ok || len(rel.Ops) != 1 || rel.Ops[0] != "=" {
+			return nil, "", fmt.Errorf("can only plot formulas like y = ...")
+		}
+		body = rel.Args[1]
+	}
+
+	var free []string
+	for _, name := range semantic.FreeVars(body) {
+		if _, ok := vars[name]; ok || name == "pi" || name == "e" {
+			continue
+		}
+		free = append(free, name)
+	}
+	switch len(free) {
+	case 0:
+		return body, "x", nil
+	case 1:
+		return body, free[0], nil
+	}
+	return nil, "", fmt.Errorf("cannot plot a function of more than one variable (%s)", strings.Join(free, ", "))
+}
+
+// PlotSource parses, interprets and plots a formula, see Plot
+func (c Config) PlotSource(src string) (string, error) {
+	n, err := semantic.Parse(src)
+	if err != nil {
+		return "", err
+	}
+	return c.Plot(n)
+}
+
+// Plot draws a formula, see Function, with the y and x ranges as labels
+func (c Config) Plot(n semantic.Node) (string, error) {
+	body, x, err := Function(n, c.Vars)
+	if err != nil {
+		return "", err
+	}
+	c.defaults()
+
+	// dots per character
+	dx, dy := 2, 4
+	if c.ASCII {
+		dx, dy = 1, 1
+	}
+	cols, rows := c.Width*dx, c.Height*dy
+
+	vars := eval.Vars{}
+	for name, value := range c.Vars {
+		vars[name] = value
+	}
+	// NaN outside of the domain
+	value := func(col float64) float64 {
+		vars[x] = c.XMin + (c.XMax-c.XMin)*col/float64(cols-1)
+		y, err := eval.Value(body, vars)
+		if err != nil || math.IsInf(y, 0) {
+			return math.NaN()
+		}
+		return y
+	}
+	ys := make([]float64, cols)
+	var finite []float64
+	for i := range ys {
+		ys[i] = value(float64(i))
+		if !math.IsNaN(ys[i]) {
+			finite = append(finite, ys[i])
+		}
+	}
+	if len(finite) == 0 {
+		return "", fmt.Errorf("the formula has no real values for %s from %s to %s", x, format(c.XMin), format(c.XMax))
+	}
+	if c.YMin == 0 && c.YMax == 0 {
+		c.YMin, c.YMax = fit(finite)
+	}
+
+	g := newGrid(cols, rows)
+	// clamped to one row above or below the grid
+	row := func(y float64) int {
+		r := math.Round((c.YMax - y) / (c.YMax - c.YMin) * float64(rows-1))
+		return int(math.Max(-1, math.Min(float64(rows), r)))
+	}
+	// the axes, drawn below the graph
+	if r := row(0); r >= 0 && r < rows {
+		g.xAxis = r
+	}
+	if c.XMin < 0 && c.XMax > 0 {
+		g.yAxis = int(math.Round(-c.XMin / (c.XMax - c.XMin) * float64(cols-1)))
+	}
+	// the graph, with vertical lines connecting neighboring points
+	prev := noPoint
+	for i, y := range ys {
+		if math.IsNaN(y) {
+			prev = noPoint
+			continue
+		}
+		r := row(y)
+		g.set(i, r)
+		if prev != noPoint && !pole(ys[i-1], value(float64(i)-0.5), y, prev-r, rows) {
+			// the upper half of the line in the left column, the lower half
+			// in the right one
+			mid := (prev + r) / 2
+			line(g, i-1, prev, mid)
+			line(g, i, mid, r)
+		}
+		prev = r
+	}
+
+	lines := g.lines(dx, dy, c.ASCII)
+	return c.frame(lines), nil
+}
+
+// whether there is a pole between two neighboring points, e.g. of \tan x: a
+// jump of more than half the chart, and a value between them that is not
+func pole(left, mid, right float64, jump, rows int) bool {
+	if jump < rows/2 && -jump < rows/2 {
+		return false
+	}
+	return !(math.Min(left, right) <= mid && mid <= math.Max(left, right))
+}
+
+// no previous point to connect to
+const noPoint = -2
+
+// a vertical line from row a to row b
+func line(g *grid, col, a, b int) {
+	if a > b {
+		a, b = b, a
+	}
+	for r := a; r <= b; r++ {
+		g.set(col, r)
+	}
+}
+
+func (c *Config) defaults() {
+	if c.XMin == 0 && c.XMax == 0 {
+		c.XMin, c.XMax = -10, 10
+	}
+	if c.Width <= 0 {
+		c.Width = defaultWidth
+	}
+	if c.Height <= 0 {
+		c.Height = defaultHeight
+	}
+}
+
+// the range of y for the values, ignoring the highest and lowest few (e.g.
+// near the poles of \tan x), with a margin
+func fit(values []float64) (lo, hi float64) {
+	sort.Float64s(values)
+	cut := len(values) / 50
+	lo, hi = values[cut], values[len(values)-1-cut]
+	if lo == hi {
+		return lo - 1, hi + 1
+	}
+	margin := (hi - lo) * 0.05
+	return lo - margin, hi + margin
+}
+
+// the y range left of the chart, the x range below it
+func (c Config) frame(lines []string) string {
+	top, bottom := format(c.YMax), format(c.YMin)
+	margin := max(len(top), len(bottom))
+	vertical, corner, horizontal := "┤", "└", "─"
+	if c.ASCII {
+		vertical, corner, horizontal = "|", "+", "-"
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		label := ""
+		switch i {
+		case 0:
+			label = top
+		case len(lines) - 1:
+			label = bottom
+		}
+		fmt.Fprintf(&b, "%*s %s%s\n", margin, label, vertical, line)
+	}
+	fmt.Fprintf(&b, "%*s %s%s\n", margin, "", corner, strings.Repeat(horizontal, c.Width))
+	left, right := format(c.XMin), format(c.XMax)
+	gap := max(1, c.Width-len(left)-len(right)+1)
+	fmt.Fprintf(&b, "%*s %s%s%s", margin, "", left, strings.Repeat(" ", gap), right)
+	return b.String()
+}
+
+// a short label, e.g. 3.142 for \pi
+func format(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+// dots, in rows of columns, over the axes
+type grid struct {
+	cols, rows int
+	dots       [][]bool
+	// the row of the x axis and the column of the y axis, -1 if they are
+	// outside of the grid
+	xAxis, yAxis int
+}
+
+func newGrid(cols, rows int) *grid {
+	g := &grid{cols: cols, rows: rows, dots: make([][]bool, rows), xAxis: -1, yAxis: -1}
+	for i := range g.dots {
+		g.dots[i] = make([]bool, cols)
+	}
+	return g
+}
+
+// sets a dot, ignoring the ones outside of the grid
+func (g *grid) set(col, row int) {
+	if col >= 0 && col < g.cols && row >= 0 && row < g.rows {
+		g.dots[row][col] = true
+	}
+}
+
+// the bits of the braille dots, by row and column in the character
+var brailleBits = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// whether a dot of the dotted axes is set
+func (g *grid) axisDot(col, row int) bool {
+	return row == g.xAxis && col%2 == 0 || col == g.yAxis && row%2 == 0
+}
+
+// an axis in ASCII: - for the x axis, | for the y axis and + where they cross
+func (g *grid) axisChar(col, row int) rune {
+	switch {
+	case row == g.xAxis && col == g.yAxis:
+		return '+'
+	case row == g.xAxis:
+		return '-'
+	case col == g.yAxis:
+		return '|'
+	}
+	return ' '
+}
+
+// the grid as lines of characters with dx×dy dots each
+func (g *grid) lines(dx, dy int, ascii bool) []string {
+	lines := make([]string, g.rows/dy)
+	for i := range lines {
+		var b strings.Builder
+		for j := 0; j < g.cols/dx; j++ {
+			if ascii {
+				if g.dots[i][j] {
+					b.WriteRune('*')
+				} else {
+					b.WriteRune(g.axisChar(j, i))
+				}
+				continue
+			}
+			r := rune(0x2800)
+			for y := 0; y < dy; y++ {
+				for x := 0; x < dx; x++ {
+					if g.dots[i*dy+y][j*dx+x] || g.axisDot(j*dx+x, i*dy+y) {
+						r |= brailleBits[y][x]
+					}
+				}
+			}
+			b.WriteRune(r)
+		}
+		lines[i] = b.String()
+	}
+	return lines
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,86 @@
+package plot
+
+import (
+	"testing"
+
+	"github.com/horriblename/mathcha/eval"
+	"github.com/horriblename/mathcha/semantic"
+)
+
+func TestFunction(t *testing.T) {
+	testCases := []struct {
+		input   string
+		vars    eval.Vars
+		body, x string
+	}{
+		{`y = \sin x + x^2`, nil, `(+ (sin x) (^ x 2))`, "x"},
+		{`t^2`, nil, `(^ t 2)`, "t"},
+		{`a x + \pi`, eval.Vars{"a": 2}, `(+ (* a x) pi)`, "x"},
+		{`3`, nil, `3`, "x"},
+	}
+	for _, tc := range testCases {
+		n, err := semantic.Parse(tc.input)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.input, err)
+		}
+		body, x, err := Function(n, tc.vars)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", tc.input, err)
+			continue
+		}
+		if body.String() != tc.body || x != tc.x {
+			t.Errorf("%s: got %s in %s, expected %s in %s", tc.input, body, x, tc.body, tc.x)
+		}
+	}
+}
+
+func TestPlot(t *testing.T) {
+	testCases := []struct {
+		cfg           Config
+		input, expect string
+	}{
+		{
+			Config{XMin: -2, XMax: 2, Width: 9, Height: 5, ASCII: true},
+			`y = x^2`,
+			" 4.2 |*   |   *\n" +
+				"     |**  |  **\n" +
+				"     | ** | ** \n" +
+				"     |  **|**  \n" +
+				"-0.2 |---***---\n" +
+				"     +---------\n" +
+				"     -2       2",
+		},
+		{
+			Config{XMin: -1, XMax: 1, Width: 4, Height: 2},
+			`t`,
+			" 1.1 ┤⠀⢀⡵⠋\n" +
+				"-1.1 ┤⡵⠋⠅⠁\n" +
+				"     └────\n" +
+				"     -1  1",
+		},
+	}
+	for _, tc := range testCases {
+		got, err := tc.cfg.PlotSource(tc.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", tc.input, err)
+			continue
+		}
+		if got != tc.expect {
+			t.Errorf("%s: got\n%s\nexpected\n%s", tc.input, got, tc.expect)
+		}
+	}
+}
+
+func TestPlotErrors(t *testing.T) {
+	testCases := map[string]string{
+		`x + y`:           `cannot plot a function of more than one variable (x, y)`,
+		`x < 1`:           `can only plot formulas like y = ...`,
+		`\sqrt{-1 - x^2}`: `the formula has no real values for x from -10 to 10`,
+	}
+	for input, expect := range testCases {
+		_, err := Config{}.PlotSource(input)
+		if err == nil || err.Error() != expect {
+			t.Errorf("%s: got error %v, expected %q", input, err, expect)
+		}
+	}
+}
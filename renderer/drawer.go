@@ -11,36 +11,26 @@ const (
 	CONF_RENDER_EMPTY_COMP_EXPR = true // config to enable rendering empty CompositeExpr "{}" as a space
 )
 
-// style definitions
-var (
-	subtle    = lipgloss.AdaptiveColor{Light: "#D9DCCF", Dark: "#383838"}
-	fg        = lipgloss.AdaptiveColor{Light: "#1ff2f7", Dark: "#abb2bf"}
-	invert    = lipgloss.AdaptiveColor{Light: "#abb2bf", Dark: "#1ff2f7"}
-	accent    = fg //lipgloss.AdaptiveColor{Light: "#264f78", Dark: "#A1BAEA"}
-	accentBg  = lipgloss.Color("#555")
-	highlight = lipgloss.Color("#264f78")
-
-	docStyle       = lipgloss.NewStyle().Foreground(fg)
-	focusStyle     = lipgloss.NewStyle().Foreground(accent).Background(accentBg)
-	highlightStyle = focusStyle.Background(highlight).Foreground(fg)
-
-	// underlineStyle = lipgloss.NewStyle().Underline(true)
-	variableStyle = lipgloss.NewStyle().Italic(true)
-)
-
 func (r *Renderer) DrawToBuffer(tree parser.Expr) {
 	r.Buffer, _ = r.Prerender(tree)
 }
 
 func (r *Renderer) Prerender(node parser.Expr) (out string, baseLevel int) {
 	defer func() {
+		if spec, ok := r.Marks[node]; ok {
+			out = r.paint(spec, out)
+		}
 		if node == r.FocusOn && r.Focus {
-			out = focusStyle.Render(out)
+			out = r.boxStyle(r.theme().Focus).Render(out)
 		}
 	}()
 	switch n := node.(type) {
 	case *parser.TextContainer:
-		return r.Prerender(n.Text)
+		str, baseLevel := r.Prerender(n.Text)
+		if r.Highlight {
+			str = r.paint(r.theme().Semantic.Text, str)
+		}
+		return str, baseLevel
 	case *LatexCmdInput:
 		str, baseLevel := r.Prerender(n.Text)
 		return n.Prefix + str, baseLevel
@@ -48,11 +38,11 @@ func (r *Renderer) Prerender(node parser.Expr) (out string, baseLevel int) {
 		if CONF_RENDER_EMPTY_COMP_EXPR {
 			var builder strings.Builder
 			for _, i := range n.Runes {
-				switch r := i.(type) {
+				switch c := i.(type) {
 				case parser.RawRuneLit:
-					builder.WriteRune(rune(r))
+					builder.WriteRune(rune(c))
 				case *Cursor:
-					builder.WriteString(r.Content())
+					builder.WriteString(r.drawCursor(c))
 				default: // panic?
 				}
 			}
@@ -105,10 +95,8 @@ func (r *Renderer) Prerender(node parser.Expr) (out string, baseLevel int) {
 
 		body := lipgloss.JoinVertical(lipgloss.Top, rows...)
 		height := lipgloss.Height(body)
-		s, t, m, b := envLeft(n.Name)
-		left := constructParenLike(height, s, t, m, b)
-		s, t, m, b = envRight(n.Name)
-		right := constructParenLike(height, s, t, m, b)
+		left := constructParenLike(height, r.envLeft(n.Name))
+		right := constructParenLike(height, r.envRight(n.Name))
 
 		return JoinHorizontal([]int{0, 0, 0}, left, body, right), -height / 2
 
@@ -134,32 +122,44 @@ func (r *Renderer) Prerender(node parser.Expr) (out string, baseLevel int) {
 
 	case *parser.ParenCompExpr:
 		content, baseLine := r.PrerenderFlexContainer(n)
+		// highlight the pair of delimiters around the cursor
+		delimStyle := StyleSpec{}
+		if r.Highlight && r.Focus && node == r.matchingDelim {
+			delimStyle = r.theme().Semantic.MatchingDelim
+		}
 		if n.Left == "(" && n.Right == ")" && lipgloss.Height(content) >= 2 {
 			height := lipgloss.Height(content)
-			left := constructParenLike(height, "(", "⎛", "⎜", "⎝")
-			right := constructParenLike(height, ")", "⎞", "⎟", "⎠")
+			left := r.paint(delimStyle, constructParenLike(height, r.glyphs().LParen))
+			right := r.paint(delimStyle, constructParenLike(height, r.glyphs().RParen))
 			return JoinHorizontal([]int{baseLine, baseLine, baseLine}, left, content, right), baseLine
 		}
-		return JoinHorizontal([]int{0, baseLine, 0}, n.Left, content, n.Right), baseLine
+		left, right := r.paint(delimStyle, n.Left), r.paint(delimStyle, n.Right)
+		return JoinHorizontal([]int{0, baseLine, 0}, left, content, right), baseLine
 	case parser.FlexContainer:
 		return r.PrerenderFlexContainer(n)
 	case *parser.UnknownCmdLit: // FIXME subcase of CmdLiteral, what to do with UnknownCmdLit?
-		return r.styleAndReset(underline, "?"), 0
+		return r.paint(r.literalStyle(n), "?"), 0
 	case parser.CmdLiteral:
+		if r.ASCII {
+			return r.paint(r.literalStyle(n), GetASCIIString(n.Command())), 0
+		}
 		content := GetVanillaString(n.Command())
-		return content, 0
+		return r.paint(r.literalStyle(n), content), 0
 		// parser.Literal interface types
-	case *parser.VarLit:
-		return r.styleAndReset(italic, n.Content()), 0
-	// case *Cursor:
-	// 	return "\x1b[7m \x1b[27m", 0 // set bg color as white(47) then set bg color to default(49)
-	case parser.Literal:
+	case *parser.VarLit, *parser.NumberLit, *parser.BadExpr:
+		lit := n.(parser.Literal)
+		return r.paint(r.literalStyle(lit), lit.Content()), 0
+	case *Cursor:
+		return r.drawCursor(n), 0
+	case *parser.SimpleOpLit:
 		content := n.Content()
 		switch content {
 		case "+", "-", "=":
 			content = " " + content + " "
 		}
-		return content, 0
+		return r.paint(r.literalStyle(n), content), 0
+	case parser.Literal:
+		return n.Content(), 0
 	case nil:
 		// TODO handle error?
 		return "[nil]", 0
@@ -169,6 +169,15 @@ func (r *Renderer) Prerender(node parser.Expr) (out string, baseLevel int) {
 	// panic("Unhandled case in Prerender()")
 }
 
+// mark-only cursors (e.g. the other end of a selection) have no symbol and are
+// not drawn
+func (r *Renderer) drawCursor(c *Cursor) string {
+	if c.Symbol == "" {
+		return ""
+	}
+	return r.paint(r.theme().Cursor, c.Symbol)
+}
+
 func (r *Renderer) PrerenderFlexContainer(node parser.FlexContainer) (output string, baseLine int) {
 	if len(node.Children()) <= 0 {
 		if CONF_RENDER_EMPTY_COMP_EXPR {
@@ -236,16 +245,20 @@ func (r *Renderer) PrerenderFlexContainer(node parser.FlexContainer) (output str
 			vertJoinQueue = nil
 		}
 		renderedChildren[index], baseLines[index] = r.Prerender(child)
+		// separate spelled-out names, e.g. \alpha\beta is "alpha beta"
+		if r.ASCII && index+1 < len(node.Children()) && endsWithASCIIName(child) && startsWithASCIIWord(node.Children()[index+1]) {
+			renderedChildren[index] += " "
+		}
 	}
 
 	if 0 <= selStart && selStart < selEnd {
 		str, base := r.Prerender(&parser.UnboundCompExpr{Elts: node.Children()[selStart:selEnd]})
 		// FIXME workaround for highlight hiding active background
-		activeBg := r.backgroundRGB(80, 80, 80)
-		highlightBg := r.backgroundRGB(26, 79, 120)
+		activeBg, _ := r.sgr(r.theme().Focus)
+		highlightBg, highlightOff := r.sgr(r.theme().Selection)
 		lines, _ := getLines(str)
 		for i, line := range lines {
-			lines[i] = highlightBg + line + activeBg
+			lines[i] = highlightBg + line + highlightOff + activeBg
 		}
 		renderedChildren[selStart] = lipgloss.JoinVertical(lipgloss.Center, lines...)
 		baseLines[selStart] = base
@@ -265,14 +278,20 @@ func (r *Renderer) PrerenderCmdContainer(node parser.CmdContainer, x int, y int)
 
 func (r *Renderer) PrerenderCmdOverline(node parser.CmdContainer) (output string, baseLevel int) {
 	block, baseLevel := r.Prerender(node.Children()[0])
+	if bar := r.glyphs().OverlineBar; bar != "" {
+		return lipgloss.JoinVertical(lipgloss.Center, strings.Repeat(bar, lipgloss.Width(block)), block), baseLevel
+	}
 	lines, _ := getLines(block)
-	lines[0] = "\x1b[53m" + lines[0] + "\x1b[55m"
+	lines[0] = r.overlineAndReset(lines[0])
 
 	return lipgloss.JoinVertical(lipgloss.Center, lines...), baseLevel
 }
 
 func (r *Renderer) PrerenderCmdUnderline(node parser.CmdContainer) (output string, baseLevel int) {
 	block, baseLevel := r.Prerender(node.Children()[0])
+	if bar := r.glyphs().UnderlineBar; bar != "" {
+		return lipgloss.JoinVertical(lipgloss.Center, block, strings.Repeat(bar, lipgloss.Width(block))), baseLevel - 1
+	}
 	lines, _ := getLines(block)
 	// \x1b[4m sets underline, \x1b[24m unsets it
 	// not using lipgloss as lipgloss ends with \x1b[0m, which resets everything
@@ -287,7 +306,7 @@ func (r *Renderer) PrerenderCmdFrac(node parser.CmdContainer) (output string, ne
 	arg2, _ := r.Prerender(node.Children()[1])
 	width := max(lipgloss.Width(arg1), lipgloss.Width(arg2))
 	newBaseLevel = -lipgloss.Height(arg2)
-	line := strings.Repeat("─", width)
+	line := strings.Repeat(r.glyphs().FracBar, width)
 
 	return lipgloss.JoinVertical(lipgloss.Center, arg1, line, arg2), newBaseLevel
 }
@@ -295,38 +314,50 @@ func (r *Renderer) PrerenderCmdFrac(node parser.CmdContainer) (output string, ne
 func (r *Renderer) PrerenderCmdSqrt(node parser.CmdContainer) (output string, baseLevel int) {
 	// TODO simplify adding overline escape chars
 	block, baseLevel := r.Prerender(node.Children()[0])
-	lines, _ := getLines(block)
-	lines[0] = r.overlineAndReset(lines[0])
-	block = lipgloss.JoinVertical(lipgloss.Center, lines...)
+	glyphs := r.glyphs()
+	if glyphs.SqrtBar != "" {
+		block = lipgloss.JoinVertical(lipgloss.Left, strings.Repeat(glyphs.SqrtBar, lipgloss.Width(block)), block)
+	} else {
+		lines, _ := getLines(block)
+		lines[0] = r.overlineAndReset(lines[0])
+		block = lipgloss.JoinVertical(lipgloss.Center, lines...)
+	}
 	height := lipgloss.Height(block)
-	root := strings.Repeat("⎟\n", height-1) + `⎷`
+	root := strings.Repeat(glyphs.SqrtMid+"\n", height-1) + glyphs.SqrtBot
+	if glyphs.SqrtBar != "" {
+		// the radical sign starts below the bar
+		root = strings.Repeat(" ", lipgloss.Width(glyphs.SqrtBot)) + strings.TrimPrefix(root, glyphs.SqrtMid)
+	}
 
 	return JoinHorizontal([]int{baseLevel, baseLevel}, root, block), baseLevel
 }
 
-func envLeft(name parser.EnvName) (single, top, mid, bot string) {
+// returns the single-line, top, middle and bottom pieces of the left delimiter
+func (r *Renderer) envLeft(name parser.EnvName) [4]string {
 	switch name {
 	case parser.ENV_align:
-		return "", "", "", ""
+		return [4]string{"", "", "", ""}
 	case parser.ENV_matrix:
-		return "[", "⎡", "⎢", "⎣"
+		return r.glyphs().LBrack
 	default:
-		return "?", " ", "?", " "
+		return [4]string{"?", " ", "?", " "}
 	}
 }
 
-func envRight(name parser.EnvName) (single, top, mid, bot string) {
+func (r *Renderer) envRight(name parser.EnvName) [4]string {
 	switch name {
 	case parser.ENV_align:
-		return "", "", "", ""
+		return [4]string{"", "", "", ""}
 	case parser.ENV_matrix:
-		return "]", "⎤", "⎥", "⎦"
+		return r.glyphs().RBrack
 	default:
-		return "?", " ", "?", " "
+		return [4]string{"?", " ", "?", " "}
 	}
 }
 
-func constructParenLike(height int, single, top, mid, bot string) string {
+// pieces are the single-line, top, middle and bottom parts of the delimiter
+func constructParenLike(height int, pieces [4]string) string {
+	single, top, mid, bot := pieces[0], pieces[1], pieces[2], pieces[3]
 	if height == 1 {
 		return single
 	}
@@ -149,7 +149,7 @@ func TestPrerender(t *testing.T) {
 		},
 	}
 
-	r := New(false)
+	r := New(COLOR_NONE)
 	for _, tC := range testCases {
 		t.Run(tC.desc, func(t *testing.T) {
 			tree := parser.Parse(tC.input)
@@ -163,3 +163,92 @@ func TestPrerender(t *testing.T) {
 		})
 	}
 }
+
+func TestPrerenderASCII(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		input  string
+		expect string
+	}{
+		{
+			desc:   "SimpleCmdLit - greek letter",
+			input:  `\alpha`,
+			expect: "alpha",
+		},
+		{
+			desc:   "SimpleCmdLit - adjacent spelled-out names",
+			input:  `\alpha\beta x+\pi`,
+			expect: "alpha beta x + pi",
+		},
+		{
+			desc:   "SimpleCmdLit - relation",
+			input:  `a \le b`,
+			expect: "a <= b",
+		},
+		{
+			desc:   "SimpleCmdLit - named function",
+			input:  `\sin x`,
+			expect: "sin x",
+		},
+		{
+			desc:   "SimpleCmdLit - symbol without ASCII look-alike",
+			input:  `a \oplus b`,
+			expect: "a oplus b",
+		},
+		{
+			desc:   "Cmd1ArgExpr - sqrt",
+			input:  `\sqrt{x}`,
+			expect: join("  _", `\/x`),
+		},
+		{
+			desc:  "Cmd1ArgExpr - tall sqrt",
+			input: `\sqrt{\frac{a}{b}}`,
+			expect: join(
+				"  _",
+				" |a",
+				" |-",
+				`\/b`,
+			),
+		},
+		{
+			desc:   "Cmd1ArgExpr - overline",
+			input:  `\overline{xy}`,
+			expect: join("__", "xy"),
+		},
+		{
+			desc:   "Cmd1ArgExpr - underline",
+			input:  `\underline{xy}+z`,
+			expect: join("xy + z", "__    "),
+		},
+		{
+			desc:   "Cmd2ArgExpr - frac",
+			input:  `\frac{1}{2}`,
+			expect: join("1", "-", "2"),
+		},
+		{
+			desc:  "ParenCompExpr - tall parentheses",
+			input: `\left(\frac{1}{2}\right)`,
+			expect: join(
+				`/1\`,
+				"|-|",
+				`\2/`,
+			),
+		},
+		{
+			desc:   "EnvExpr - matrix environment",
+			input:  `\begin{matrix} a & b \\ c & d \end{matrix}`,
+			expect: join("[a b]", "[c d]"),
+		},
+	}
+
+	r := New(COLOR_NONE)
+	r.ASCII = true
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			out, _ := r.Prerender(parser.Parse(tC.input))
+			if out != tC.expect {
+				t.Errorf("got:  %q\nwant: %q", out, tC.expect)
+			}
+		})
+	}
+}
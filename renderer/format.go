@@ -0,0 +1,276 @@
+package renderer
+
+import (
+	"strings"
+
+	parser "github.com/horriblename/mathcha/latex"
+	"github.com/mattn/go-runewidth"
+)
+
+// How the arguments of superscripts and subscripts are written
+type BraceStyle int
+
+const (
+	BRACES_MINIMAL BraceStyle = iota // only where needed, e.g. x^2, x^\alpha, x^{10}
+	BRACES_ALWAYS                    // always, e.g. x^{2}
+)
+
+// FormatConfig configures Format. The zero value is the default style
+type FormatConfig struct {
+	Braces BraceStyle
+	// write relations without spaces, e.g. "a=b" instead of "a = b"
+	TightRelations bool
+}
+
+// Format pretty-prints a formula as LaTeX: binary operators (and relations,
+// unless TightRelations) are surrounded by spaces, commands are spelled the
+// canonical way (e.g. \le for \leq), and the rows of environments are written
+// one per line, with their "&"s aligned. Nested environments stay on a single
+// line.
+//
+// Formatting the parsed output again gives the same output
+func (cfg *FormatConfig) Format(node parser.Expr) string {
+	f := latexFormatter{cfg: cfg}
+	f.format(node)
+	return f.b.String()
+}
+
+type latexFormatter struct {
+	cfg    *FormatConfig
+	b      strings.Builder
+	inline bool // environments on a single line
+	// a space is due before the next piece
+	space bool
+	// whether the last piece ends an operand, which makes a following "+" or
+	// "-" binary
+	operand bool
+}
+
+// appends a piece of source, separated from the previous one if necessary
+func (f *latexFormatter) write(s string) {
+	if s == "" {
+		return
+	}
+	if f.b.Len() > 0 && (f.space || endsWithCommandName(f.b.String()) && startsWithLetter(s)) {
+		f.b.WriteByte(' ')
+	}
+	f.space = false
+	f.b.WriteString(s)
+}
+
+// appends an operator surrounded by spaces
+func (f *latexFormatter) spaced(s string) {
+	f.space = f.b.Len() > 0
+	f.write(s)
+	f.space = true
+	f.operand = false
+}
+
+// appends an operand
+func (f *latexFormatter) operandPiece(s string) {
+	f.write(s)
+	f.operand = true
+}
+
+// appends a piece that is neither operand nor operator, e.g. "(" or ","
+func (f *latexFormatter) punctuation(s string) {
+	f.write(s)
+	f.operand = false
+}
+
+// whether s ends with a command like \alpha, so that a following letter
+// would become part of the command name
+func endsWithCommandName(s string) bool {
+	i := len(s)
+	for i > 0 && isASCIILetter(s[i-1]) {
+		i--
+	}
+	return i < len(s) && i > 0 && s[i-1] == '\\'
+}
+
+func startsWithLetter(s string) bool {
+	return isASCIILetter(s[0])
+}
+
+func isASCIILetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// formats a node on its own, e.g. a command argument. Environments are
+// written on a single line
+func (f *latexFormatter) sub(node parser.Expr) string {
+	inner := latexFormatter{cfg: f.cfg, inline: true}
+	if c, ok := node.(*parser.CompositeExpr); ok {
+		for _, child := range c.Elts {
+			inner.format(child)
+		}
+	} else {
+		inner.format(node)
+	}
+	return inner.b.String()
+}
+
+// the argument of a superscript or subscript, see BraceStyle
+func (f *latexFormatter) script(node parser.Expr) string {
+	s := f.sub(node)
+	if f.cfg.Braces == BRACES_MINIMAL {
+		if c, ok := node.(*parser.CompositeExpr); ok && len(c.Elts) == 1 {
+			switch c.Elts[0].(type) {
+			case *parser.NumberLit, *parser.VarLit, *parser.SimpleCmdLit:
+				if len(s) == 1 || s[0] == '\\' && endsWithCommandName(s) {
+					return s
+				}
+			}
+		}
+	}
+	return "{" + s + "}"
+}
+
+func (f *latexFormatter) format(node parser.Expr) {
+	switch n := node.(type) {
+	case *parser.TextContainer:
+		f.operandPiece(n.Type.GetCmd() + "{" + n.Text.BuildString() + "}")
+	case *parser.ParenCompExpr:
+		f.punctuation(`\left` + n.Left)
+		for _, c := range n.Elts {
+			f.format(c)
+		}
+		f.operandPiece(`\right` + n.Right)
+	case *parser.CompositeExpr:
+		f.operandPiece("{" + f.sub(n) + "}")
+	case *parser.EnvExpr:
+		f.operandPiece(f.env(n))
+	case parser.FlexContainer:
+		for _, c := range n.Children() {
+			f.format(c)
+		}
+	case parser.CmdContainer:
+		args := n.Children()
+		switch n.Command() {
+		case parser.CMD_superscript, parser.CMD_subscript:
+			// attached to the base, without any space
+			f.b.WriteString(n.Command().GetCmd() + f.script(args[0]))
+			f.space = false
+		default:
+			s := n.Command().GetCmd()
+			for _, arg := range args {
+				s += "{" + f.sub(arg) + "}"
+			}
+			f.operandPiece(s)
+		}
+		f.operand = true
+	case *parser.SimpleCmdLit:
+		cmd := n.Command()
+		source := n.Source
+		if cmd != parser.CMD_UNKNOWN && cmd != parser.CMD_SPACE {
+			source = cmd.GetCmd()
+		}
+		switch {
+		case cmd.IsRelation() || spacedArrows[cmd]:
+			f.relation(source)
+		case cmd.IsBinaryOperator() && f.operand:
+			f.spaced(source)
+		case cmd == parser.CMD_UNKNOWN && source == `\{`:
+			f.punctuation(source)
+		case cmd == parser.CMD_SPACE:
+			f.b.WriteString(source)
+			f.space = false
+		default:
+			f.operandPiece(source)
+		}
+	case *parser.SimpleOpLit:
+		switch n.Source {
+		case "=", "<", ">":
+			f.relation(n.Source)
+		case "+", "-":
+			if f.operand {
+				f.spaced(n.Source)
+			} else {
+				f.punctuation(n.Source)
+			}
+		case ",", ";":
+			f.punctuation(n.Source)
+			f.space = true
+		case "(", "[":
+			f.punctuation(n.Source)
+		case "/", ":":
+			f.punctuation(n.Source)
+		default:
+			f.operandPiece(n.Source)
+		}
+	case *Cursor:
+	case parser.Literal:
+		f.operandPiece(n.Content())
+	}
+}
+
+// arrows are spaced like relations
+var spacedArrows = map[parser.LatexCmd]bool{
+	parser.CMD_to: true, parser.CMD_gets: true, parser.CMD_rArr: true, parser.CMD_mapsto: true,
+}
+
+func (f *latexFormatter) relation(s string) {
+	if f.cfg.TightRelations {
+		f.punctuation(s)
+	} else {
+		f.spaced(s)
+	}
+}
+
+// \begin{...} ... \end{...}, one row per line with aligned "&"s, or on a
+// single line if inline
+func (f *latexFormatter) env(n *parser.EnvExpr) string {
+	begin, end := `\begin{`+n.Name.String()+"}", `\end{`+n.Name.String()+"}"
+	cells := make([][]string, len(n.Elts))
+	var widths []int
+	for i, row := range n.Elts {
+		cells[i] = make([]string, len(row))
+		for j, cell := range row {
+			cells[i][j] = f.sub(cell)
+			if j >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if w := runewidth.StringWidth(cells[i][j]); w > widths[j] {
+				widths[j] = w
+			}
+		}
+	}
+
+	rows := make([]string, len(cells))
+	for i, row := range cells {
+		var b strings.Builder
+		for j, cell := range row {
+			if j > 0 {
+				b.WriteString(" & ")
+			}
+			b.WriteString(cell)
+			if !f.inline && j < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[j]-runewidth.StringWidth(cell)))
+			}
+		}
+		rows[i] = b.String()
+		if len(row) > 1 && row[len(row)-1] == "" {
+			rows[i] = strings.TrimSuffix(rows[i], " ")
+		}
+	}
+
+	if f.inline {
+		if len(rows) == 0 {
+			return begin + end
+		}
+		return begin + " " + strings.Join(rows, ` \\ `) + " " + end
+	}
+	var b strings.Builder
+	b.WriteString(begin + "\n")
+	for i, row := range rows {
+		if row != "" {
+			b.WriteString("  " + row)
+		}
+		if i < len(rows)-1 {
+			b.WriteString(` \\`)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(end)
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package renderer
+
+import (
+	"testing"
+
+	parser "github.com/horriblename/mathcha/latex"
+)
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		cfg    FormatConfig
+		input  string
+		expect string
+	}{
+		{desc: "operators", input: `a+b-c\cdot d`, expect: `a + b - c \cdot d`},
+		{desc: "unary minus", input: `-x+(-1)`, expect: `-x + (-1)`},
+		{desc: "relations", input: `a\leq b=c`, expect: `a \le b = c`},
+		{desc: "tight relations", cfg: FormatConfig{TightRelations: true}, input: `a \le b = c+1`, expect: `a\le b=c + 1`},
+		{desc: "canonical spelling", input: `x\neq\infin`, expect: `x \ne \infty`},
+		{desc: "minimal braces", input: `x^{2}+y_{i}^{\alpha}+z^{10}+e^{-x}`, expect: `x^2 + y_i^\alpha + z^{10} + e^{-x}`},
+		{desc: "always braces", cfg: FormatConfig{Braces: BRACES_ALWAYS}, input: `x^2_i`, expect: `x^{2}_{i}`},
+		{desc: "command arguments", input: `\frac12+\sqrt x`, expect: `\frac{1}{2} + \sqrt{x}`},
+		{desc: "commands before letters", input: `\alpha x\sin y`, expect: `\alpha x\sin y`},
+		{desc: "commas", input: `f(x,y)`, expect: `f(x, y)`},
+		{desc: "text", input: `\text{if  } x`, expect: `\text{if  }x`},
+		{desc: "left right", input: `\left( a+b \right)`, expect: `\left(a + b\right)`},
+		{
+			desc:   "matrix",
+			input:  `\begin{matrix}a&bb\\ccc&d\end{matrix}`,
+			expect: "\\begin{matrix}\n  a   & bb \\\\\n  ccc & d\n\\end{matrix}",
+		},
+		{
+			desc:   "align",
+			input:  `\begin{align}x&=1\\y+z&=2\end{align}`,
+			expect: "\\begin{align}\n  x     & = 1 \\\\\n  y + z & = 2\n\\end{align}",
+		},
+		{
+			desc:   "nested matrix",
+			input:  `\frac{\begin{matrix}a&b\end{matrix}}{2}`,
+			expect: `\frac{\begin{matrix} a & b \end{matrix}}{2}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := tc.cfg.Format(parser.Parse(tc.input))
+			if got != tc.expect {
+				t.Errorf("got:\n%s\nexpected:\n%s", got, tc.expect)
+			}
+			if again := tc.cfg.Format(parser.Parse(got)); again != got {
+				t.Errorf("not idempotent, formatted again:\n%s", again)
+			}
+		})
+	}
+}
+
+// formatting keeps the tree and is idempotent
+func TestFormatStable(t *testing.T) {
+	for _, cfg := range []FormatConfig{{}, {Braces: BRACES_ALWAYS, TightRelations: true}} {
+		for _, tc := range latexTestCases {
+			tree := parser.Parse(tc.input)
+			formatted := cfg.Format(tree)
+			reparsed := parser.Parse(formatted)
+			if !tree.DeepEqWith(reparsed, parser.DeepEqCfg{SkipPos: true}) {
+				t.Errorf("%s: tree changed\noriginal:  %s\nformatted: %s\nreparsed:  %s",
+					tc.desc, tree.VisualizeTree(), formatted, reparsed.VisualizeTree())
+			}
+			if again := cfg.Format(reparsed); again != formatted {
+				t.Errorf("%s: not idempotent\nfirst:  %s\nsecond: %s", tc.desc, formatted, again)
+			}
+		}
+	}
+}
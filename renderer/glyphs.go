@@ -0,0 +1,175 @@
+package renderer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	parser "github.com/horriblename/mathcha/latex"
+)
+
+// Glyphs is the set of characters used to draw the structural parts of a
+// formula: fraction bars, radicals and tall delimiters
+type Glyphs struct {
+	FracBar string // repeated to the width of the fraction
+
+	// radical sign; SqrtMid is repeated for every line but the last one
+	SqrtMid, SqrtBot string
+	// when not empty, the radicand is overlined by a row of SqrtBar instead of
+	// the overline escape code
+	SqrtBar string
+	// same as SqrtBar, for \overline
+	OverlineBar string
+	// when not empty, \underline draws a row of UnderlineBar under its argument
+	// instead of the underline escape code
+	UnderlineBar string
+
+	// single-line, top, middle and bottom pieces of tall delimiters
+	LParen, RParen [4]string
+	LBrack, RBrack [4]string
+}
+
+var UnicodeGlyphs = Glyphs{
+	FracBar: "─",
+	SqrtMid: "⎟",
+	SqrtBot: "⎷",
+	LParen:  [4]string{"(", "⎛", "⎜", "⎝"},
+	RParen:  [4]string{")", "⎞", "⎟", "⎠"},
+	LBrack:  [4]string{"[", "⎡", "⎢", "⎣"},
+	RBrack:  [4]string{"]", "⎤", "⎥", "⎦"},
+}
+
+// ASCIIGlyphs draws everything with printable ASCII characters only, for
+// fonts and terminals that lack box-drawing characters (e.g. the Linux console)
+var ASCIIGlyphs = Glyphs{
+	FracBar:      "-",
+	SqrtMid:      " |",
+	SqrtBot:      `\/`,
+	SqrtBar:      "_",
+	OverlineBar:  "_",
+	UnderlineBar: "_",
+	LParen:       [4]string{"(", "/", "|", `\`},
+	RParen:       [4]string{")", `\`, "|", "/"},
+	LBrack:       [4]string{"[", "[", "|", "["},
+	RBrack:       [4]string{"]", "]", "|", "]"},
+}
+
+func (r *Renderer) glyphs() *Glyphs {
+	if r.ASCII {
+		return &ASCIIGlyphs
+	}
+	return &UnicodeGlyphs
+}
+
+// ASCII replacements for vanilla symbols. Multi-character operators are padded
+// with spaces so that they don't run into their operands. Symbols that have no
+// obvious ASCII look-alike are spelled out by name; see GetASCIIString
+var VanillaToASCII = map[parser.LatexCmd]string{
+	parser.CMD_cdot:   `*`,
+	parser.CMD_times:  ` x `,
+	parser.CMD_div:    `/`,
+	parser.CMD_ast:    `*`,
+	parser.CMD_pm:     ` +- `,
+	parser.CMD_mp:     ` -+ `,
+	parser.CMD_ne:     ` != `,
+	parser.CMD_le:     ` <= `,
+	parser.CMD_ge:     ` >= `,
+	parser.CMD_ll:     ` << `,
+	parser.CMD_gg:     ` >> `,
+	parser.CMD_sim:    `~`,
+	parser.CMD_simeq:  ` ~= `,
+	parser.CMD_cong:   ` ~= `,
+	parser.CMD_asymp:  ` ~~ `,
+	parser.CMD_equiv:  ` == `,
+	parser.CMD_mid:    `|`,
+	parser.CMD_neg:    `!`,
+	parser.CMD_circ:   `o`,
+	parser.CMD_bullet: `*`,
+
+	parser.CMD_parallel:      ` || `,
+	parser.CMD_setminus:      `\`,
+	parser.CMD_smallsetminus: `\`,
+	parser.CMD_ldots:         `...`,
+	parser.CMD_cdots:         `...`,
+	parser.CMD_dots:          `...`,
+	parser.CMD_vdots:         `:`,
+	parser.CMD_ddots:         `...`,
+
+	parser.CMD_to:                 ` -> `,
+	parser.CMD_gets:               ` <- `,
+	parser.CMD_longrightarrow:     ` --> `,
+	parser.CMD_longleftarrow:      ` <-- `,
+	parser.CMD_Longrightarrow:     ` ==> `,
+	parser.CMD_Longleftarrow:      ` <== `,
+	parser.CMD_longleftrightarrow: ` <-> `,
+	parser.CMD_Longleftrightarrow: ` <=> `,
+	parser.CMD_mapsto:             ` |-> `,
+	parser.CMD_rArr:               ` => `,
+	parser.CMD_lArr:               ` <= `,
+	parser.CMD_harr:               ` <-> `,
+	parser.CMD_hArr:               ` <=> `,
+
+	parser.CMD_lfloor: `|_`,
+	parser.CMD_rfloor: `_|`,
+	parser.CMD_lceil:  `|`,
+	parser.CMD_rceil:  `|`,
+
+	parser.CMD_degree: `deg`,
+	parser.CMD_infty:  `inf`,
+}
+
+// Returns a printable ASCII representation of a vanilla symbol.
+// Symbols that are already ASCII in VanillaToUnicode (e.g. "sin ") are
+// reused, letters (e.g. greek) are spelled out by their command name and
+// the remaining symbols by their padded command name, e.g. " oplus "
+func GetASCIIString(cmd parser.LatexCmd) string {
+	if s, ok := VanillaToASCII[cmd]; ok {
+		return s
+	}
+	uni := GetVanillaString(cmd)
+	if uni != "" && isASCII(uni) {
+		return uni
+	}
+	name := strings.TrimSpace(strings.TrimPrefix(cmd.GetCmd(), `\`))
+	if r, _ := utf8.DecodeRuneInString(uni); unicode.IsLetter(r) {
+		return name
+	}
+	return " " + name + " "
+}
+
+// whether the ASCII drawing of n ends with a spelled-out name, e.g. alpha,
+// that would run into a following name or letter
+func endsWithASCIIName(n parser.Expr) bool {
+	if _, ok := n.(*parser.UnknownCmdLit); ok {
+		return false
+	}
+	lit, ok := n.(parser.CmdLiteral)
+	if !ok {
+		return false
+	}
+	r, _ := utf8.DecodeLastRuneInString(GetASCIIString(lit.Command()))
+	return unicode.IsLetter(r)
+}
+
+// whether the ASCII drawing of n starts with a letter or digit
+func startsWithASCIIWord(n parser.Expr) bool {
+	switch n := n.(type) {
+	case *parser.VarLit, *parser.NumberLit:
+		return true
+	case *parser.UnknownCmdLit:
+		return false
+	case parser.CmdLiteral:
+		r, _ := utf8.DecodeRuneInString(GetASCIIString(n.Command()))
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	return false
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
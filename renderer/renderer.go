@@ -1,22 +1,36 @@
 package renderer
 
 import (
+	"github.com/charmbracelet/lipgloss"
 	parser "github.com/horriblename/mathcha/latex"
 )
 
 type Renderer struct {
-	Color        bool
+	Colors       ColorProfile
+	Theme        *Theme // nil means DarkTheme
+	Highlight    bool   // semantic highlighting, see SemanticStyles
+	ASCII        bool   // draw with ASCII characters only, see ASCIIGlyphs
 	Buffer       string
 	LatexTree    parser.FlexContainer
 	FocusOn      parser.Container // the container in which the cursor is, a better implementation would be letting Render functions return a 'focused' flag when cursor is found
 	HasSelection bool             // whether there is a selection in FocusOn
 	Focus        bool             // whether the widget itself is focused
+	// extra styles of individual nodes, drawn around the node, e.g. the changes
+	// of a diff
+	Marks map[parser.Expr]StyleSpec
+
+	// the innermost \left...\right around FocusOn, whose delimiters are
+	// highlighted, see Sync
+	matchingDelim *parser.ParenCompExpr
+
+	lip       *lipgloss.Renderer // lazily created, see Renderer.lipgloss
+	lipColors ColorProfile       // the profile lip was created with
 }
 
-func New(color bool) Renderer {
+func New(colors ColorProfile) Renderer {
 	root := &parser.UnboundCompExpr{}
 	return Renderer{
-		Color:        color,
+		Colors:       colors,
 		Buffer:       "",
 		LatexTree:    root,
 		FocusOn:      root,
@@ -25,10 +39,10 @@ func New(color bool) Renderer {
 	}
 }
 
-func FromFormula(formula string, color bool) *Renderer {
+func FromFormula(formula string, colors ColorProfile) *Renderer {
 	root := parser.Parse(formula)
 	return &Renderer{
-		Color:        color,
+		Colors:       colors,
 		Buffer:       "",
 		LatexTree:    root,
 		FocusOn:      root,
@@ -46,9 +60,36 @@ func (r *Renderer) Load(tree parser.FlexContainer) {
 func (r *Renderer) Sync(focus parser.Container, selected bool /*whether there is a selection*/) {
 	r.FocusOn = focus
 	r.HasSelection = selected
+	r.matchingDelim = nil
+	if r.Highlight && focus != nil {
+		r.matchingDelim = enclosingParen(r.LatexTree, focus)
+	}
 	r.DrawToBuffer(r.LatexTree)
 }
 
+// the innermost ParenCompExpr in root that is or contains focus, or nil
+func enclosingParen(root parser.Expr, focus parser.Expr) *parser.ParenCompExpr {
+	var found *parser.ParenCompExpr
+	// the innermost ParenCompExpr around each node on the path to the current one
+	var parens []*parser.ParenCompExpr
+	parser.Inspect(root, func(n parser.Expr) bool {
+		if n == nil {
+			parens = parens[:len(parens)-1]
+			return false
+		}
+		paren, ok := n.(*parser.ParenCompExpr)
+		if !ok && len(parens) > 0 {
+			paren = parens[len(parens)-1]
+		}
+		parens = append(parens, paren)
+		if n == focus {
+			found = paren
+		}
+		return found == nil
+	})
+	return found
+}
+
 func (r *Renderer) View() string {
 	return r.Buffer
 }
@@ -1,36 +1,118 @@
 package renderer
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
 
-type color int
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-isatty"
+	"github.com/muesli/termenv"
+)
+
+// ColorProfile is the color capability of the terminal the renderer draws to.
+// It also decides whether text attributes (italics, underline, overline) are
+// emitted at all: COLOR_NONE produces plain text without any escape codes
+type ColorProfile int
+
+const (
+	COLOR_NONE      ColorProfile = iota // no escape codes at all
+	COLOR_16                            // the 16 basic ANSI colors
+	COLOR_256                           // xterm 256 colors
+	COLOR_TRUECOLOR                     // 24-bit RGB colors
+)
 
-const ()
+var colorProfileNames = [...]string{
+	COLOR_NONE:      "none",
+	COLOR_16:        "16",
+	COLOR_256:       "256",
+	COLOR_TRUECOLOR: "truecolor",
+}
+
+func (p ColorProfile) String() string { return colorProfileNames[p] }
+
+func (p ColorProfile) termenv() termenv.Profile {
+	switch p {
+	case COLOR_16:
+		return termenv.ANSI
+	case COLOR_256:
+		return termenv.ANSI256
+	case COLOR_TRUECOLOR:
+		return termenv.TrueColor
+	default:
+		return termenv.Ascii
+	}
+}
+
+// DetectColorProfile picks the color profile for the given output according to
+// mode, which is one of "auto", "always" or "never" (usually the -color flag).
+//
+// "auto" disables colors when the output is not a terminal, NO_COLOR is set or
+// TERM is "dumb"; "always" colors even when writing to a file or pipe. In both
+// cases, the number of colors is guessed from COLORTERM and TERM
+func DetectColorProfile(mode string, out *os.File) (ColorProfile, error) {
+	isTTY := isatty.IsTerminal(out.Fd()) || isatty.IsCygwinTerminal(out.Fd())
+	return detectColorProfile(mode, isTTY, os.Getenv)
+}
+
+func detectColorProfile(mode string, isTTY bool, getenv func(string) string) (ColorProfile, error) {
+	switch mode {
+	case "never":
+		return COLOR_NONE, nil
+	case "always":
+		return envColorProfile(getenv), nil
+	case "auto", "":
+		if !isTTY || getenv("NO_COLOR") != "" || getenv("TERM") == "dumb" {
+			return COLOR_NONE, nil
+		}
+		return envColorProfile(getenv), nil
+	default:
+		return COLOR_NONE, fmt.Errorf("invalid color mode %q, expected auto, always or never", mode)
+	}
+}
+
+func envColorProfile(getenv func(string) string) ColorProfile {
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return COLOR_TRUECOLOR
+	case "":
+	default:
+		return COLOR_256
+	}
+	if strings.Contains(getenv("TERM"), "256color") {
+		return COLOR_256
+	}
+	return COLOR_16
+}
 
 type style int
 
 const (
 	underline style = 4
-	italic    style = 3
 )
 const styleResetOffset int = 20
 
-func (self *Renderer) backgroundRGB(r int, g int, b int) string {
-	if !self.Color {
-		return ""
+// returns a lipgloss renderer that renders styles according to self.Colors
+func (self *Renderer) lipgloss() *lipgloss.Renderer {
+	if self.lip == nil || self.lipColors != self.Colors {
+		self.lip = lipgloss.NewRenderer(io.Discard)
+		self.lip.SetColorProfile(self.Colors.termenv())
+		self.lipColors = self.Colors
 	}
-	return fmt.Sprintf("\x1b[48;2;%d;%d;%dm", r, g, b)
+	return self.lip
 }
 
 // wraps content string with style + reset
 func (self *Renderer) styleAndReset(s style, content string) string {
-	if !self.Color {
+	if self.Colors == COLOR_NONE {
 		return content
 	}
 	return fmt.Sprintf("\x1b[%dm%s\x1b[%dm", int(s), content, int(s)+styleResetOffset)
 }
 
 func (self *Renderer) overlineAndReset(content string) string {
-	if !self.Color {
+	if self.Colors == COLOR_NONE {
 		return content
 	}
 	return fmt.Sprintf("\x1b[53m%s\x1b[55m", content)
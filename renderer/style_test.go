@@ -0,0 +1,107 @@
+package renderer
+
+import "testing"
+
+func TestDetectColorProfile(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		mode   string
+		isTTY  bool
+		env    map[string]string
+		expect ColorProfile
+	}{
+		{
+			desc:   "auto - not a tty",
+			mode:   "auto",
+			isTTY:  false,
+			env:    map[string]string{"COLORTERM": "truecolor"},
+			expect: COLOR_NONE,
+		},
+		{
+			desc:   "auto - NO_COLOR",
+			mode:   "auto",
+			isTTY:  true,
+			env:    map[string]string{"NO_COLOR": "1", "COLORTERM": "truecolor"},
+			expect: COLOR_NONE,
+		},
+		{
+			desc:   "auto - dumb terminal",
+			mode:   "auto",
+			isTTY:  true,
+			env:    map[string]string{"TERM": "dumb"},
+			expect: COLOR_NONE,
+		},
+		{
+			desc:   "auto - truecolor",
+			mode:   "auto",
+			isTTY:  true,
+			env:    map[string]string{"TERM": "xterm-256color", "COLORTERM": "24bit"},
+			expect: COLOR_TRUECOLOR,
+		},
+		{
+			desc:   "auto - 256 colors",
+			mode:   "auto",
+			isTTY:  true,
+			env:    map[string]string{"TERM": "xterm-256color"},
+			expect: COLOR_256,
+		},
+		{
+			desc:   "auto - linux console",
+			mode:   "auto",
+			isTTY:  true,
+			env:    map[string]string{"TERM": "linux"},
+			expect: COLOR_16,
+		},
+		{
+			desc:   "always - ignores tty and NO_COLOR",
+			mode:   "always",
+			isTTY:  false,
+			env:    map[string]string{"NO_COLOR": "1", "COLORTERM": "truecolor"},
+			expect: COLOR_TRUECOLOR,
+		},
+		{
+			desc:   "never",
+			mode:   "never",
+			isTTY:  true,
+			env:    map[string]string{"COLORTERM": "truecolor"},
+			expect: COLOR_NONE,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			getenv := func(key string) string { return tC.env[key] }
+			profile, err := detectColorProfile(tC.mode, tC.isTTY, getenv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if profile != tC.expect {
+				t.Errorf("got: %s, want: %s", profile, tC.expect)
+			}
+		})
+	}
+
+	if _, err := detectColorProfile("sometimes", true, func(string) string { return "" }); err == nil {
+		t.Error("expected error for invalid mode")
+	}
+}
+
+func TestColorProfileDegradesStyles(t *testing.T) {
+	r := New(COLOR_NONE)
+	if out := r.styleAndReset(underline, "x"); out != "x" {
+		t.Errorf("COLOR_NONE should not emit escape codes, got %q", out)
+	}
+	selection := StyleSpec{Background: "#1a4f78"}
+	if on, off := r.sgr(selection); on != "" || off != "" {
+		t.Errorf("COLOR_NONE should not emit escape codes, got %q %q", on, off)
+	}
+
+	r.Colors = COLOR_256
+	if on, _ := r.sgr(selection); on != "\x1b[48;5;24m" {
+		t.Errorf("got %q", on)
+	}
+	r.Colors = COLOR_TRUECOLOR
+	if on, off := r.sgr(selection); on != "\x1b[48;2;26;79;120m" || off != "\x1b[49m" {
+		t.Errorf("got %q %q", on, off)
+	}
+}
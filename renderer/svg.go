@@ -0,0 +1,487 @@
+package renderer
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	parser "github.com/horriblename/mathcha/latex"
+	"github.com/mattn/go-runewidth"
+)
+
+// SVG backend
+//
+// The layout works like the terminal drawer, only with real distances: every
+// node is laid out into a box with a width and a height above (ascent) and
+// below (descent) its baseline, and boxes are joined horizontally along their
+// baselines, just like JoinHorizontal aligns blocks on level 0. Superscripts
+// sit above the baseline, subscripts and denominators below it.
+//
+// All distances are in em, and only converted to pixels when writing the SVG.
+// There are no font metrics available, so the width of a glyph is estimated
+// from its terminal cell width; every glyph is centered in its box to hide the
+// error.
+
+type SVGConfig struct {
+	FontSize   float64 // in px, defaults to 20
+	FontFamily string  // defaults to a serif font
+	Color      string  // fill and stroke color, defaults to black
+}
+
+const (
+	svgCharWidth   = 0.6  // estimated width of a single-cell glyph
+	svgAscent      = 0.75 // height of a glyph above the baseline
+	svgDescent     = 0.25 // depth of a glyph below the baseline
+	svgAxis        = 0.25 // height of the math axis (fraction bars) above the baseline
+	svgOpSpace     = 0.25 // space around binary operators and relations
+	svgScriptScale = 0.7  // size of superscripts and subscripts
+	svgBigOpScale  = 1.4  // size of \sum, \int, ...
+	svgLineWidth   = 0.06
+	svgPadding     = 0.2
+)
+
+// a laid out node. Items are positioned relative to the left end of the
+// baseline, with y growing downwards like in SVG
+type svgBox struct {
+	width, ascent, descent float64
+	items                  []svgItem
+}
+
+type svgItem interface {
+	// writes the item translated by (dx, dy) em; unit is the size of an em in px
+	write(b *strings.Builder, dx, dy, unit float64)
+}
+
+type svgText struct {
+	x, y   float64 // center of the glyph's baseline
+	text   string
+	size   float64 // font size in em
+	italic bool
+	scaleY float64 // vertical stretch for delimiters, 0 means none
+}
+
+type svgLine struct {
+	x1, y1, x2, y2 float64
+}
+
+type svgPath struct {
+	points [][2]float64
+}
+
+func (t svgText) write(b *strings.Builder, dx, dy, unit float64) {
+	style := ""
+	if t.italic {
+		style = ` font-style="italic"`
+	}
+	x, y := (t.x+dx)*unit, (t.y+dy)*unit
+	if t.scaleY != 0 {
+		fmt.Fprintf(b, `<text transform="translate(%.2f %.2f) scale(1 %.3f)" font-size="%.2f" text-anchor="middle"%s>%s</text>`,
+			x, y, t.scaleY, t.size*unit, style, html.EscapeString(t.text))
+	} else {
+		fmt.Fprintf(b, `<text x="%.2f" y="%.2f" font-size="%.2f" text-anchor="middle"%s>%s</text>`,
+			x, y, t.size*unit, style, html.EscapeString(t.text))
+	}
+	b.WriteString("\n")
+}
+
+func (l svgLine) write(b *strings.Builder, dx, dy, unit float64) {
+	fmt.Fprintf(b, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="currentColor" stroke-width="%.2f"/>`+"\n",
+		(l.x1+dx)*unit, (l.y1+dy)*unit, (l.x2+dx)*unit, (l.y2+dy)*unit, svgLineWidth*unit)
+}
+
+func (p svgPath) write(b *strings.Builder, dx, dy, unit float64) {
+	b.WriteString(`<polyline fill="none" points="`)
+	for i, pt := range p.points {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(b, "%.2f,%.2f", (pt[0]+dx)*unit, (pt[1]+dy)*unit)
+	}
+	fmt.Fprintf(b, `" stroke="currentColor" stroke-width="%.2f"/>`+"\n", svgLineWidth*unit)
+}
+
+// places another box's items at (dx, dy) relative to this box's origin
+func (box *svgBox) place(other svgBox, dx, dy float64) {
+	for _, item := range other.items {
+		box.items = append(box.items, shiftSVGItem(item, dx, dy))
+	}
+}
+
+func shiftSVGItem(item svgItem, dx, dy float64) svgItem {
+	switch it := item.(type) {
+	case svgText:
+		it.x, it.y = it.x+dx, it.y+dy
+		return it
+	case svgLine:
+		it.x1, it.y1, it.x2, it.y2 = it.x1+dx, it.y1+dy, it.x2+dx, it.y2+dy
+		return it
+	case svgPath:
+		points := make([][2]float64, len(it.points))
+		for i, pt := range it.points {
+			points[i] = [2]float64{pt[0] + dx, pt[1] + dy}
+		}
+		return svgPath{points}
+	}
+	return item
+}
+
+func (box svgBox) height() float64 { return box.ascent + box.descent }
+
+// a box containing a single glyph run, spaced by pad em on both sides
+func svgGlyphs(text string, scale float64, italic bool, pad float64) svgBox {
+	if text == "" {
+		return svgBox{}
+	}
+	width := float64(runewidth.StringWidth(text))*svgCharWidth*scale + 2*pad*scale
+	return svgBox{
+		width:   width,
+		ascent:  svgAscent * scale,
+		descent: svgDescent * scale,
+		items:   []svgItem{svgText{x: width / 2, text: text, size: scale, italic: italic}},
+	}
+}
+
+// joins boxes along their baselines
+func svgJoinHorizontal(boxes ...svgBox) svgBox {
+	var joined svgBox
+	for _, box := range boxes {
+		joined.place(box, joined.width, 0)
+		joined.width += box.width
+		if box.ascent > joined.ascent {
+			joined.ascent = box.ascent
+		}
+		if box.descent > joined.descent {
+			joined.descent = box.descent
+		}
+	}
+	return joined
+}
+
+// a delimiter glyph stretched to cover the given box vertically
+func svgDelimiter(text string, around svgBox, scale float64) svgBox {
+	height := around.height()
+	if height <= (svgAscent+svgDescent)*scale*1.2 {
+		return svgGlyphs(text, scale, false, 0.05)
+	}
+	stretch := height / ((svgAscent + svgDescent) * scale)
+	width := svgCharWidth * scale
+	return svgBox{
+		width:   width,
+		ascent:  around.ascent,
+		descent: around.descent,
+		items: []svgItem{svgText{
+			x:      width / 2,
+			y:      around.descent - svgDescent*scale*stretch,
+			text:   text,
+			size:   scale,
+			scaleY: stretch,
+		}},
+	}
+}
+
+func (cfg *SVGConfig) layout(node parser.Expr, scale float64) svgBox {
+	switch n := node.(type) {
+	case *parser.TextContainer:
+		return svgGlyphs(n.Text.BuildString(), scale, false, 0)
+	case *parser.ParenCompExpr:
+		content := cfg.layoutRow(n.Children(), scale)
+		if len(n.Children()) == 0 {
+			content = svgGlyphs(" ", scale, false, 0)
+		}
+		left := svgDelimiter(strings.TrimPrefix(n.Left, `\`), content, scale)
+		right := svgDelimiter(strings.TrimPrefix(n.Right, `\`), content, scale)
+		return svgJoinHorizontal(left, content, right)
+	case *parser.EnvExpr:
+		return cfg.layoutEnv(n, scale)
+	case parser.FlexContainer:
+		return cfg.layoutRow(n.Children(), scale)
+	case parser.CmdContainer:
+		switch n.Command() {
+		case parser.CMD_frac, parser.CMD_binom:
+			return cfg.layoutFrac(n, scale)
+		case parser.CMD_sqrt:
+			return cfg.layoutSqrt(n, scale)
+		case parser.CMD_overline, parser.CMD_underline:
+			box := cfg.layout(n.Children()[0], scale)
+			y := -box.ascent - 0.1*scale
+			if n.Command() == parser.CMD_underline {
+				y = box.descent + 0.1*scale
+			}
+			box.items = append(box.items, svgLine{0, y, box.width, y})
+			if n.Command() == parser.CMD_overline {
+				box.ascent += 0.15 * scale
+			} else {
+				box.descent += 0.15 * scale
+			}
+			return box
+		case parser.CMD_superscript, parser.CMD_subscript:
+			// scripts without a base, see layoutRow for scripts with one
+			return cfg.layoutScripts(n.(*parser.Cmd1ArgExpr), nil, svgBox{}, scale)
+		}
+		return svgGlyphs("?", scale, false, 0)
+	case *parser.UnknownCmdLit:
+		return svgGlyphs("?", scale, false, 0)
+	case parser.CmdLiteral:
+		cmd := n.Command()
+		text := GetVanillaString(cmd)
+		switch {
+		case cmd.IsBigOperator():
+			return svgGlyphs(text, scale*svgBigOpScale, false, 0.05)
+		case cmd.IsRelation(), cmd == parser.CMD_times, cmd == parser.CMD_cdot, cmd == parser.CMD_pm,
+			cmd == parser.CMD_mp, cmd == parser.CMD_div:
+			return svgGlyphs(text, scale, false, svgOpSpace)
+		case strings.TrimSpace(text) == "":
+			// spacing commands
+			return svgBox{width: float64(len(text)) * svgCharWidth * scale * 0.5}
+		case len(strings.TrimSpace(text)) > 1:
+			// named functions, e.g. \sin
+			return svgGlyphs(strings.TrimSpace(text), scale, false, 0.1)
+		}
+		// lowercase greek letters are variables, uppercase ones are upright
+		return svgGlyphs(text, scale, cmd.IsGreek() && cmd < parser.CMD_Gamma, 0)
+	case *parser.VarLit:
+		return svgGlyphs(n.Content(), scale, true, 0)
+	case *parser.SimpleOpLit:
+		switch n.Source {
+		case "+", "-", "=", "<", ">":
+			text := n.Source
+			if text == "-" {
+				text = "−" // proper minus sign
+			}
+			return svgGlyphs(text, scale, false, svgOpSpace)
+		}
+		return svgGlyphs(n.Source, scale, false, 0)
+	case *Cursor, nil:
+		return svgBox{}
+	case parser.Literal:
+		return svgGlyphs(n.Content(), scale, false, 0)
+	}
+	return svgBox{}
+}
+
+// lays out siblings, attaching superscripts and subscripts to the box before
+// them
+func (cfg *SVGConfig) layoutRow(children []parser.Expr, scale float64) svgBox {
+	boxes := make([]svgBox, 0, len(children))
+	for i := 0; i < len(children); i++ {
+		script, ok := children[i].(*parser.Cmd1ArgExpr)
+		if !ok || (script.Type != parser.CMD_superscript && script.Type != parser.CMD_subscript) {
+			boxes = append(boxes, cfg.layout(children[i], scale))
+			continue
+		}
+
+		// stack neighboring superscripts and subscripts onto each other
+		var other *parser.Cmd1ArgExpr
+		if i+1 < len(children) {
+			if next, ok := children[i+1].(*parser.Cmd1ArgExpr); ok && next.Type != script.Type &&
+				(next.Type == parser.CMD_superscript || next.Type == parser.CMD_subscript) {
+				other = next
+				i++
+			}
+		}
+		var base svgBox
+		if len(boxes) > 0 {
+			base = boxes[len(boxes)-1]
+			boxes = boxes[:len(boxes)-1]
+		}
+		boxes = append(boxes, cfg.layoutScripts(script, other, base, scale))
+	}
+	return svgJoinHorizontal(boxes...)
+}
+
+// attaches one or two scripts (a superscript and/or a subscript) to base
+func (cfg *SVGConfig) layoutScripts(a, b *parser.Cmd1ArgExpr, base svgBox, scale float64) svgBox {
+	var sup, sub *svgBox
+	for _, script := range []*parser.Cmd1ArgExpr{a, b} {
+		if script == nil {
+			continue
+		}
+		box := cfg.layout(script.Arg1, scale*svgScriptScale)
+		if script.Type == parser.CMD_superscript {
+			sup = &box
+		} else {
+			sub = &box
+		}
+	}
+
+	result := base
+	scriptWidth := 0.0
+	if sup != nil {
+		// the bottom of the superscript sits a bit below the top of the base
+		shift := -max2(base.ascent, svgAscent*scale) + sup.descent + 0.35*scale
+		result.place(*sup, base.width, shift)
+		if -shift+sup.ascent > result.ascent {
+			result.ascent = -shift + sup.ascent
+		}
+		scriptWidth = sup.width
+	}
+	if sub != nil {
+		shift := max2(base.descent, svgDescent*scale) + sub.ascent - 0.4*scale
+		result.place(*sub, base.width, shift)
+		if shift+sub.descent > result.descent {
+			result.descent = shift + sub.descent
+		}
+		if sub.width > scriptWidth {
+			scriptWidth = sub.width
+		}
+	}
+	result.width = base.width + scriptWidth
+	return result
+}
+
+func (cfg *SVGConfig) layoutFrac(node parser.CmdContainer, scale float64) svgBox {
+	num := cfg.layout(node.Children()[0], scale)
+	den := cfg.layout(node.Children()[1], scale)
+	pad := 0.1 * scale
+	gap := 0.15 * scale
+	width := max2(num.width, den.width) + 2*pad
+	axis := -svgAxis * scale
+
+	box := svgBox{
+		width:   width,
+		ascent:  -axis + gap + num.height(),
+		descent: axis + gap + den.height(),
+	}
+	box.place(num, (width-num.width)/2, axis-gap-num.descent)
+	box.place(den, (width-den.width)/2, axis+gap+den.ascent)
+	if node.Command() == parser.CMD_frac {
+		box.items = append(box.items, svgLine{pad / 2, axis, width - pad/2, axis})
+		return box
+	}
+
+	// \binom has parentheses instead of a bar
+	left := svgDelimiter("(", box, scale)
+	right := svgDelimiter(")", box, scale)
+	return svgJoinHorizontal(left, box, right)
+}
+
+func (cfg *SVGConfig) layoutSqrt(node parser.CmdContainer, scale float64) svgBox {
+	radicand := cfg.layout(node.Children()[0], scale)
+	top := -radicand.ascent - 0.15*scale
+	bottom := radicand.descent + 0.05*scale
+	mid := (top+bottom)/2 + 0.15*scale
+	sign := 0.6 * scale
+
+	box := svgBox{
+		width:   sign + radicand.width + 0.1*scale,
+		ascent:  -top + svgLineWidth,
+		descent: bottom,
+	}
+	box.items = append(box.items, svgPath{[][2]float64{
+		{0, mid},
+		{0.12 * scale, mid - 0.06*scale},
+		{0.3 * scale, bottom},
+		{sign - 0.05*scale, top},
+		{box.width, top},
+	}})
+	box.place(radicand, sign, 0)
+	return box
+}
+
+func (cfg *SVGConfig) layoutEnv(node *parser.EnvExpr, scale float64) svgBox {
+	numCols := 0
+	for _, row := range node.Elts {
+		if len(row) > numCols {
+			numCols = len(row)
+		}
+	}
+
+	colGap := 0.8 * scale
+	rowGap := 0.3 * scale
+	colWidths := make([]float64, numCols)
+	cells := make([][]svgBox, len(node.Elts))
+	for r, row := range node.Elts {
+		cells[r] = make([]svgBox, len(row))
+		for c, cell := range row {
+			cells[r][c] = cfg.layout(cell, scale)
+			colWidths[c] = max2(colWidths[c], cells[r][c].width)
+		}
+	}
+
+	var body svgBox
+	y := 0.0
+	for r, row := range cells {
+		ascent, descent := svgAscent*scale, svgDescent*scale
+		for _, cell := range row {
+			ascent, descent = max2(ascent, cell.ascent), max2(descent, cell.descent)
+		}
+		if r > 0 {
+			y += rowGap
+		}
+		y += ascent
+		x := 0.0
+		for c, cell := range row {
+			// matrices center their columns, aligned environments alternate
+			// between right and left alignment around "&"
+			offset := (colWidths[c] - cell.width) / 2
+			if node.Name == parser.ENV_align {
+				offset = 0
+				if c%2 == 0 {
+					offset = colWidths[c] - cell.width
+				}
+			}
+			body.place(cell, x+offset, y)
+			x += colWidths[c] + colGap
+		}
+		y += descent
+	}
+	for _, w := range colWidths {
+		body.width += w + colGap
+	}
+	if numCols > 0 {
+		body.width -= colGap
+	}
+
+	// center the table on the math axis
+	shift := -y/2 - svgAxis*scale
+	var centered svgBox
+	centered.place(body, 0, shift)
+	centered.width = body.width
+	centered.ascent = -shift
+	centered.descent = y + shift
+
+	if node.Name != parser.ENV_matrix {
+		return centered
+	}
+	left := svgDelimiter("[", centered, scale)
+	right := svgDelimiter("]", centered, scale)
+	return svgJoinHorizontal(left, centered, right)
+}
+
+// ProduceSVG lays out the formula and returns a standalone SVG document
+func (cfg *SVGConfig) ProduceSVG(node parser.Expr) string {
+	unit := cfg.FontSize
+	if unit == 0 {
+		unit = 20
+	}
+	family := cfg.FontFamily
+	if family == "" {
+		family = "STIX Two Math, Cambria Math, Latin Modern Math, serif"
+	}
+	color := cfg.Color
+	if color == "" {
+		color = "black"
+	}
+
+	box := cfg.layout(node, 1)
+	width := (box.width + 2*svgPadding) * unit
+	height := (box.height() + 2*svgPadding) * unit
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%.2f" height="%.2f" viewBox="0 0 %.2f %.2f">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(&b, `<g font-family="%s" color="%s" fill="currentColor">`+"\n",
+		html.EscapeString(family), html.EscapeString(color))
+	for _, item := range box.items {
+		item.write(&b, svgPadding, svgPadding+box.ascent, unit)
+	}
+	b.WriteString("</g>\n</svg>\n")
+	return b.String()
+}
+
+func max2(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,113 @@
+package renderer
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	parser "github.com/horriblename/mathcha/latex"
+)
+
+// counts the elements of an SVG document by name, failing on malformed XML
+func countSVGElements(t *testing.T, svg string) map[string]int {
+	t.Helper()
+	counts := map[string]int{}
+	dec := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return counts
+		}
+		if err != nil {
+			t.Fatalf("invalid SVG: %s\n%s", err, svg)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			counts[start.Name.Local]++
+		}
+	}
+}
+
+func TestProduceSVG(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		input     string
+		texts     int
+		lines     int
+		polylines int
+		stretched int
+	}{
+		{
+			desc:  "literals",
+			input: `a+1<b`,
+			texts: 5,
+		},
+		{
+			desc:  "fraction bar",
+			input: `\frac{1}{x}`,
+			texts: 2,
+			lines: 1,
+		},
+		{
+			desc:      "radical",
+			input:     `\sqrt{2}`,
+			texts:     1,
+			polylines: 1,
+		},
+		{
+			desc:      "scaled delimiters",
+			input:     `\left(\frac{1}{2}\right)`,
+			texts:     4,
+			lines:     1,
+			stretched: 2,
+		},
+		{
+			desc:  "scripts",
+			input: `x^2_i`,
+			texts: 3,
+		},
+		{
+			desc:  "escaped text",
+			input: `a<b`,
+			texts: 3,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			tree := parser.Parse(tC.input)
+			cfg := SVGConfig{}
+			svg := cfg.ProduceSVG(tree)
+			counts := countSVGElements(t, svg)
+			if counts["svg"] != 1 {
+				t.Errorf("expected a single svg root, got %d", counts["svg"])
+			}
+			if counts["text"] != tC.texts || counts["line"] != tC.lines || counts["polyline"] != tC.polylines {
+				t.Errorf("got %d texts, %d lines, %d polylines; want %d, %d, %d\n%s",
+					counts["text"], counts["line"], counts["polyline"], tC.texts, tC.lines, tC.polylines, svg)
+			}
+			if n := strings.Count(svg, "scale(1 "); n != tC.stretched {
+				t.Errorf("got %d stretched delimiters, want %d\n%s", n, tC.stretched, svg)
+			}
+		})
+	}
+}
+
+func TestSVGScriptPositions(t *testing.T) {
+	cfg := SVGConfig{}
+	tree := parser.Parse(`x^2_i`)
+	box := cfg.layout(tree, 1)
+	if len(box.items) != 3 {
+		t.Fatalf("expected 3 glyphs, got %d", len(box.items))
+	}
+	base, sup, sub := box.items[0].(svgText), box.items[1].(svgText), box.items[2].(svgText)
+	if !(sup.y < base.y && base.y < sub.y) {
+		t.Errorf("expected superscript above and subscript below the baseline, got y=%v, %v, %v", sup.y, base.y, sub.y)
+	}
+	if sup.x != sub.x || sup.x <= base.x {
+		t.Errorf("expected stacked scripts after the base, got x=%v, %v, %v", base.x, sup.x, sub.x)
+	}
+	if sup.size >= base.size {
+		t.Errorf("expected smaller scripts")
+	}
+}
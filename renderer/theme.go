@@ -0,0 +1,290 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	parser "github.com/horriblename/mathcha/latex"
+)
+
+// StyleSpec describes how one semantic class of the formula is drawn.
+// Colors are either "#rrggbb" or an ANSI color number ("0"-"255"), and are
+// degraded to the closest color the terminal supports
+type StyleSpec struct {
+	Foreground string `json:"fg,omitempty"`
+	Background string `json:"bg,omitempty"`
+	Bold       bool   `json:"bold,omitempty"`
+	Italic     bool   `json:"italic,omitempty"`
+	Underline  bool   `json:"underline,omitempty"`
+	Reverse    bool   `json:"reverse,omitempty"`
+}
+
+// A Theme assigns a style to each semantic class.
+//
+// Theme files are JSON objects with the same keys as the struct tags below,
+// each holding a StyleSpec. Classes missing from the file are taken from the
+// theme named by "extends" (one of BuiltinThemes, "dark" if not given).
+// Classes in the file are merged with the base theme attribute by attribute,
+// so "variable": {"fg": "#fff"} keeps the italics of the base theme unless it
+// says "italic": false. For example:
+//
+//	{
+//		"extends": "light",
+//		"variable": {"fg": "#0184bc", "italic": true},
+//		"focus": {"bg": "#e5e5e6"}
+//	}
+type Theme struct {
+	Variable       StyleSpec `json:"variable"`
+	Number         StyleSpec `json:"number"`
+	Operator       StyleSpec `json:"operator"`        // +, -, = and other plain symbols
+	CommandSymbol  StyleSpec `json:"command_symbol"`  // e.g. \alpha, \times
+	UnknownCommand StyleSpec `json:"unknown_command"` // drawn as "?"
+	Focus          StyleSpec `json:"focus"`           // the box around the container holding the cursor
+	Selection      StyleSpec `json:"selection"`
+	Cursor         StyleSpec `json:"cursor"`
+	Inserted       StyleSpec `json:"inserted"` // changes shown by "mathcha diff"
+	Deleted        StyleSpec `json:"deleted"`
+	Modified       StyleSpec `json:"modified"`
+
+	// used instead of the plain classes above when semantic highlighting is on
+	Semantic SemanticStyles `json:"semantic"`
+}
+
+// Styles for semantic highlighting, see Renderer.Highlight
+type SemanticStyles struct {
+	Number        StyleSpec `json:"number"`
+	Variable      StyleSpec `json:"variable"`
+	Greek         StyleSpec `json:"greek"`
+	Relation      StyleSpec `json:"relation"`     // =, <, \le, \in, ...
+	BigOperator   StyleSpec `json:"big_operator"` // \sum, \int, ...
+	Text          StyleSpec `json:"text"`         // \text{...}
+	Error         StyleSpec `json:"error"`        // unparsable input
+	MatchingDelim StyleSpec `json:"matching_delim"`
+}
+
+var DarkTheme = Theme{
+	Variable:       StyleSpec{Italic: true},
+	UnknownCommand: StyleSpec{Underline: true},
+	Focus:          StyleSpec{Foreground: "#abb2bf", Background: "#505050"},
+	Selection:      StyleSpec{Background: "#1a4f78"},
+	Cursor:         StyleSpec{Reverse: true},
+	Inserted:       StyleSpec{Background: "#2d4a2b"},
+	Deleted:        StyleSpec{Background: "#5c2626", Underline: true},
+	Modified:       StyleSpec{Background: "#5c4a1e"},
+	Semantic: SemanticStyles{
+		Number:        StyleSpec{Foreground: "#d19a66"},
+		Variable:      StyleSpec{Foreground: "#e06c75", Italic: true},
+		Greek:         StyleSpec{Foreground: "#c678dd"},
+		Relation:      StyleSpec{Foreground: "#56b6c2"},
+		BigOperator:   StyleSpec{Foreground: "#61afef", Bold: true},
+		Text:          StyleSpec{Foreground: "#98c379"},
+		Error:         StyleSpec{Foreground: "#ff5555", Underline: true},
+		MatchingDelim: StyleSpec{Foreground: "#e5c07b", Bold: true},
+	},
+}
+
+var LightTheme = Theme{
+	Variable:       StyleSpec{Italic: true},
+	UnknownCommand: StyleSpec{Underline: true, Foreground: "#e45649"},
+	Focus:          StyleSpec{Foreground: "#383a42", Background: "#dcdcdc"},
+	Selection:      StyleSpec{Background: "#a9c7e8"},
+	Cursor:         StyleSpec{Reverse: true},
+	Inserted:       StyleSpec{Background: "#c6efc6"},
+	Deleted:        StyleSpec{Background: "#f5c6c6", Underline: true},
+	Modified:       StyleSpec{Background: "#f5e3a9"},
+	Semantic: SemanticStyles{
+		Number:        StyleSpec{Foreground: "#986801"},
+		Variable:      StyleSpec{Foreground: "#e45649", Italic: true},
+		Greek:         StyleSpec{Foreground: "#a626a4"},
+		Relation:      StyleSpec{Foreground: "#0184bc"},
+		BigOperator:   StyleSpec{Foreground: "#4078f2", Bold: true},
+		Text:          StyleSpec{Foreground: "#50a14f"},
+		Error:         StyleSpec{Foreground: "#ca1243", Underline: true},
+		MatchingDelim: StyleSpec{Foreground: "#c18401", Bold: true},
+	},
+}
+
+var BuiltinThemes = map[string]*Theme{
+	"dark":  &DarkTheme,
+	"light": &LightTheme,
+}
+
+// LoadTheme returns the builtin theme of the given name, or reads a theme
+// file otherwise. "auto" is the dark theme, see AutoTheme
+func LoadTheme(nameOrPath string) (*Theme, error) {
+	if nameOrPath == "auto" {
+		nameOrPath = "dark"
+	}
+	if theme, ok := BuiltinThemes[nameOrPath]; ok {
+		return theme, nil
+	}
+
+	data, err := os.ReadFile(nameOrPath)
+	if err != nil {
+		return nil, fmt.Errorf("theme %q is neither a builtin theme nor a readable file: %w", nameOrPath, err)
+	}
+	theme, err := ParseTheme(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", nameOrPath, err)
+	}
+	return theme, nil
+}
+
+// AutoTheme picks the dark or light theme based on the terminal background.
+// It queries the terminal, so it is only meant for the interactive editor
+func AutoTheme() *Theme {
+	if lipgloss.HasDarkBackground() {
+		return &DarkTheme
+	}
+	return &LightTheme
+}
+
+// ParseTheme parses the content of a theme file, see Theme. The classes of the
+// file are merged with those of the base theme. Unknown keys are an error, so
+// that typos don't go unnoticed
+func ParseTheme(data []byte) (*Theme, error) {
+	var header struct {
+		Extends string `json:"extends"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	if header.Extends == "" {
+		header.Extends = "dark"
+	}
+	base, ok := BuiltinThemes[header.Extends]
+	if !ok {
+		return nil, fmt.Errorf("unknown builtin theme %q", header.Extends)
+	}
+
+	theme := *base
+	file := struct {
+		Extends string `json:"extends"`
+		*Theme
+	}{Theme: &theme}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, err
+	}
+	return &theme, nil
+}
+
+// style of a number, variable, operator or command symbol
+func (r *Renderer) literalStyle(node parser.Literal) StyleSpec {
+	theme := r.theme()
+	if r.Highlight {
+		switch n := node.(type) {
+		case *parser.NumberLit:
+			return theme.Semantic.Number
+		case *parser.VarLit:
+			return theme.Semantic.Variable
+		case *parser.SimpleOpLit:
+			switch n.Source {
+			case "=", "<", ">":
+				return theme.Semantic.Relation
+			}
+		case *parser.UnknownCmdLit:
+			return theme.Semantic.Error
+		case *parser.BadExpr:
+			return theme.Semantic.Error
+		case parser.CmdLiteral:
+			switch cmd := n.Command(); {
+			case cmd.IsGreek():
+				return theme.Semantic.Greek
+			case cmd.IsRelation():
+				return theme.Semantic.Relation
+			case cmd.IsBigOperator():
+				return theme.Semantic.BigOperator
+			}
+		}
+	}
+
+	switch node.(type) {
+	case *parser.NumberLit:
+		return theme.Number
+	case *parser.VarLit:
+		return theme.Variable
+	case *parser.SimpleOpLit:
+		return theme.Operator
+	case *parser.UnknownCmdLit:
+		return theme.UnknownCommand
+	case parser.CmdLiteral:
+		return theme.CommandSymbol
+	}
+	return StyleSpec{}
+}
+
+func (r *Renderer) theme() *Theme {
+	if r.Theme == nil {
+		return &DarkTheme
+	}
+	return r.Theme
+}
+
+// returns the escape codes that turn on and off the given style
+func (r *Renderer) sgr(spec StyleSpec) (on string, off string) {
+	if r.Colors == COLOR_NONE {
+		return "", ""
+	}
+	profile := r.Colors.termenv()
+	var set, reset []string
+	if c := profile.Color(spec.Foreground); spec.Foreground != "" && c != nil && c.Sequence(false) != "" {
+		set, reset = append(set, c.Sequence(false)), append(reset, "39")
+	}
+	if c := profile.Color(spec.Background); spec.Background != "" && c != nil && c.Sequence(true) != "" {
+		set, reset = append(set, c.Sequence(true)), append(reset, "49")
+	}
+	if spec.Bold {
+		set, reset = append(set, "1"), append(reset, "22")
+	}
+	if spec.Italic {
+		set, reset = append(set, "3"), append(reset, "23")
+	}
+	if spec.Underline {
+		set, reset = append(set, "4"), append(reset, "24")
+	}
+	if spec.Reverse {
+		set, reset = append(set, "7"), append(reset, "27")
+	}
+	if len(set) == 0 {
+		return "", ""
+	}
+	return "\x1b[" + strings.Join(set, ";") + "m", "\x1b[" + strings.Join(reset, ";") + "m"
+}
+
+// paint wraps every line of content in the given style. Only the attributes
+// that are set get reset afterwards, so that enclosing styles (e.g. the focus
+// background) stay intact
+func (r *Renderer) paint(spec StyleSpec, content string) string {
+	on, off := r.sgr(spec)
+	if on == "" {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = on + line + off
+	}
+	return strings.Join(lines, "\n")
+}
+
+// a lipgloss style for boxes, as opposed to paint, lines are padded to the
+// same width
+func (r *Renderer) boxStyle(spec StyleSpec) lipgloss.Style {
+	style := r.lipgloss().NewStyle().
+		Bold(spec.Bold).
+		Italic(spec.Italic).
+		Underline(spec.Underline).
+		Reverse(spec.Reverse)
+	if spec.Foreground != "" {
+		style = style.Foreground(lipgloss.Color(spec.Foreground))
+	}
+	if spec.Background != "" {
+		style = style.Background(lipgloss.Color(spec.Background))
+	}
+	return style
+}
@@ -0,0 +1,171 @@
+package renderer
+
+import (
+	"os"
+	"testing"
+
+	parser "github.com/horriblename/mathcha/latex"
+)
+
+func TestParseTheme(t *testing.T) {
+	theme, err := ParseTheme([]byte(`{
+		"extends": "light",
+		"variable": {"fg": "#0184bc"},
+		"number": {"fg": "1", "bold": true}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if theme.Variable.Foreground != "#0184bc" || !theme.Variable.Italic {
+		t.Errorf("variable should be overridden while keeping italics from the light theme, got %+v", theme.Variable)
+	}
+	if theme.Number != (StyleSpec{Foreground: "1", Bold: true}) {
+		t.Errorf("got %+v", theme.Number)
+	}
+	if theme.Focus != LightTheme.Focus {
+		t.Errorf("missing classes should be taken from the light theme, got %+v", theme.Focus)
+	}
+	if LightTheme.Variable.Foreground != "" {
+		t.Error("parsing a theme modified the builtin theme")
+	}
+
+	theme, err = ParseTheme([]byte(`{"variable": {"fg": "#fff", "italic": false}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if theme.Variable != (StyleSpec{Foreground: "#fff"}) {
+		t.Errorf("italics of the dark theme should be turned off, got %+v", theme.Variable)
+	}
+
+	if _, err := ParseTheme([]byte(`{"extends": "neon"}`)); err == nil {
+		t.Error("expected error for unknown base theme")
+	}
+	if _, err := ParseTheme([]byte(`{"semantic": {"numbr": {"fg": "1"}}}`)); err == nil {
+		t.Error("expected error for unknown key")
+	}
+}
+
+func TestExampleTheme(t *testing.T) {
+	data, err := os.ReadFile("../extras/themes/example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseTheme(data); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestThemeStylesLiterals(t *testing.T) {
+	r := New(COLOR_16)
+	r.Theme = &Theme{
+		Variable: StyleSpec{Italic: true},
+		Number:   StyleSpec{Foreground: "1"},
+	}
+
+	out, _ := r.Prerender(parser.Parse("2x"))
+	expect := "\x1b[31m2\x1b[39m\x1b[3mx\x1b[23m"
+	if out != expect {
+		t.Errorf("got:  %q\nwant: %q", out, expect)
+	}
+}
+
+func TestSemanticHighlight(t *testing.T) {
+	r := New(COLOR_16)
+	r.Theme = &Theme{
+		CommandSymbol: StyleSpec{Bold: true},
+		Semantic: SemanticStyles{
+			Greek:         StyleSpec{Foreground: "1"},
+			Relation:      StyleSpec{Foreground: "2"},
+			BigOperator:   StyleSpec{Foreground: "3"},
+			Text:          StyleSpec{Foreground: "4"},
+			Error:         StyleSpec{Underline: true},
+			MatchingDelim: StyleSpec{Foreground: "5"},
+		},
+	}
+
+	testCases := []struct {
+		desc      string
+		input     string
+		highlight bool
+		expect    string
+	}{
+		{
+			desc:      "disabled - command symbols share one style",
+			input:     `\alpha\le\sum`,
+			highlight: false,
+			expect:    "\x1b[1mα\x1b[22m\x1b[1m≤\x1b[22m\x1b[1m∑\x1b[22m",
+		},
+		{
+			desc:      "greek, relation and big operator",
+			input:     `\alpha\le\sum`,
+			highlight: true,
+			expect:    "\x1b[31mα\x1b[39m\x1b[32m≤\x1b[39m\x1b[33m∑\x1b[39m",
+		},
+		{
+			desc:      "other command symbols keep the plain style",
+			input:     `\times`,
+			highlight: true,
+			expect:    "\x1b[1m×\x1b[22m",
+		},
+		{
+			desc:      "equal sign is a relation",
+			input:     `=`,
+			highlight: true,
+			expect:    "\x1b[32m = \x1b[39m",
+		},
+		{
+			desc:      "text",
+			input:     `\text{hi}`,
+			highlight: true,
+			expect:    "\x1b[34mhi\x1b[39m",
+		},
+		{
+			desc:      "unknown command",
+			input:     `\foo`,
+			highlight: true,
+			expect:    "\x1b[4m?\x1b[24m",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			r.Highlight = tC.highlight
+			out, _ := r.Prerender(parser.Parse(tC.input))
+			if out != tC.expect {
+				t.Errorf("got:  %q\nwant: %q", out, tC.expect)
+			}
+		})
+	}
+
+	t.Run("matching delimiters around the cursor", func(t *testing.T) {
+		r.Highlight = true
+		r.Theme.Focus = StyleSpec{}
+		tree := parser.Parse(`\left(1\right)`)
+		paren := tree.Elts[0].(*parser.ParenCompExpr)
+		r.LatexTree = tree
+		r.Focus = true
+		r.Sync(paren, false)
+
+		expect := "\x1b[35m(\x1b[39m1\x1b[35m)\x1b[39m"
+		if r.Buffer != expect {
+			t.Errorf("got:  %q\nwant: %q", r.Buffer, expect)
+		}
+	})
+
+	t.Run("matching delimiters around a nested cursor", func(t *testing.T) {
+		tree := parser.Parse(`\left(a\left(b\right)^{c}\right)`)
+		outer := tree.Elts[0].(*parser.ParenCompExpr)
+		inner := outer.Elts[1].(*parser.ParenCompExpr)
+		script := outer.Elts[2].(*parser.Cmd1ArgExpr).Arg1.(parser.Container)
+		if got := enclosingParen(tree, script); got != outer {
+			t.Errorf("in the superscript: got %v, want the outer parentheses", got)
+		}
+		if got := enclosingParen(tree, inner); got != inner {
+			t.Errorf("in the inner parentheses: got %v, want them", got)
+		}
+		if got := enclosingParen(tree, tree); got != nil {
+			t.Errorf("outside of parentheses: got %v, want nil", got)
+		}
+	})
+}
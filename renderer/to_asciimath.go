@@ -0,0 +1,167 @@
+package renderer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/horriblename/mathcha/asciimath"
+	parser "github.com/horriblename/mathcha/latex"
+)
+
+// ProduceAsciiMath returns the formula in AsciiMath syntax, which is read back
+// by asciimath.Parse
+func ProduceAsciiMath(node parser.Expr) string {
+	w := asciiMathWriter{}
+	w.write(node)
+	return strings.TrimSpace(w.b.String())
+}
+
+// like typstWriter, neighboring words are separated by a space, since
+// AsciiMath would otherwise read e.g. "x" "x" as "xx" (times)
+type asciiMathWriter struct {
+	b strings.Builder
+}
+
+func (w *asciiMathWriter) append(s string) {
+	if s == "" {
+		return
+	}
+	prev, _ := utf8.DecodeLastRuneInString(w.b.String())
+	next, _ := utf8.DecodeRuneInString(s)
+	if (unicode.IsLetter(prev) && typstWordRune(next)) || (unicode.IsDigit(prev) && unicode.IsLetter(next)) ||
+		asciiMathSymbolRune(prev) && asciiMathSymbolRune(next) {
+		w.b.WriteByte(' ')
+	}
+	w.b.WriteString(s)
+}
+
+// runs of these form multi-character symbols, e.g. "<" "=" becomes "<="
+func asciiMathSymbolRune(r rune) bool {
+	return strings.ContainsRune(`+-*/\<>=!~:|_^.,@`, r)
+}
+
+func (w *asciiMathWriter) produce(node parser.Expr) string {
+	sub := asciiMathWriter{}
+	sub.write(node)
+	return strings.TrimSpace(sub.b.String())
+}
+
+// the argument of a script or fraction, in brackets unless it is a single
+// token
+func (w *asciiMathWriter) group(node parser.Expr) string {
+	if container, ok := node.(parser.FlexContainer); ok && len(container.Children()) == 1 {
+		switch child := container.Children()[0].(type) {
+		case *parser.Cmd1ArgExpr:
+			// function applications like sqrt(x)
+			switch child.Type {
+			case parser.CMD_sqrt, parser.CMD_overline, parser.CMD_underline:
+				return w.produce(node)
+			}
+		case *parser.TextContainer:
+			return w.produce(node)
+		}
+	}
+	s := w.produce(node)
+	_, isSymbol := asciimath.Symbols[s]
+	if isSymbol || utf8.RuneCountInString(s) == 1 || strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) }) == -1 {
+		return s
+	}
+	return "(" + s + ")"
+}
+
+// rows of an environment, e.g. "[[a,b],[c,d]]" or "{:(x,=1),(y,=2):}"
+func (w *asciiMathWriter) matrix(env *parser.EnvExpr, open, close string) string {
+	rowOpen, rowClose := "[", "]"
+	if open != "[" {
+		rowOpen, rowClose = "(", ")"
+	}
+	rows := make([]string, len(env.Elts))
+	for i, row := range env.Elts {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			cells[j] = w.produce(cell)
+		}
+		rows[i] = rowOpen + strings.Join(cells, ",") + rowClose
+	}
+	return open + strings.Join(rows, ",") + close
+}
+
+func (w *asciiMathWriter) write(node parser.Expr) {
+	switch n := node.(type) {
+	case *parser.TextContainer:
+		text := n.Text.BuildString()
+		if strings.Contains(text, `"`) {
+			w.append("text(" + text + ")")
+		} else {
+			w.append(`"` + text + `"`)
+		}
+	case *parser.ParenCompExpr:
+		left, right := strings.TrimPrefix(n.Left, `\`), strings.TrimPrefix(n.Right, `\`)
+		if env, ok := n.Elts[0].(*parser.EnvExpr); ok && len(n.Elts) == 1 && left == "(" && env.Name == parser.ENV_matrix {
+			w.append(w.matrix(env, "(", ")"))
+			return
+		}
+		sub := asciiMathWriter{}
+		for _, c := range n.Children() {
+			sub.write(c)
+		}
+		w.append(left + strings.TrimSpace(sub.b.String()) + right)
+	case *parser.EnvExpr:
+		if n.Name == parser.ENV_align {
+			w.append(w.matrix(n, "{:", ":}"))
+		} else {
+			w.append(w.matrix(n, "[", "]"))
+		}
+	case parser.FlexContainer:
+		for _, c := range n.Children() {
+			w.write(c)
+		}
+	case parser.CmdContainer:
+		args := n.Children()
+		switch n.Command() {
+		case parser.CMD_frac:
+			w.append(w.group(args[0]) + "/" + w.group(args[1]))
+		case parser.CMD_binom:
+			w.append("((" + w.produce(args[0]) + "),(" + w.produce(args[1]) + "))")
+		case parser.CMD_sqrt:
+			w.append("sqrt(" + w.produce(args[0]) + ")")
+		case parser.CMD_overline:
+			w.append("bar(" + w.produce(args[0]) + ")")
+		case parser.CMD_underline:
+			w.append("ul(" + w.produce(args[0]) + ")")
+		case parser.CMD_superscript:
+			w.b.WriteString("^" + w.group(args[0]))
+		case parser.CMD_subscript:
+			w.b.WriteString("_" + w.group(args[0]))
+		}
+	case *parser.UnknownCmdLit:
+		w.append(`"` + n.Content() + `"`)
+	case parser.CmdLiteral:
+		cmd := n.Command()
+		if name, ok := asciimath.SymbolName(cmd); ok {
+			w.append(name)
+			return
+		}
+		if lit, ok := n.(*parser.SimpleCmdLit); ok && cmd == parser.CMD_UNKNOWN {
+			// escaped symbols like \{
+			w.append(strings.TrimPrefix(lit.Source, `\`))
+			return
+		}
+		if text := strings.TrimSpace(GetVanillaString(cmd)); text != "" {
+			w.append(text)
+			return
+		}
+		w.append(`"` + strings.TrimSpace(cmd.GetCmd()) + `"`)
+	case *parser.SimpleOpLit:
+		if n.Source == "/" {
+			// a single slash makes a fraction
+			w.append("//")
+			return
+		}
+		w.append(n.Source)
+	case *Cursor:
+	case parser.Literal:
+		w.append(n.Content())
+	}
+}
@@ -0,0 +1,56 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/horriblename/mathcha/asciimath"
+	parser "github.com/horriblename/mathcha/latex"
+)
+
+func TestProduceAsciiMath(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect string
+		lossy  bool // the AST changes when read back
+	}{
+		{input: `\sum_{i=1}^{n}i^{2}`, expect: `sum_(i=1)^n i^2`},
+		{input: `\frac{\sqrt{x}}{2}`, expect: `sqrt(x)/2`},
+		{input: `\frac{x+1}{y}`, expect: `(x+1)/y`},
+		{input: `x\times y\le\alpha`, expect: `x xx y<=alpha`},
+		{input: `xx`, expect: `x x`},
+		{input: `<=`, expect: `< =`},
+		{input: `a/b`, expect: `a//b`, lossy: true}, // "//" is \slash
+		{input: `\text{if}x`, expect: `"if"x`},
+		{input: `\begin{matrix}a&b\\c&d\end{matrix}`, expect: `[[a,b],[c,d]]`},
+		{input: `\begin{align}x&=1\\y&=2\end{align}`, expect: `{:(x,=1),(y,=2):}`},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.input, func(t *testing.T) {
+			tree := parser.Parse(tC.input)
+			got := ProduceAsciiMath(tree)
+			if got != tC.expect {
+				t.Errorf("got %q, want %q", got, tC.expect)
+			}
+			// and back
+			reparsed := asciimath.Parse(got)
+			if !tC.lossy && !tree.DeepEqWith(reparsed, parser.DeepEqCfg{SkipPos: true}) {
+				t.Errorf("AST mismatch after round trip\noriginal:\n%s\nreparsed:\n%s", tree.VisualizeTree(), reparsed.VisualizeTree())
+			}
+		})
+	}
+}
+
+// AsciiMath can't express everything LaTeX can (e.g. \left...\right), but
+// the output must be stable once read back
+func TestAsciiMathStable(t *testing.T) {
+	for _, tc := range latexTestCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			first := ProduceAsciiMath(parser.Parse(tc.input))
+			second := ProduceAsciiMath(asciimath.Parse(first))
+			if first != second {
+				t.Errorf("output changed after round trip: %q -> %q", first, second)
+			}
+		})
+	}
+}
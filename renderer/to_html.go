@@ -0,0 +1,217 @@
+package renderer
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	parser "github.com/horriblename/mathcha/latex"
+)
+
+type HTMLConfig struct {
+	Inline bool // display="inline" instead of "block"
+}
+
+// ProduceHTML returns the formula as a presentation MathML <math> element,
+// ready to be embedded in an HTML page. The LaTeX source is attached as an
+// annotation and a plain text version (see ProducePlainText) as aria-label
+func (cfg *HTMLConfig) ProduceHTML(node parser.Expr) string {
+	display := "block"
+	if cfg.Inline {
+		display = "inline"
+	}
+	latexCfg := LatexSourceConfig{}
+
+	b := strings.Builder{}
+	b.WriteString(`<math xmlns="http://www.w3.org/1998/Math/MathML" display="` + display + `"`)
+	b.WriteString(` aria-label="` + html.EscapeString(ProducePlainText(node)) + `">`)
+	b.WriteString("<semantics>")
+	b.WriteString(cfg.produceMathML(node))
+	b.WriteString(`<annotation encoding="application/x-tex">`)
+	b.WriteString(html.EscapeString(strings.TrimSpace(latexCfg.ProduceLatex(node))))
+	b.WriteString("</annotation></semantics></math>")
+	return b.String()
+}
+
+func (cfg *HTMLConfig) produceMathML(node parser.Expr) string {
+	switch n := node.(type) {
+	case *parser.TextContainer:
+		return mathMLElem("mtext", n.Text.BuildString())
+	case *parser.ParenCompExpr:
+		b := strings.Builder{}
+		b.WriteString("<mrow>")
+		b.WriteString(`<mo fence="true" stretchy="true">` + html.EscapeString(strings.TrimPrefix(n.Left, `\`)) + "</mo>")
+		b.WriteString(cfg.produceMathMLRow(n.Children()))
+		b.WriteString(`<mo fence="true" stretchy="true">` + html.EscapeString(strings.TrimPrefix(n.Right, `\`)) + "</mo>")
+		b.WriteString("</mrow>")
+		return b.String()
+	case *parser.EnvExpr:
+		b := strings.Builder{}
+		if n.Name == parser.ENV_matrix {
+			b.WriteString(`<mrow><mo fence="true" stretchy="true">[</mo>`)
+			b.WriteString("<mtable>")
+		} else {
+			b.WriteString(`<mtable columnalign="right left" columnspacing="0">`)
+		}
+		for _, row := range n.Elts {
+			b.WriteString("<mtr>")
+			for _, cell := range row {
+				b.WriteString("<mtd>" + cfg.produceMathML(cell) + "</mtd>")
+			}
+			b.WriteString("</mtr>")
+		}
+		b.WriteString("</mtable>")
+		if n.Name == parser.ENV_matrix {
+			b.WriteString(`<mo fence="true" stretchy="true">]</mo></mrow>`)
+		}
+		return b.String()
+	case parser.FlexContainer:
+		return "<mrow>" + cfg.produceMathMLRow(n.Children()) + "</mrow>"
+	case parser.CmdContainer:
+		args := n.Children()
+		switch n.Command() {
+		case parser.CMD_frac:
+			return "<mfrac>" + cfg.produceMathML(args[0]) + cfg.produceMathML(args[1]) + "</mfrac>"
+		case parser.CMD_binom:
+			return `<mrow><mo fence="true" stretchy="true">(</mo><mfrac linethickness="0">` +
+				cfg.produceMathML(args[0]) + cfg.produceMathML(args[1]) +
+				`</mfrac><mo fence="true" stretchy="true">)</mo></mrow>`
+		case parser.CMD_sqrt:
+			return "<msqrt>" + cfg.produceMathML(args[0]) + "</msqrt>"
+		case parser.CMD_overline:
+			return `<mover accent="true">` + cfg.produceMathML(args[0]) + `<mo stretchy="true">¯</mo></mover>`
+		case parser.CMD_underline:
+			return `<munder accentunder="true">` + cfg.produceMathML(args[0]) + `<mo stretchy="true">_</mo></munder>`
+		case parser.CMD_superscript, parser.CMD_subscript:
+			// scripts without a base, see produceMathMLRow for scripts with one
+			return cfg.produceMathMLScripts("<mrow></mrow>", false, n.(*parser.Cmd1ArgExpr), nil)
+		}
+		return mathMLError(n.Command().GetCmd())
+	case *parser.UnknownCmdLit:
+		return mathMLError(n.Content())
+	case *parser.BadExpr:
+		return mathMLError(n.Content())
+	case parser.CmdLiteral:
+		cmd := n.Command()
+		text := GetVanillaString(cmd)
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case cmd.IsBigOperator():
+			return `<mo largeop="true">` + html.EscapeString(trimmed) + "</mo>"
+		case trimmed == "":
+			if text == "" {
+				return mathMLError(n.Content())
+			}
+			// "\ "
+			return `<mspace width="0.25em"/>`
+		case utf8.RuneCountInString(trimmed) > 1:
+			// named functions, e.g. \sin
+			return mathMLElem("mi", trimmed)
+		}
+		if r, _ := utf8.DecodeRuneInString(trimmed); unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return mathMLElem("mi", trimmed)
+		}
+		return mathMLElem("mo", trimmed)
+	case *parser.VarLit:
+		// multi-letter variables are implicit multiplications
+		b := strings.Builder{}
+		for _, r := range n.Source {
+			b.WriteString(mathMLElem("mi", string(r)))
+		}
+		return b.String()
+	case *parser.NumberLit:
+		return mathMLElem("mn", n.Source)
+	case *parser.SimpleOpLit:
+		return mathMLElem("mo", n.Source)
+	case *Cursor:
+		return ""
+	case parser.Literal:
+		return mathMLElem("mi", n.Content())
+	}
+	return ""
+}
+
+// the children of a row, with superscripts and subscripts attached to the
+// element before them
+func (cfg *HTMLConfig) produceMathMLRow(children []parser.Expr) string {
+	out := make([]string, 0, len(children))
+	// whether the last element in out is a big operator, whose limits go above
+	// and below it in block display
+	bigOp := false
+	// the parser emits one NumberLit per digit, these are joined into one <mn>
+	number := ""
+	for i := 0; i < len(children); i++ {
+		if num, ok := children[i].(*parser.NumberLit); ok {
+			if number != "" {
+				out = out[:len(out)-1]
+			}
+			number += num.Source
+			out = append(out, mathMLElem("mn", number))
+			bigOp = false
+			continue
+		}
+		number = ""
+
+		script, ok := children[i].(*parser.Cmd1ArgExpr)
+		if !ok || (script.Type != parser.CMD_superscript && script.Type != parser.CMD_subscript) {
+			out = append(out, cfg.produceMathML(children[i]))
+			cmd, isCmd := children[i].(parser.CmdLiteral)
+			bigOp = isCmd && cmd.Command().IsBigOperator()
+			continue
+		}
+
+		var other *parser.Cmd1ArgExpr
+		if i+1 < len(children) {
+			if next, ok := children[i+1].(*parser.Cmd1ArgExpr); ok && next.Type != script.Type &&
+				(next.Type == parser.CMD_superscript || next.Type == parser.CMD_subscript) {
+				other = next
+				i++
+			}
+		}
+		base := "<mrow></mrow>"
+		if len(out) > 0 {
+			base = out[len(out)-1]
+			out = out[:len(out)-1]
+		}
+		out = append(out, cfg.produceMathMLScripts(base, bigOp && !cfg.Inline, script, other))
+		bigOp = false
+	}
+	return strings.Join(out, "")
+}
+
+// attaches one or two scripts (a superscript and/or a subscript) to base
+func (cfg *HTMLConfig) produceMathMLScripts(base string, limits bool, a, b *parser.Cmd1ArgExpr) string {
+	sup, sub := "", ""
+	for _, script := range []*parser.Cmd1ArgExpr{a, b} {
+		if script == nil {
+			continue
+		}
+		if script.Type == parser.CMD_superscript {
+			sup = cfg.produceMathML(script.Arg1)
+		} else {
+			sub = cfg.produceMathML(script.Arg1)
+		}
+	}
+
+	tags := [3]string{"msubsup", "msup", "msub"}
+	if limits {
+		tags = [3]string{"munderover", "mover", "munder"}
+	}
+	switch {
+	case sup != "" && sub != "":
+		return "<" + tags[0] + ">" + base + sub + sup + "</" + tags[0] + ">"
+	case sup != "":
+		return "<" + tags[1] + ">" + base + sup + "</" + tags[1] + ">"
+	default:
+		return "<" + tags[2] + ">" + base + sub + "</" + tags[2] + ">"
+	}
+}
+
+func mathMLElem(tag, content string) string {
+	return "<" + tag + ">" + html.EscapeString(content) + "</" + tag + ">"
+}
+
+func mathMLError(source string) string {
+	return "<merror>" + mathMLElem("mtext", source) + "</merror>"
+}
@@ -0,0 +1,111 @@
+package renderer
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	parser "github.com/horriblename/mathcha/latex"
+)
+
+func TestProduceHTML(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		input  string
+		inline bool
+		expect []string // substrings of the output
+	}{
+		{
+			desc:   "tokens",
+			input:  `x+12<\alpha`,
+			expect: []string{`display="block"`, "<mi>x</mi><mo>+</mo><mn>12</mn><mo>&lt;</mo><mi>α</mi>"},
+		},
+		{
+			desc:   "inline display",
+			input:  `x`,
+			inline: true,
+			expect: []string{`display="inline"`},
+		},
+		{
+			desc:   "fraction and radical",
+			input:  `\frac{1}{\sqrt{2}}`,
+			expect: []string{"<mfrac><mrow><mn>1</mn></mrow><mrow><msqrt><mrow><mn>2</mn></mrow></msqrt></mrow></mfrac>"},
+		},
+		{
+			desc:   "scripts",
+			input:  `x^2_i`,
+			expect: []string{"<msubsup><mi>x</mi><mrow><mi>i</mi></mrow><mrow><mn>2</mn></mrow></msubsup>"},
+		},
+		{
+			desc:   "big operator limits",
+			input:  `\sum_{i}x`,
+			expect: []string{`<munder><mo largeop="true">∑</mo><mrow><mi>i</mi></mrow></munder><mi>x</mi>`},
+		},
+		{
+			desc:   "big operator limits - inline",
+			input:  `\sum_{i}x`,
+			inline: true,
+			expect: []string{`<msub><mo largeop="true">∑</mo>`},
+		},
+		{
+			desc:  "matrix",
+			input: `\begin{matrix}1 & 2\\3 & 4\end{matrix}`,
+			expect: []string{
+				"<mtable><mtr><mtd><mrow><mn>1</mn></mrow></mtd><mtd><mrow><mn>2</mn></mrow></mtd></mtr>",
+				`aria-label="[[1, 2], [3, 4]]"`,
+			},
+		},
+		{
+			desc:   "annotation and label",
+			input:  `\frac{a}{b+1}`,
+			expect: []string{`aria-label="a/(b + 1)"`, `<annotation encoding="application/x-tex">\frac {a}{b+1}</annotation>`},
+		},
+		{
+			desc:   "unknown command",
+			input:  `\foo`,
+			expect: []string{`<merror><mtext>\foo</mtext></merror>`},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			cfg := HTMLConfig{Inline: tC.inline}
+			out := cfg.ProduceHTML(parser.Parse(tC.input))
+			for _, expect := range tC.expect {
+				if !strings.Contains(out, expect) {
+					t.Errorf("expected %q in output:\n%s", expect, out)
+				}
+			}
+			if err := xml.Unmarshal([]byte(out), new(struct{})); err != nil {
+				t.Errorf("output is not well-formed: %s\n%s", err, out)
+			}
+		})
+	}
+}
+
+func TestProducePlainText(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect string
+	}{
+		{`x^2+1`, `x^2 + 1`},
+		{`x^{n+1}`, `x^(n + 1)`},
+		{`x_{i}y`, `x_i y`},
+		{`\frac{1}{2}x^2`, `1/2 x^2`},
+		{`\frac{1}{2}\le\sqrt{x+1}`, `1/2 less than or equal to square root of (x + 1)`},
+		{`\sum_{i=0}^{n} i`, `sum from i = 0 to n of i`},
+		{`\int f\,dx`, `integral of f dx`},
+		{`\alpha\beta`, `alpha beta`},
+		{`\left(a\right)\cdot b`, `(a) times b`},
+		{`\text{if }x`, `if x`},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.input, func(t *testing.T) {
+			out := ProducePlainText(parser.Parse(tC.input))
+			if out != tC.expect {
+				t.Errorf("got %q, want %q", out, tC.expect)
+			}
+		})
+	}
+}
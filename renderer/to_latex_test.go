@@ -6,95 +6,96 @@ import (
 	parser "github.com/horriblename/mathcha/latex"
 )
 
-func TestLatexConsistent(t *testing.T) {
-	testCases := []struct {
-		desc  string
-		input string
-	}{
-		{
-			desc:  "NumberLit - single digit",
-			input: "5",
-		},
-		{
-			desc:  "NumberLit - multiple digits",
-			input: "123",
-		},
-		{
-			desc:  "VarLit - single letter",
-			input: "x",
-		},
-		{
-			desc:  "SimpleOpLit - plus sign",
-			input: "+",
-		},
-		{
-			desc:  "SimpleCmdLit - math symbol",
-			input: "\\times",
-		},
-		{
-			desc:  "SimpleCmdLit - greek letter",
-			input: "\\pi",
-		},
-		{
-			desc:  "CompositeExpr - simple braces",
-			input: "{x}",
-		},
-		{
-			desc:  "CompositeExpr - nested",
-			input: "{a + b}",
-		},
-		{
-			desc:  "SuperExpr - superscript",
-			input: "x^2",
-		},
-		{
-			desc:  "SubExpr - subscript",
-			input: "x_1",
-		},
-		{
-			desc:  "Cmd1ArgExpr - sqrt",
-			input: "\\sqrt{x}",
-		},
-		{
-			desc:  "Cmd1ArgExpr - underline",
-			input: "\\underline{x}",
-		},
-		{
-			desc:  "Cmd2ArgExpr - frac",
-			input: "\\frac{1}{2}",
-		},
-		{
-			desc:  "Cmd2ArgExpr - binom",
-			input: "\\binom{a}{b}",
-		},
-		{
-			desc:  "ParenCompExpr - left right parentheses",
-			input: "\\left( x \\right)",
-		},
-		{
-			desc:  "ParenCompExpr - brackets",
-			input: "\\left[ x \\right]",
-		},
-		{
-			desc:  "TextContainer - text command",
-			input: "\\text{hello}",
-		},
-		{
-			desc:  "EnvExpr - matrix environment",
-			input: `\begin{matrix} a & b \\ c & d \end{matrix}`,
-		},
-		{
-			desc:  "EnvExpr - single cell",
-			input: `\begin{matrix} x \end{matrix}`,
-		},
-		{
-			desc:  "Combined - simple expression",
-			input: "x + 1",
-		},
-	}
+// also used by the tests of other producers, see e.g. TestProduceTypst
+var latexTestCases = []struct {
+	desc  string
+	input string
+}{
+	{
+		desc:  "NumberLit - single digit",
+		input: "5",
+	},
+	{
+		desc:  "NumberLit - multiple digits",
+		input: "123",
+	},
+	{
+		desc:  "VarLit - single letter",
+		input: "x",
+	},
+	{
+		desc:  "SimpleOpLit - plus sign",
+		input: "+",
+	},
+	{
+		desc:  "SimpleCmdLit - math symbol",
+		input: "\\times",
+	},
+	{
+		desc:  "SimpleCmdLit - greek letter",
+		input: "\\pi",
+	},
+	{
+		desc:  "CompositeExpr - simple braces",
+		input: "{x}",
+	},
+	{
+		desc:  "CompositeExpr - nested",
+		input: "{a + b}",
+	},
+	{
+		desc:  "SuperExpr - superscript",
+		input: "x^2",
+	},
+	{
+		desc:  "SubExpr - subscript",
+		input: "x_1",
+	},
+	{
+		desc:  "Cmd1ArgExpr - sqrt",
+		input: "\\sqrt{x}",
+	},
+	{
+		desc:  "Cmd1ArgExpr - underline",
+		input: "\\underline{x}",
+	},
+	{
+		desc:  "Cmd2ArgExpr - frac",
+		input: "\\frac{1}{2}",
+	},
+	{
+		desc:  "Cmd2ArgExpr - binom",
+		input: "\\binom{a}{b}",
+	},
+	{
+		desc:  "ParenCompExpr - left right parentheses",
+		input: "\\left( x \\right)",
+	},
+	{
+		desc:  "ParenCompExpr - brackets",
+		input: "\\left[ x \\right]",
+	},
+	{
+		desc:  "TextContainer - text command",
+		input: "\\text{hello}",
+	},
+	{
+		desc:  "EnvExpr - matrix environment",
+		input: `\begin{matrix} a & b \\ c & d \end{matrix}`,
+	},
+	{
+		desc:  "EnvExpr - single cell",
+		input: `\begin{matrix} x \end{matrix}`,
+	},
+	{
+		desc:  "Combined - simple expression",
+		input: "x + 1",
+	},
+}
 
+func TestLatexConsistent(t *testing.T) {
 	cfg := &LatexSourceConfig{}
-	for _, tc := range testCases {
+	for _, tc := range latexTestCases {
 		t.Run(tc.desc, func(t *testing.T) {
 			tree := parser.Parse(tc.input)
 			if tree == nil {
@@ -114,3 +115,11 @@ func TestLatexConsistent(t *testing.T) {
 		})
 	}
 }
+
+func TestProduceLatexSpacing(t *testing.T) {
+	cfg := &LatexSourceConfig{}
+	input := `\cos x\cdot\sin x`
+	if got := cfg.ProduceLatex(parser.Parse(input)); got != `\cos x\cdot \sin x` {
+		t.Errorf("%s: got %q", input, got)
+	}
+}
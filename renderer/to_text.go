@@ -0,0 +1,201 @@
+package renderer
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	parser "github.com/horriblename/mathcha/latex"
+)
+
+// ProducePlainText returns a linear, human-readable form of the formula in
+// plain ASCII, e.g. "(a + 1)/2 less than or equal to square root of x". It is
+// meant to be read (by people or screen readers), not parsed
+func ProducePlainText(node parser.Expr) string {
+	b := strings.Builder{}
+	writePlainText(&b, node)
+	// words are padded with spaces, see plainTextWord
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// spoken names of symbols; the other commands are written like the ASCII
+// drawing does, e.g. alpha or sin, see GetASCIIString
+var plainTextWords = map[parser.LatexCmd]string{
+	parser.CMD_le:      "less than or equal to",
+	parser.CMD_ge:      "greater than or equal to",
+	parser.CMD_lt:      "less than",
+	parser.CMD_gt:      "greater than",
+	parser.CMD_ne:      "not equal to",
+	parser.CMD_ll:      "much less than",
+	parser.CMD_gg:      "much greater than",
+	parser.CMD_equiv:   "equivalent to",
+	parser.CMD_sim:     "similar to",
+	parser.CMD_propto:  "proportional to",
+	parser.CMD_in:      "in",
+	parser.CMD_notin:   "not in",
+	parser.CMD_subset:  "subset of",
+	parser.CMD_cup:     "union",
+	parser.CMD_cap:     "intersection",
+	parser.CMD_to:      "to",
+	parser.CMD_mapsto:  "maps to",
+	parser.CMD_times:   "times",
+	parser.CMD_cdot:    "times",
+	parser.CMD_div:     "divided by",
+	parser.CMD_pm:      "plus or minus",
+	parser.CMD_mp:      "minus or plus",
+	parser.CMD_infty:   "infinity",
+	parser.CMD_ldots:   "dots",
+	parser.CMD_cdots:   "dots",
+	parser.CMD_dots:    "dots",
+	parser.CMD_forall:  "for all",
+	parser.CMD_exists:  "there exists",
+	parser.CMD_neg:     "not",
+	parser.CMD_degree:  "degrees",
+	parser.CMD_sum:     "sum",
+	parser.CMD_prod:    "product",
+	parser.CMD_int:     "integral",
+	parser.CMD_iint:    "double integral",
+	parser.CMD_iiint:   "triple integral",
+	parser.CMD_oint:    "contour integral",
+	parser.CMD_bigcup:  "union",
+	parser.CMD_bigcap:  "intersection",
+	parser.CMD_partial: "partial",
+}
+
+// the spoken name of a command, padded with spaces so that it doesn't run
+// into its neighbours
+func plainTextWord(cmd parser.LatexCmd) string {
+	word, ok := plainTextWords[cmd]
+	if !ok {
+		word = GetASCIIString(cmd)
+	}
+	return " " + word + " "
+}
+
+func isScriptCmd(n parser.Expr) bool {
+	cmd, ok := n.(parser.CmdContainer)
+	return ok && (cmd.Command() == parser.CMD_superscript || cmd.Command() == parser.CMD_subscript)
+}
+
+// writes the children of a FlexContainer; a big operator reads its limits as
+// "from ... to ... of", e.g. "sum from i = 1 to n of"
+func writePlainTextChildren(b *strings.Builder, children []parser.Expr) {
+	for i := 0; i < len(children); i++ {
+		lit, ok := children[i].(parser.CmdLiteral)
+		if !ok || !lit.Command().IsBigOperator() {
+			writePlainText(b, children[i])
+			continue
+		}
+		b.WriteString(plainTextWord(lit.Command()))
+		var from, to string
+		for i+1 < len(children) && isScriptCmd(children[i+1]) {
+			i++
+			script := children[i].(parser.CmdContainer)
+			if script.Command() == parser.CMD_subscript {
+				from = ProducePlainText(script.Children()[0])
+			} else {
+				to = ProducePlainText(script.Children()[0])
+			}
+		}
+		if from != "" {
+			b.WriteString(" from " + from + " ")
+		}
+		if to != "" {
+			b.WriteString(" to " + to + " ")
+		}
+		b.WriteString(" of ")
+	}
+}
+
+func writePlainText(b *strings.Builder, node parser.Expr) {
+	switch n := node.(type) {
+	case *parser.TextContainer:
+		b.WriteString(n.Text.BuildString())
+	case *parser.ParenCompExpr:
+		b.WriteString(strings.TrimPrefix(n.Left, `\`))
+		writePlainTextChildren(b, n.Children())
+		b.WriteString(strings.TrimPrefix(n.Right, `\`))
+	case *parser.EnvExpr:
+		rowSep, open, close := "; ", "", ""
+		if n.Name == parser.ENV_matrix {
+			rowSep, open, close = ", ", "[", "]"
+		}
+		b.WriteString(open)
+		for i, row := range n.Elts {
+			if i > 0 {
+				b.WriteString(rowSep)
+			}
+			b.WriteString(open)
+			for j, cell := range row {
+				if j > 0 && n.Name == parser.ENV_matrix {
+					b.WriteString(", ")
+				}
+				b.WriteString(ProducePlainText(cell))
+			}
+			b.WriteString(close)
+		}
+		b.WriteString(close)
+	case parser.FlexContainer:
+		writePlainTextChildren(b, n.Children())
+	case parser.CmdContainer:
+		args := n.Children()
+		switch n.Command() {
+		case parser.CMD_frac:
+			b.WriteString(" " + plainTextGroup(args[0]) + "/" + plainTextGroup(args[1]) + " ")
+		case parser.CMD_binom:
+			b.WriteString(" " + plainTextGroup(args[0]) + " choose " + plainTextGroup(args[1]) + " ")
+		case parser.CMD_sqrt:
+			b.WriteString(" square root of " + plainTextGroup(args[0]) + " ")
+		case parser.CMD_superscript:
+			b.WriteString("^" + plainTextGroup(args[0]) + " ")
+		case parser.CMD_subscript:
+			b.WriteString("_" + plainTextGroup(args[0]) + " ")
+		default:
+			for _, arg := range args {
+				writePlainText(b, arg)
+			}
+		}
+	case *parser.UnknownCmdLit:
+		b.WriteString(n.Content())
+	case parser.CmdLiteral:
+		if lit, ok := n.(*parser.SimpleCmdLit); ok && n.Command() == parser.CMD_UNKNOWN {
+			// escaped symbols like \{ and spaces like \,
+			text := strings.TrimPrefix(lit.Source, `\`)
+			if strings.ContainsAny(text, ",;:! ") {
+				text = " "
+			}
+			b.WriteString(text)
+			break
+		}
+		b.WriteString(plainTextWord(n.Command()))
+	case *parser.SimpleOpLit:
+		switch n.Source {
+		case "+", "-", "=", "<", ">":
+			b.WriteString(" " + n.Source + " ")
+		case ",", ";":
+			b.WriteString(n.Source + " ")
+		default:
+			b.WriteString(n.Source)
+		}
+	case *Cursor:
+	case parser.Literal:
+		b.WriteString(n.Content())
+	}
+}
+
+// plain text of node, parenthesized unless it is a single symbol or number
+func plainTextGroup(node parser.Expr) string {
+	text := ProducePlainText(node)
+	if container, ok := node.(parser.FlexContainer); ok && len(container.Children()) == 1 {
+		switch container.Children()[0].(type) {
+		case *parser.NumberLit, *parser.SimpleCmdLit:
+			if !strings.Contains(text, " ") {
+				return text
+			}
+		case *parser.VarLit:
+			if utf8.RuneCountInString(text) == 1 {
+				return text
+			}
+		}
+	}
+	return "(" + text + ")"
+}
@@ -0,0 +1,230 @@
+package renderer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	parser "github.com/horriblename/mathcha/latex"
+)
+
+// ProduceTypst returns the formula in Typst math syntax, i.e. the content
+// between the "$"s of a Typst equation
+func ProduceTypst(node parser.Expr) string {
+	w := typstWriter{}
+	w.write(node)
+	return strings.TrimSpace(w.b.String())
+}
+
+// typstWriter appends Typst source piece by piece. Typst reads runs of letters
+// as a single (multi-letter) identifier, so neighboring pieces that would run
+// into each other are separated by a space
+type typstWriter struct {
+	b strings.Builder
+	// inside function arguments, e.g. frac(...), where commas separate
+	// arguments and need to be escaped
+	inArgs bool
+}
+
+func (w *typstWriter) append(s string) {
+	if s == "" {
+		return
+	}
+	prev, _ := utf8.DecodeLastRuneInString(w.b.String())
+	next, _ := utf8.DecodeRuneInString(s)
+	if typstWordRune(prev) && typstWordRune(next) && !(unicode.IsDigit(prev) && unicode.IsDigit(next)) {
+		w.b.WriteByte(' ')
+	}
+	w.b.WriteString(s)
+}
+
+func typstWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// produces a node on its own, i.e. without merging it with its neighbors
+func (w *typstWriter) produce(node parser.Expr) string {
+	sub := typstWriter{inArgs: w.inArgs}
+	sub.write(node)
+	return strings.TrimSpace(sub.b.String())
+}
+
+// produces function arguments, separated by ", "
+func (w *typstWriter) args(nodes ...parser.Expr) string {
+	sub := typstWriter{inArgs: true}
+	args := make([]string, len(nodes))
+	for i, node := range nodes {
+		args[i] = sub.produce(node)
+	}
+	return strings.Join(args, ", ")
+}
+
+// produces the argument of ^ or _, parenthesized unless it is a single token
+func (w *typstWriter) group(node parser.Expr) string {
+	s := w.produce(node)
+	switch {
+	case s == "":
+		return `""`
+	case utf8.RuneCountInString(s) == 1, typstIsAtom(s):
+		return s
+	}
+	return "(" + s + ")"
+}
+
+// whether s is a single word, number or function call, e.g. "12", "sin" or
+// "frac(a, b)"
+func typstIsAtom(s string) bool {
+	name := strings.IndexFunc(s, func(r rune) bool { return !typstWordRune(r) && r != '.' })
+	if name == -1 {
+		return true
+	}
+	if name == 0 || s[name] != '(' || !strings.HasSuffix(s, ")") {
+		return false
+	}
+	// the opening parenthesis must be closed by the last one
+	depth := 0
+	for i, r := range s[name:] {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && name+i != len(s)-1 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+func (w *typstWriter) write(node parser.Expr) {
+	switch n := node.(type) {
+	case *parser.TextContainer:
+		text := n.Text.BuildString()
+		text = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text)
+		w.append(`"` + text + `"`)
+	case *parser.ParenCompExpr:
+		left, right := strings.TrimPrefix(n.Left, `\`), strings.TrimPrefix(n.Right, `\`)
+		sub := typstWriter{}
+		for _, c := range n.Children() {
+			sub.write(c)
+		}
+		w.append("lr(" + left + strings.TrimSpace(sub.b.String()) + right + ")")
+	case *parser.EnvExpr:
+		rows := make([]string, len(n.Elts))
+		if n.Name == parser.ENV_matrix {
+			for i, row := range n.Elts {
+				cells := make([]parser.Expr, len(row))
+				for j, cell := range row {
+					cells[j] = cell
+				}
+				rows[i] = w.args(cells...)
+			}
+			w.append(`mat(delim: "[", ` + strings.Join(rows, "; ") + ")")
+			return
+		}
+		for i, row := range n.Elts {
+			cells := make([]string, len(row))
+			for j, cell := range row {
+				cells[j] = w.produce(cell)
+			}
+			rows[i] = strings.Join(cells, " & ")
+		}
+		w.append(strings.Join(rows, ` \`+"\n"))
+	case *parser.CompositeExpr:
+		// braces only group in LaTeX, there is nothing to draw
+		for _, c := range n.Children() {
+			w.write(c)
+		}
+	case parser.FlexContainer:
+		for _, c := range n.Children() {
+			w.write(c)
+		}
+	case parser.CmdContainer:
+		args := n.Children()
+		switch n.Command() {
+		case parser.CMD_frac:
+			w.append("frac(" + w.args(args[0], args[1]) + ")")
+		case parser.CMD_binom:
+			w.append("binom(" + w.args(args[0], args[1]) + ")")
+		case parser.CMD_sqrt:
+			w.append("sqrt(" + w.args(args[0]) + ")")
+		case parser.CMD_overline:
+			w.append("overline(" + w.args(args[0]) + ")")
+		case parser.CMD_underline:
+			w.append("underline(" + w.args(args[0]) + ")")
+		case parser.CMD_superscript:
+			if w.b.Len() == 0 {
+				w.b.WriteString(`""`)
+			}
+			w.b.WriteString("^" + w.group(args[0]))
+		case parser.CMD_subscript:
+			if w.b.Len() == 0 {
+				w.b.WriteString(`""`)
+			}
+			w.b.WriteString("_" + w.group(args[0]))
+		}
+	case *parser.UnknownCmdLit:
+		w.append(`"` + strings.ReplaceAll(n.Content(), `\`, `\\`) + `"`)
+	case parser.CmdLiteral:
+		cmd := n.Command()
+		text := GetVanillaString(cmd)
+		switch {
+		case typstSymbols[cmd] != "":
+			w.append(typstSymbols[cmd])
+		case cmd == parser.CMD_SPACE:
+			w.append("thick")
+		case cmd == parser.CMD_UNKNOWN:
+			// escaped symbols like \{
+			if lit, ok := n.(*parser.SimpleCmdLit); ok {
+				w.append(typstEscape(strings.TrimPrefix(lit.Source, `\`)))
+			}
+		case text == "":
+			w.append(strings.TrimSpace(strings.TrimPrefix(cmd.GetCmd(), `\`)))
+		default:
+			// named functions like "sin " are Typst identifiers too
+			w.append(typstEscape(strings.TrimSpace(text)))
+		}
+	case *parser.SimpleOpLit:
+		if n.Source == "," && w.inArgs {
+			w.append(`\,`)
+			return
+		}
+		w.append(typstEscape(n.Source))
+	case *Cursor:
+	case parser.Literal:
+		w.append(n.Content())
+	}
+}
+
+// symbols that are spelled out by name, which reads better than their unicode
+// counterparts. Everything else is written as unicode
+var typstSymbols = map[parser.LatexCmd]string{
+	parser.CMD_sum:       "sum",
+	parser.CMD_prod:      "product",
+	parser.CMD_coprod:    "product.co",
+	parser.CMD_int:       "integral",
+	parser.CMD_oint:      "integral.cont",
+	parser.CMD_iint:      "integral.double",
+	parser.CMD_iiint:     "integral.triple",
+	parser.CMD_oiint:     "integral.surf",
+	parser.CMD_oiiint:    "integral.vol",
+	parser.CMD_bigcap:    "inter.big",
+	parser.CMD_bigcup:    "union.big",
+	parser.CMD_bigsqcup:  "union.sq.big",
+	parser.CMD_bigvee:    "or.big",
+	parser.CMD_bigwedge:  "and.big",
+	parser.CMD_bigodot:   "dot.circle.big",
+	parser.CMD_bigotimes: "times.circle.big",
+	parser.CMD_bigoplus:  "plus.circle.big",
+	parser.CMD_biguplus:  "union.plus.big",
+	parser.CMD_infty:     "infinity",
+}
+
+// escapes characters that have a meaning in Typst math
+func typstEscape(s string) string {
+	if len(s) == 1 && strings.Contains(`/_^&#$\";`, s) {
+		return `\` + s
+	}
+	return s
+}
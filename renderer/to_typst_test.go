@@ -0,0 +1,78 @@
+package renderer
+
+import (
+	"testing"
+
+	parser "github.com/horriblename/mathcha/latex"
+)
+
+func TestProduceTypst(t *testing.T) {
+	// expected output for each of latexTestCases, by desc
+	expect := map[string]string{
+		"NumberLit - single digit":               "5",
+		"NumberLit - multiple digits":            "123",
+		"VarLit - single letter":                 "x",
+		"SimpleOpLit - plus sign":                "+",
+		"SimpleCmdLit - math symbol":             "×",
+		"SimpleCmdLit - greek letter":            "π",
+		"CompositeExpr - simple braces":          "x",
+		"CompositeExpr - nested":                 "a+b",
+		"SuperExpr - superscript":                "x^2",
+		"SubExpr - subscript":                    "x_1",
+		"Cmd1ArgExpr - sqrt":                     "sqrt(x)",
+		"Cmd1ArgExpr - underline":                "underline(x)",
+		"Cmd2ArgExpr - frac":                     "frac(1, 2)",
+		"Cmd2ArgExpr - binom":                    "binom(a, b)",
+		"ParenCompExpr - left right parentheses": "lr((x))",
+		"ParenCompExpr - brackets":               "lr([x])",
+		"TextContainer - text command":           `"hello"`,
+		"EnvExpr - matrix environment":           `mat(delim: "[", a, b; c, d)`,
+		"EnvExpr - single cell":                  `mat(delim: "[", x)`,
+		"Combined - simple expression":           "x+1",
+	}
+
+	latexCfg := &LatexSourceConfig{}
+	for _, tc := range latexTestCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			want, ok := expect[tc.desc]
+			if !ok {
+				t.Fatalf("no expected Typst output for LaTeX test case %q", tc.desc)
+			}
+			tree := parser.Parse(tc.input)
+			if got := ProduceTypst(tree); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+			// the output must not depend on the formatting of the LaTeX source
+			reparsed := parser.Parse(latexCfg.ProduceLatex(tree))
+			if got := ProduceTypst(reparsed); got != want {
+				t.Errorf("after LaTeX round trip: got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestProduceTypstSyntax(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect string
+	}{
+		{`\sum_{i=0}^n i`, `sum_(i=0)^n i`},
+		{`x^{n+1}`, `x^(n+1)`},
+		{`e^{\frac{1}{2}}`, `e^frac(1, 2)`},
+		{`xy\alpha`, `x y α`},
+		{`\sin x`, `sin x`},
+		{`\frac{f(a,b)}{2}`, `frac(f(a\,b), 2)`},
+		{`a/b`, `a\/b`},
+		{`\text{say "hi"}`, `"say \"hi\""`},
+		{`\left\{x\right\}`, `lr({x})`},
+		{`\begin{align}a&=1\\b&=2\end{align}`, "a & =1 \\\nb & =2"},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.input, func(t *testing.T) {
+			if got := ProduceTypst(parser.Parse(tC.input)); got != tC.expect {
+				t.Errorf("got %q, want %q", got, tC.expect)
+			}
+		})
+	}
+}
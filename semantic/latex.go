@@ -0,0 +1,311 @@
+package semantic
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/horriblename/mathcha/latex"
+)
+
+// ToLatex writes a semantic tree back as a presentational one, with as few
+// parentheses as FromLatex needs to read the same tree again. Products are
+// implicit where that is unambiguous (2x, xy, 2\sin x), quotients of Div
+// nodes are written with "/". Produce the source with e.g.
+// renderer.LatexSourceConfig.ProduceLatex
+func ToLatex(n Node) *latex.UnboundCompExpr {
+	w := latexWriter{}
+	w.node(n, precRelation)
+	return &latex.UnboundCompExpr{Elts: w.nodes}
+}
+
+// precedences, from the loosest binding
+const (
+	precRelation = iota
+	precSum
+	precNeg
+	precProduct
+	precPower
+	precAtom
+)
+
+func prec(n Node) int {
+	switch n := n.(type) {
+	case *Relation:
+		return precRelation
+	case *Binary:
+		if n.Op == Add || n.Op == Sub {
+			return precSum
+		}
+		return precProduct
+	case *Neg:
+		return precNeg
+	case *Number:
+		if n.Value < 0 {
+			return precNeg
+		}
+	case *BigOp:
+		return precProduct
+	case *Power:
+		return precPower
+	case *Apply:
+		if n.Func == "factorial" {
+			return precPower
+		}
+		if n.Func != "sqrt" && n.Func != "abs" {
+			// a function without parentheses swallows the following factors
+			return precProduct
+		}
+	}
+	return precAtom
+}
+
+type latexWriter struct {
+	nodes []latex.Expr
+}
+
+func (w *latexWriter) add(nodes ...latex.Expr) {
+	w.nodes = append(w.nodes, nodes...)
+}
+
+func (w *latexWriter) op(source string) {
+	w.add(&latex.SimpleOpLit{Source: source})
+}
+
+func (w *latexWriter) cmd(source string) {
+	w.add(&latex.SimpleCmdLit{Source: source, Type: latex.MatchLatexCmd(source)})
+}
+
+// writes a node, in parentheses if it binds looser than min
+func (w *latexWriter) node(n Node, min int) {
+	if prec(n) < min {
+		w.op("(")
+		w.node(n, precRelation)
+		w.op(")")
+		return
+	}
+
+	switch n := n.(type) {
+	case *Number:
+		if n.Value < 0 {
+			w.op("-")
+		}
+		w.number(math.Abs(n.Value))
+	case *Var:
+		w.variable(n.Name)
+	case *Neg:
+		w.op("-")
+		w.node(n.X, precProduct)
+	case *Binary:
+		w.binary(n)
+	case *Frac:
+		w.add(&latex.Cmd2ArgExpr{Type: latex.CMD_frac, Arg1: w.group(n.Num), Arg2: w.group(n.Den)})
+	case *Power:
+		if _, ok := n.Base.(*Frac); ok {
+			// not \frac{1}{2}^2
+			w.node(n.Base, precAtom+1)
+		} else {
+			w.node(n.Base, precAtom)
+		}
+		w.script(latex.CMD_superscript, n.Exp)
+	case *Apply:
+		w.apply(n)
+	case *Binomial:
+		w.add(&latex.Cmd2ArgExpr{Type: latex.CMD_binom, Arg1: w.group(n.N), Arg2: w.group(n.K)})
+	case *BigOp:
+		if n.Op == Prod {
+			w.cmd(`\prod`)
+		} else {
+			w.cmd(`\sum`)
+		}
+		w.script(latex.CMD_subscript, &Relation{Ops: []string{"="}, Args: []Node{&Var{Name: n.Var}, n.From}})
+		w.script(latex.CMD_superscript, n.To)
+		w.node(n.Body, precProduct)
+	case *Matrix:
+		env := &latex.EnvExpr{Name: latex.ENV_matrix}
+		for _, row := range n.Rows {
+			cells := make([]*latex.UnboundCompExpr, len(row))
+			for i, cell := range row {
+				cells[i] = ToLatex(cell)
+			}
+			env.Elts = append(env.Elts, cells)
+		}
+		w.add(env)
+	case *Relation:
+		for i, arg := range n.Args {
+			if i > 0 {
+				if op := n.Ops[i-1]; op[0] == '\\' {
+					w.cmd(op)
+				} else {
+					w.op(op)
+				}
+			}
+			w.node(arg, precSum)
+		}
+	}
+}
+
+// a number with digits, without an exponent
+func (w *latexWriter) number(value float64) {
+	for _, r := range strconv.FormatFloat(value, 'f', -1, 64) {
+		if r == '.' {
+			w.op(".")
+		} else {
+			w.add(&latex.NumberLit{Source: string(r)})
+		}
+	}
+}
+
+func (w *latexWriter) variable(name string) {
+	base, sub := name, ""
+	if i := strings.IndexByte(name, '_'); i > 0 {
+		base, sub = name[:i], name[i:]
+	}
+	if latex.MatchLatexCmd(`\` + base).IsGreek() {
+		base = `\` + base
+	}
+	w.add(latex.Parse(base + sub).Elts...)
+}
+
+// the argument of a command or a script
+func (w *latexWriter) group(n Node) *latex.CompositeExpr {
+	inner := latexWriter{}
+	inner.node(n, precRelation)
+	return &latex.CompositeExpr{Elts: inner.nodes}
+}
+
+func (w *latexWriter) script(cmd latex.LatexCmd, n Node) {
+	w.add(&latex.Cmd1ArgExpr{Type: cmd, Arg1: w.group(n)})
+}
+
+func (w *latexWriter) binary(n *Binary) {
+	switch n.Op {
+	case Add, Sub:
+		w.node(n.X, precSum)
+		w.op(n.Op.String())
+		// a - (b + c), a + (-b)
+		w.node(n.Y, precProduct)
+	case Div:
+		w.node(n.X, precProduct)
+		w.op("/")
+		w.node(n.Y, precPower)
+	case Mul:
+		if _, ok := rightmost(n.X).(*BigOp); ok {
+			// the summand would extend over y
+			w.node(n.X, precAtom)
+		} else {
+			w.node(n.X, precProduct)
+		}
+		if !implicitProduct(n.X, n.Y) {
+			w.cmd(`\cdot`)
+		}
+		// a \cdot (-b), a(b/c)
+		if y, ok := n.Y.(*Binary); ok && y.Op == Div {
+			w.node(n.Y, precAtom)
+		} else {
+			w.node(n.Y, precProduct)
+		}
+	}
+}
+
+// whether x and y can be written next to each other, e.g. 2x but not 2 \cdot 3
+// or \sin x \cdot y
+func implicitProduct(x, y Node) bool {
+	switch r := rightmost(x).(type) {
+	case *Apply:
+		if swallows(r) {
+			return false
+		}
+	case *BigOp:
+		return false
+	}
+	if b, ok := x.(*Binary); ok && b.Op == Div {
+		return false
+	}
+	switch l := leftmost(y).(type) {
+	case *Var:
+		return true
+	case *Apply:
+		return l.Func != "factorial"
+	}
+	// in parentheses
+	return prec(y) < precProduct
+}
+
+// the leaf written last, or a node that swallows what follows it
+func rightmost(n Node) Node {
+	switch n := n.(type) {
+	case *Binary:
+		if n.Op == Mul || n.Op == Div {
+			return rightmost(n.Y)
+		}
+	case *Neg:
+		return rightmost(n.X)
+	}
+	return n
+}
+
+// whether a function application would take the following factors as its
+// argument, as in \sin x y
+func swallows(n *Apply) bool {
+	return prec(n) == precProduct && bareArg(n.Arg)
+}
+
+// the leaf written first
+func leftmost(n Node) Node {
+	switch n := n.(type) {
+	case *Binary:
+		if n.Op == Mul || n.Op == Div {
+			return leftmost(n.X)
+		}
+	case *Power:
+		return leftmost(n.Base)
+	case *Apply:
+		if n.Func == "factorial" {
+			return leftmost(n.Arg)
+		}
+	}
+	return n
+}
+
+func (w *latexWriter) apply(n *Apply) {
+	switch n.Func {
+	case "sqrt":
+		w.add(&latex.Cmd1ArgExpr{Type: latex.CMD_sqrt, Arg1: w.group(n.Arg)})
+		return
+	case "abs":
+		w.op("|")
+		w.node(n.Arg, precRelation)
+		w.op("|")
+		return
+	case "factorial":
+		w.node(n.Arg, precAtom)
+		w.op("!")
+		return
+	}
+
+	w.cmd(`\` + n.Func)
+	if n.Base != nil {
+		w.script(latex.CMD_subscript, n.Base)
+	}
+	if bareArg(n.Arg) {
+		w.node(n.Arg, precAtom)
+	} else {
+		w.op("(")
+		w.node(n.Arg, precRelation)
+		w.op(")")
+	}
+}
+
+// whether the argument of a function is written without parentheses, as in
+// \sin x. \sin 2x would be read back as \sin(2x) as well, but is easily
+// misread
+func bareArg(n Node) bool {
+	switch n := n.(type) {
+	case *Var:
+		return true
+	case *Number:
+		return n.Value >= 0
+	}
+	return false
+}
@@ -0,0 +1,676 @@
+package semantic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/horriblename/mathcha/latex"
+)
+
+// interprets a list of siblings by recursive descent:
+//
+//	relation = expr {relop expr}
+//	expr     = term {("+" | "-") term}
+//	term     = unary {["*" | "/"] unary}
+//	unary    = ("+" | "-") unary | power
+//	power    = primary {"^" arg | "!"}
+type parser struct {
+	nodes []latex.Expr
+	pos   int
+}
+
+func (p *parser) peek() latex.Expr {
+	if p.pos < len(p.nodes) {
+		return p.nodes[p.pos]
+	}
+	return nil
+}
+
+// whether the next node is a SimpleOpLit with one of the given sources
+func (p *parser) peekOp(ops ...string) (string, bool) {
+	op, ok := p.peek().(*latex.SimpleOpLit)
+	if !ok {
+		return "", false
+	}
+	for _, s := range ops {
+		if op.Source == s {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) peekCmd() latex.LatexCmd {
+	if cmd, ok := p.peek().(*latex.SimpleCmdLit); ok {
+		return cmd.Type
+	}
+	return latex.CMD_UNKNOWN
+}
+
+// the next node if it is a superscript or subscript, nil otherwise
+func (p *parser) peekScript(cmd latex.LatexCmd) *latex.Cmd1ArgExpr {
+	if script, ok := p.peek().(*latex.Cmd1ArgExpr); ok && script.Type == cmd {
+		return script
+	}
+	return nil
+}
+
+func (p *parser) errorf(node latex.Expr, format string, args ...interface{}) error {
+	return &Error{Node: node, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) unexpected() error {
+	return p.errorf(p.peek(), "unexpected %s", describe(p.peek()))
+}
+
+// interprets a group, e.g. the argument of a command
+func group(node latex.Expr) (Node, error) {
+	nodes := []latex.Expr{node}
+	if c, ok := node.(latex.FlexContainer); ok {
+		nodes = c.Children()
+	}
+	return seq(nodes, node)
+}
+
+// interprets a list of siblings without relations; parent is the node
+// reported if the list is empty
+func seq(nodes []latex.Expr, parent latex.Expr) (Node, error) {
+	if len(nodes) == 0 {
+		return nil, &Error{Node: parent, Msg: "missing operand"}
+	}
+	p := parser{nodes: nodes}
+	n, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(nodes) {
+		return nil, p.unexpected()
+	}
+	return n, nil
+}
+
+// the relation the next node stands for, if any, e.g. "\le"
+func (p *parser) peekRelation() (string, bool) {
+	if op, ok := p.peekOp("=", "<", ">"); ok {
+		return op, true
+	}
+	if cmd := p.peekCmd(); cmd.IsRelation() {
+		return strings.TrimSpace(cmd.GetCmd()), true
+	}
+	return "", false
+}
+
+func (p *parser) relation() (Node, error) {
+	x, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	rel := &Relation{Args: []Node{x}}
+	for {
+		op, ok := p.peekRelation()
+		if !ok {
+			break
+		}
+		p.pos++
+		y, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		rel.Ops = append(rel.Ops, op)
+		rel.Args = append(rel.Args, y)
+	}
+	if len(rel.Ops) == 0 {
+		return x, nil
+	}
+	return rel, nil
+}
+
+func (p *parser) expr() (Node, error) {
+	sum, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.peekOp("+", "-")
+		if !ok {
+			return sum, nil
+		}
+		p.pos++
+		x, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		if op == "+" {
+			sum = &Binary{Op: Add, X: sum, Y: x}
+		} else {
+			sum = &Binary{Op: Sub, X: sum, Y: x}
+		}
+	}
+}
+
+func (p *parser) term() (Node, error) {
+	start := p.pos
+	product, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, implicit := Mul, false
+		divisor := p.peek()
+		switch cmd := p.peekCmd(); {
+		case cmd == latex.CMD_cdot || cmd == latex.CMD_times || cmd == latex.CMD_ast:
+			p.pos++
+		case cmd == latex.CMD_div:
+			p.pos++
+			op = Div
+		default:
+			if s, ok := p.peekOp("*", "/"); ok {
+				p.pos++
+				if s == "/" {
+					op = Div
+				}
+			} else if p.startsFactor() {
+				implicit = true
+			} else {
+				return product, nil
+			}
+		}
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if op == Div && p.startsFactor() {
+			// up to the end of the next factor, e.g. a/bc
+			p.power()
+			return nil, p.errorf(divisor, "ambiguous division %s, use \\frac or parentheses", describe(p.nodes[start:p.pos]...))
+		}
+		product = &Binary{Op: op, X: product, Y: x, Implicit: implicit}
+	}
+}
+
+// whether the next node starts a factor, multiplied implicitly with the
+// preceding one
+func (p *parser) startsFactor() bool {
+	switch n := p.peek().(type) {
+	case nil:
+		return false
+	case *latex.SimpleOpLit:
+		return n.Source == "(" || n.Source == "[" || n.Source == "|"
+	case *latex.SimpleCmdLit:
+		return !n.Type.IsRelation() && !n.Type.IsBinaryOperator()
+	case *latex.Cmd1ArgExpr:
+		return n.Type != latex.CMD_superscript && n.Type != latex.CMD_subscript
+	}
+	return true
+}
+
+func (p *parser) unary() (Node, error) {
+	if op, ok := p.peekOp("+", "-"); ok {
+		p.pos++
+		x, err := p.unary()
+		if err != nil || op == "+" {
+			return x, err
+		}
+		return &Neg{X: x}, nil
+	}
+	return p.power()
+}
+
+func (p *parser) power() (Node, error) {
+	base, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if script := p.peekScript(latex.CMD_superscript); script != nil {
+			p.pos++
+			if p.peekScript(latex.CMD_superscript) != nil {
+				return nil, p.errorf(p.peek(), "double superscript, use braces to nest them")
+			}
+			exponent, err := group(script.Arg1)
+			if err != nil {
+				return nil, err
+			}
+			base = &Power{Base: base, Exp: exponent}
+		} else if _, ok := p.peekOp("!"); ok {
+			p.pos++
+			base = &Apply{Func: "factorial", Arg: base}
+		} else {
+			return base, nil
+		}
+	}
+}
+
+func (p *parser) primary() (Node, error) {
+	node := p.peek()
+	switch n := node.(type) {
+	case nil:
+		if p.pos > 0 {
+			return nil, p.errorf(p.nodes[p.pos-1], "missing operand after %s", describe(p.nodes[p.pos-1]))
+		}
+		return nil, p.errorf(nil, "missing operand")
+	case *latex.NumberLit:
+		return p.number()
+	case *latex.VarLit:
+		name, _ := p.varName()
+		return &Var{Name: name}, nil
+	case *latex.SimpleCmdLit:
+		switch {
+		case functionNames[n.Type] != "":
+			return p.function()
+		case n.Type == latex.CMD_sum || n.Type == latex.CMD_prod:
+			return p.bigOperator()
+		case n.Type.IsGreek():
+			name, _ := p.varName()
+			return &Var{Name: name}, nil
+		}
+	case *latex.SimpleOpLit:
+		switch n.Source {
+		case "(":
+			return p.enclosed("(", ")")
+		case "[":
+			return p.enclosed("[", "]")
+		case "|":
+			x, err := p.enclosed("|", "|")
+			if err != nil {
+				return nil, err
+			}
+			return &Apply{Func: "abs", Arg: x}, nil
+		}
+	case *latex.CompositeExpr:
+		p.pos++
+		return seq(n.Elts, n)
+	case *latex.ParenCompExpr:
+		p.pos++
+		return seq(n.Elts, n)
+	case *latex.Cmd1ArgExpr:
+		if n.Type == latex.CMD_sqrt {
+			p.pos++
+			x, err := group(n.Arg1)
+			if err != nil {
+				return nil, err
+			}
+			return &Apply{Func: "sqrt", Arg: x}, nil
+		}
+	case *latex.Cmd2ArgExpr:
+		if n.Type != latex.CMD_frac && n.Type != latex.CMD_binom {
+			break
+		}
+		p.pos++
+		a, err := group(n.Arg1)
+		if err != nil {
+			return nil, err
+		}
+		b, err := group(n.Arg2)
+		if err != nil {
+			return nil, err
+		}
+		if n.Type == latex.CMD_binom {
+			return &Binomial{N: a, K: b}, nil
+		}
+		return &Frac{Num: a, Den: b}, nil
+	case *latex.EnvExpr:
+		if n.Name == latex.ENV_matrix {
+			p.pos++
+			return matrix(n)
+		}
+	}
+	return nil, p.errorf(node, "cannot interpret %s", describe(node))
+}
+
+func matrix(env *latex.EnvExpr) (Node, error) {
+	m := &Matrix{}
+	for _, row := range env.Elts {
+		if len(row) != len(env.Elts[0]) {
+			return nil, &Error{Node: env, Msg: "the rows of the matrix have different lengths"}
+		}
+		cells := make([]Node, len(row))
+		for i, cell := range row {
+			x, err := seq(cell.Elts, cell)
+			if err != nil {
+				return nil, err
+			}
+			cells[i] = x
+		}
+		m.Rows = append(m.Rows, cells)
+	}
+	return m, nil
+}
+
+// digits, optionally with a decimal point, e.g. 3.14
+func (p *parser) number() (Node, error) {
+	var digits strings.Builder
+	for ; p.pos < len(p.nodes); p.pos++ {
+		if n, ok := p.nodes[p.pos].(*latex.NumberLit); ok {
+			digits.WriteString(n.Source)
+			continue
+		}
+		// a point followed by a digit
+		if _, ok := p.peekOp("."); ok && p.pos+1 < len(p.nodes) {
+			if _, ok := p.nodes[p.pos+1].(*latex.NumberLit); ok && !strings.Contains(digits.String(), ".") {
+				digits.WriteByte('.')
+				continue
+			}
+		}
+		break
+	}
+	value, err := strconv.ParseFloat(digits.String(), 64)
+	if err != nil {
+		return nil, err
+	}
+	return &Number{Value: value}, nil
+}
+
+// consumes a variable, with its subscript, and returns its name, see Var
+func (p *parser) varName() (string, bool) {
+	var name string
+	switch n := p.peek().(type) {
+	case *latex.VarLit:
+		name = n.Source
+	case *latex.SimpleCmdLit:
+		if !n.Type.IsGreek() {
+			return "", false
+		}
+		name = strings.TrimPrefix(n.Type.GetCmd(), `\`)
+	default:
+		return "", false
+	}
+	p.pos++
+	if script := p.peekScript(latex.CMD_subscript); script != nil {
+		p.pos++
+		name += describe(script)
+	}
+	return name, true
+}
+
+// an expression between two delimiters, e.g. (a+b) or |x|
+func (p *parser) enclosed(open, close string) (Node, error) {
+	depth := 0
+	for i := p.pos + 1; i < len(p.nodes); i++ {
+		op, ok := p.nodes[i].(*latex.SimpleOpLit)
+		switch {
+		case !ok:
+		case op.Source == close && depth == 0:
+			x, err := seq(p.nodes[p.pos+1:i], p.nodes[p.pos])
+			p.pos = i + 1
+			return x, err
+		case op.Source == close:
+			depth--
+		case op.Source == open:
+			depth++
+		}
+	}
+	return nil, p.errorf(p.peek(), "unmatched %s", open)
+}
+
+// the functions, by the canonical names of their commands
+var functionNames = map[latex.LatexCmd]string{
+	latex.CMD_sin:     "sin",
+	latex.CMD_cos:     "cos",
+	latex.CMD_tan:     "tan",
+	latex.CMD_sec:     "sec",
+	latex.CMD_csc:     "csc",
+	latex.CMD_cosec:   "csc",
+	latex.CMD_cot:     "cot",
+	latex.CMD_cotan:   "cot",
+	latex.CMD_sinh:    "sinh",
+	latex.CMD_cosh:    "cosh",
+	latex.CMD_tanh:    "tanh",
+	latex.CMD_coth:    "coth",
+	latex.CMD_arcsin:  "arcsin",
+	latex.CMD_arccos:  "arccos",
+	latex.CMD_arctan:  "arctan",
+	latex.CMD_arcsinh: "arcsinh",
+	latex.CMD_arccosh: "arccosh",
+	latex.CMD_arctanh: "arctanh",
+	latex.CMD_ln:      "ln",
+	latex.CMD_lg:      "lg",
+	latex.CMD_log:     "log",
+}
+
+// the inverses of the functions, for \sin^{-1} x and the like
+var inverseFunctions = map[string]string{
+	"sin":  "arcsin",
+	"cos":  "arccos",
+	"tan":  "arctan",
+	"sinh": "arcsinh",
+	"cosh": "arccosh",
+	"tanh": "arctanh",
+}
+
+// a function applied to the following factors, e.g. \sin 2x, \sin^2(x) or
+// \log_2 8. A power of -1 is the inverse function, e.g. \sin^{-1} x is
+// \arcsin x; it is ambiguous for functions without an inverse here
+func (p *parser) function() (Node, error) {
+	cmd := p.peek()
+	apply := &Apply{Func: functionNames[p.peekCmd()]}
+	p.pos++
+
+	if script := p.peekScript(latex.CMD_subscript); script != nil && apply.Func == "log" {
+		p.pos++
+		base, err := group(script.Arg1)
+		if err != nil {
+			return nil, err
+		}
+		apply.Base = base
+	}
+	var exponent Node
+	if script := p.peekScript(latex.CMD_superscript); script != nil {
+		p.pos++
+		var err error
+		if exponent, err = group(script.Arg1); err != nil {
+			return nil, err
+		}
+		if isMinusOne(exponent) {
+			inverse, ok := inverseFunctions[apply.Func]
+			if !ok {
+				return nil, p.errorf(script, "ambiguous %s^{-1}, write \\frac{1}{%s x} for the reciprocal", describe(cmd), describe(cmd))
+			}
+			apply.Func, exponent = inverse, nil
+		}
+	}
+
+	var err error
+	if _, ok := p.peekOp("("); ok {
+		apply.Arg, err = p.enclosed("(", ")")
+	} else {
+		apply.Arg, err = p.argument()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if exponent != nil {
+		return &Power{Base: apply, Exp: exponent}, nil
+	}
+	return apply, nil
+}
+
+func isMinusOne(n Node) bool {
+	neg, ok := n.(*Neg)
+	if !ok {
+		return false
+	}
+	one, ok := neg.X.(*Number)
+	return ok && one.Value == 1
+}
+
+// the factors a function without parentheses applies to
+func (p *parser) argument() (Node, error) {
+	x, err := p.power()
+	if err != nil {
+		return nil, err
+	}
+	for p.startsFactor() && !p.startsFunction() {
+		y, err := p.power()
+		if err != nil {
+			return nil, err
+		}
+		x = &Binary{Op: Mul, X: x, Y: y, Implicit: true}
+	}
+	return x, nil
+}
+
+func (p *parser) startsFunction() bool {
+	cmd := p.peekCmd()
+	return functionNames[cmd] != "" || cmd == latex.CMD_sum || cmd == latex.CMD_prod
+}
+
+// \sum or \prod, e.g. \sum_{i=1}^{n} i^2. The summand extends up to the next
+// "+" or "-"
+func (p *parser) bigOperator() (Node, error) {
+	node := p.peek()
+	op := &BigOp{Op: Sum}
+	if p.peekCmd() == latex.CMD_prod {
+		op.Op = Prod
+	}
+	p.pos++
+
+	var from, to latex.Expr
+	for from == nil || to == nil {
+		if script := p.peekScript(latex.CMD_subscript); script != nil && from == nil {
+			from = script.Arg1
+		} else if script := p.peekScript(latex.CMD_superscript); script != nil && to == nil {
+			to = script.Arg1
+		} else {
+			return nil, p.errorf(node, "\\sum and \\prod need both bounds, e.g. \\sum_{i=1}^{n}")
+		}
+		p.pos++
+	}
+
+	c, ok := from.(latex.FlexContainer)
+	if !ok {
+		return nil, p.errorf(from, "expected the lower bound as i=1")
+	}
+	lower := parser{nodes: c.Children()}
+	name, ok := lower.varName()
+	if _, isEq := lower.peekOp("="); !ok || !isEq {
+		return nil, p.errorf(from, "expected the lower bound as i=1")
+	}
+	op.Var = name
+
+	var err error
+	if op.From, err = seq(lower.nodes[lower.pos+1:], from); err != nil {
+		return nil, err
+	}
+	if op.To, err = group(to); err != nil {
+		return nil, err
+	}
+	if op.Body, err = p.term(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// nodes as compact LaTeX, for error messages and the names of variables,
+// e.g. "x_1" or "\frac{a}{b}"
+func describe(nodes ...latex.Expr) string {
+	if len(nodes) == 0 || nodes[0] == nil {
+		return "end of formula"
+	}
+	return compact(nodes)
+}
+
+// nodes as compact LaTeX, "" without nodes, see describe
+func compact(nodes []latex.Expr) string {
+	var b strings.Builder
+	for _, node := range nodes {
+		writeLatex(&b, node)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// writes a node as LaTeX with as few spaces and braces as possible
+func writeLatex(b *strings.Builder, node latex.Expr) {
+	switch n := node.(type) {
+	case *latex.TextContainer:
+		write(b, n.Command().GetCmd()+"{"+n.Text.BuildString()+"}")
+	case *latex.ParenCompExpr:
+		write(b, `\left`+n.Left)
+		for _, child := range n.Children() {
+			writeLatex(b, child)
+		}
+		write(b, `\right`+n.Right)
+	case *latex.EnvExpr:
+		write(b, `\begin{`+n.Name.String()+"}")
+		for i, row := range n.Elts {
+			if i > 0 {
+				write(b, `\\`)
+			}
+			for j, cell := range row {
+				if j > 0 {
+					write(b, "&")
+				}
+				writeLatex(b, cell)
+			}
+		}
+		write(b, `\end{`+n.Name.String()+"}")
+	case *latex.CompositeExpr:
+		write(b, "{")
+		for _, child := range n.Children() {
+			writeLatex(b, child)
+		}
+		write(b, "}")
+	case latex.FlexContainer:
+		for _, child := range n.Children() {
+			writeLatex(b, child)
+		}
+	case latex.CmdContainer:
+		cmd := n.Command()
+		if cmd == latex.CMD_superscript || cmd == latex.CMD_subscript {
+			write(b, cmd.GetCmd())
+			arg := compact(argChildren(n.Children()[0]))
+			if len(argChildren(n.Children()[0])) == 1 && (utf8.RuneCountInString(arg) == 1 || arg[0] == '\\') {
+				write(b, arg)
+			} else {
+				write(b, "{"+arg+"}")
+			}
+			break
+		}
+		write(b, cmd.GetCmd())
+		for _, arg := range n.Children() {
+			write(b, "{"+compact(argChildren(arg))+"}")
+		}
+	case *latex.SimpleCmdLit:
+		write(b, n.Source)
+	case *latex.UnknownCmdLit:
+		write(b, n.Source)
+	case latex.Literal:
+		write(b, n.Content())
+	}
+}
+
+// the nodes inside the braces of an argument
+func argChildren(arg latex.Expr) []latex.Expr {
+	if c, ok := arg.(latex.FlexContainer); ok {
+		return c.Children()
+	}
+	return []latex.Expr{arg}
+}
+
+// appends a piece of LaTeX, keeping a command name apart from a following
+// letter
+func write(b *strings.Builder, s string) {
+	if s == "" {
+		return
+	}
+	if r, _ := utf8.DecodeRuneInString(s); unicode.IsLetter(r) && endsWithCommandName(b.String()) {
+		b.WriteByte(' ')
+	}
+	b.WriteString(s)
+}
+
+func endsWithCommandName(s string) bool {
+	i := strings.LastIndexByte(s, '\\')
+	if i < 0 || i == len(s)-1 {
+		return false
+	}
+	for _, r := range s[i+1:] {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,298 @@
+// Package semantic interprets the presentational syntax tree of package latex
+// as mathematics: "2xy+1" becomes (+ (* (* 2 x) y) 1) rather than a flat list
+// of digits, letters and operators. Evaluation, computer algebra and exports
+// to other languages work on this tree
+package semantic
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/horriblename/mathcha/latex"
+)
+
+// Node is a node of the semantic tree: one of Number, Var, Neg, Binary, Frac,
+// Power, Apply, Binomial, BigOp, Matrix and Relation
+type Node interface {
+	// an s-expression, e.g. "(+ x 1)", for debugging and tests
+	String() string
+	node()
+}
+
+// Number is a constant written with digits, e.g. 3.14
+type Number struct {
+	Value float64
+}
+
+// Var is a variable: a letter, a greek letter without the backslash (e.g.
+// "alpha") or either with a subscript, e.g. "x_1" or "x_{10}". The constants
+// \pi and e are variables "pi" and "e"
+type Var struct {
+	Name string
+}
+
+// Neg is a negation, e.g. -x
+type Neg struct {
+	X Node
+}
+
+type BinaryOp int
+
+const (
+	Add BinaryOp = iota
+	Sub
+	Mul
+	Div // a/b, \div; \frac is a Frac
+)
+
+var binaryOpNames = [...]string{
+	Add: "+",
+	Sub: "-",
+	Mul: "*",
+	Div: "/",
+}
+
+func (op BinaryOp) String() string { return binaryOpNames[op] }
+
+// Binary is an arithmetic operation, e.g. a+b
+type Binary struct {
+	Op   BinaryOp
+	X, Y Node
+	// a product written without an operator, e.g. 2x
+	Implicit bool
+}
+
+// Frac is a \frac
+type Frac struct {
+	Num, Den Node
+}
+
+// Power is a base with a superscript, e.g. x^2
+type Power struct {
+	Base, Exp Node
+}
+
+// Apply is a function applied to an argument, e.g. \sin x. Func is the name of
+// the function's command without the backslash, spelled the canonical way
+// (e.g. "csc" for \cosec), or one of "sqrt", "abs" (|x|) and "factorial" (x!).
+// Base is the base of a logarithm, e.g. 2 for \log_2 x, or nil
+type Apply struct {
+	Func string
+	Base Node
+	Arg  Node
+}
+
+// Binomial is a \binom
+type Binomial struct {
+	N, K Node
+}
+
+type BigOpKind int
+
+const (
+	Sum  BigOpKind = iota // \sum
+	Prod                  // \prod
+)
+
+// BigOp is a sum or product over an integer range, e.g.
+// \sum_{i=1}^{n} i^2 with Var "i"
+type BigOp struct {
+	Op       BigOpKind
+	Var      string
+	From, To Node
+	Body     Node
+}
+
+// Matrix is a matrix environment, e.g.
+// \begin{matrix} a & b \\ c & d \end{matrix}. All rows have the same length
+type Matrix struct {
+	Rows [][]Node
+}
+
+// Relation is a chain of relations, e.g. a < b \le c with Ops "<" and "\le".
+// The Ops are written as in LaTeX, commands the canonical way (e.g. "\le" for
+// \leq)
+type Relation struct {
+	Ops  []string
+	Args []Node
+}
+
+func (*Number) node()   {}
+func (*Var) node()      {}
+func (*Neg) node()      {}
+func (*Binary) node()   {}
+func (*Frac) node()     {}
+func (*Power) node()    {}
+func (*Apply) node()    {}
+func (*Binomial) node() {}
+func (*BigOp) node()    {}
+func (*Matrix) node()   {}
+func (*Relation) node() {}
+
+func (n *Number) String() string { return strconv.FormatFloat(n.Value, 'g', -1, 64) }
+func (n *Var) String() string    { return n.Name }
+func (n *Neg) String() string    { return "(neg " + n.X.String() + ")" }
+func (n *Binary) String() string {
+	return "(" + n.Op.String() + " " + n.X.String() + " " + n.Y.String() + ")"
+}
+func (n *Frac) String() string  { return "(frac " + n.Num.String() + " " + n.Den.String() + ")" }
+func (n *Power) String() string { return "(^ " + n.Base.String() + " " + n.Exp.String() + ")" }
+func (n *Apply) String() string {
+	if n.Base != nil {
+		return "(" + n.Func + "_" + n.Base.String() + " " + n.Arg.String() + ")"
+	}
+	return "(" + n.Func + " " + n.Arg.String() + ")"
+}
+func (n *Binomial) String() string { return "(binom " + n.N.String() + " " + n.K.String() + ")" }
+func (n *BigOp) String() string {
+	op := "sum"
+	if n.Op == Prod {
+		op = "prod"
+	}
+	return fmt.Sprintf("(%s %s %s %s %s)", op, n.Var, n.From, n.To, n.Body)
+}
+func (n *Matrix) String() string {
+	rows := make([]string, len(n.Rows))
+	for i, row := range n.Rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			cells[j] = cell.String()
+		}
+		rows[i] = "(" + strings.Join(cells, " ") + ")"
+	}
+	return "(matrix " + strings.Join(rows, " ") + ")"
+}
+func (n *Relation) String() string {
+	parts := []string{n.Args[0].String()}
+	for i, op := range n.Ops {
+		parts = append(parts, op, n.Args[i+1].String())
+	}
+	return "(rel " + strings.Join(parts, " ") + ")"
+}
+
+// Error is a formula that cannot be interpreted, e.g. an unknown command or
+// an ambiguous one like a/bc. Node is the offending node, if any
+type Error struct {
+	Node latex.Expr
+	Msg  string
+}
+
+func (e *Error) Error() string { return e.Msg }
+
+// FromLatex interprets a formula, usually the root returned by latex.Parse.
+// Products are written explicitly (\cdot, \times, *) or implicitly by
+// juxtaposition, and have the same precedence as divisions. Functions like
+// \sin apply to the following factors, up to the next function or operator
+// (\sin 2x is \sin(2x)); \sum and \prod apply to everything up to the next
+// "+" or "-". \sin^{-1} x is \arcsin x, and likewise for the other
+// trigonometric and hyperbolic functions with an inverse. Relations may only
+// appear at the top level.
+//
+// Ambiguous input is an error: a division followed by an implicit product
+// (a/bc), a double superscript (x^2^3), or a power of -1 of a function
+// without an inverse (\sec^{-1} x)
+func FromLatex(node latex.Expr) (Node, error) {
+	nodes := []latex.Expr{node}
+	if c, ok := node.(latex.FlexContainer); ok {
+		nodes = c.Children()
+	}
+	p := parser{nodes: nodes}
+	if len(nodes) == 0 {
+		return nil, &Error{Msg: "missing operand"}
+	}
+	n, err := p.relation()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(nodes) {
+		return nil, p.unexpected()
+	}
+	return n, nil
+}
+
+// Parse parses and interprets a formula, see FromLatex
+func Parse(src string) (Node, error) {
+	tree, err := latex.ParseSafe(src)
+	if err != nil {
+		return nil, err
+	}
+	return FromLatex(tree)
+}
+
+// VarName returns the name of a variable written in LaTeX, e.g. "alpha" for
+// "\alpha" or "x_1" for "x_{1}". Greek letters may be written without the
+// backslash
+func VarName(src string) (string, error) {
+	src = strings.TrimSpace(src)
+	if src != "" && src[0] != '\\' && latex.MatchLatexCmd(`\`+src).IsGreek() {
+		return src, nil
+	}
+	tree, err := latex.ParseSafe(src)
+	if err != nil {
+		return "", err
+	}
+	p := parser{nodes: tree.Elts}
+	name, ok := p.varName()
+	if !ok || p.pos != len(p.nodes) {
+		return "", fmt.Errorf("%q is not a variable", src)
+	}
+	return name, nil
+}
+
+// FreeVars returns the names of the variables of a formula, sorted, without
+// the variables of \sum and \prod (unless they also appear outside of them).
+// The constants pi and e are variables too
+func FreeVars(n Node) []string {
+	seen := map[string]bool{}
+	freeVars(n, map[string]bool{}, seen)
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func freeVars(n Node, bound, seen map[string]bool) {
+	var children []Node
+	switch n := n.(type) {
+	case *Var:
+		if !bound[n.Name] {
+			seen[n.Name] = true
+		}
+	case *Neg:
+		children = []Node{n.X}
+	case *Binary:
+		children = []Node{n.X, n.Y}
+	case *Frac:
+		children = []Node{n.Num, n.Den}
+	case *Power:
+		children = []Node{n.Base, n.Exp}
+	case *Apply:
+		children = []Node{n.Arg}
+		if n.Base != nil {
+			children = append(children, n.Base)
+		}
+	case *Binomial:
+		children = []Node{n.N, n.K}
+	case *BigOp:
+		freeVars(n.From, bound, seen)
+		freeVars(n.To, bound, seen)
+		if !bound[n.Var] {
+			bound[n.Var] = true
+			defer delete(bound, n.Var)
+		}
+		children = []Node{n.Body}
+	case *Matrix:
+		for _, row := range n.Rows {
+			children = append(children, row...)
+		}
+	case *Relation:
+		children = n.Args
+	}
+	for _, child := range children {
+		freeVars(child, bound, seen)
+	}
+}
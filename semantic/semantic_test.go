@@ -0,0 +1,211 @@
+package semantic
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/horriblename/mathcha/latex"
+	"github.com/horriblename/mathcha/renderer"
+)
+
+func TestFromLatex(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect string
+	}{
+		{`2xy+1`, `(+ (* (* 2 x) y) 1)`},
+		{`1 - 2 - 3`, `(- (- 1 2) 3)`},
+		{`a + b \cdot c`, `(+ a (* b c))`},
+		{`a \div b \times c`, `(* (/ a b) c)`},
+		{`-x^2`, `(neg (^ x 2))`},
+		{`3.14r`, `(* 3.14 r)`},
+		{`2(x+1)`, `(* 2 (+ x 1))`},
+		{`\left(a+b\right)^2`, `(^ (+ a b) 2)`},
+		{`|x-1|`, `(abs (- x 1))`},
+		{`n!`, `(factorial n)`},
+		{`\frac{a}{b+1}`, `(frac a (+ b 1))`},
+		{`\sqrt{x}`, `(sqrt x)`},
+		{`\binom{n}{k}`, `(binom n k)`},
+		{`\sin 2x + 1`, `(+ (sin (* 2 x)) 1)`},
+		{`\sin x \cos x`, `(* (sin x) (cos x))`},
+		{`\sin^2(x)`, `(^ (sin x) 2)`},
+		{`\sin^{-1} x`, `(arcsin x)`},
+		{`\tanh^{-1}(2x)`, `(arctanh (* 2 x))`},
+		{`\sin^{-2} x`, `(^ (sin x) (neg 2))`},
+		{`\log_2 8`, `(log_2 8)`},
+		{`\cosec x`, `(csc x)`},
+		{`\alpha_1 + x_{10}`, `(+ alpha_1 x_{10})`},
+		{`\sum_{i=1}^{n} i^2 + 1`, `(+ (sum i 1 n (^ i 2)) 1)`},
+		{`\prod_{k=1}^{n} k`, `(prod k 1 n k)`},
+		{`\begin{matrix}a & 2b \\ c & d\end{matrix}`, `(matrix (a (* 2 b)) (c d))`},
+		{`y = 2x + 1`, `(rel y = (+ (* 2 x) 1))`},
+		{`0 < x \leq 1`, `(rel 0 < x \le 1)`},
+	}
+
+	for _, tc := range testCases {
+		got, err := FromLatex(latex.Parse(tc.input))
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", tc.input, err)
+			continue
+		}
+		if got.String() != tc.expect {
+			t.Errorf("%s: got %s, expected %s", tc.input, got, tc.expect)
+		}
+	}
+}
+
+func TestFromLatexErrors(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect string
+	}{
+		{``, `missing operand`},
+		{`a/bc`, `ambiguous division a/bc, use \frac or parentheses`},
+		{`1/2x + 1`, `ambiguous division 1/2x, use \frac or parentheses`},
+		{`a/b\frac{}{c}`, `ambiguous division a/b\frac{}{c}, use \frac or parentheses`},
+		{`x^2^3`, `double superscript, use braces to nest them`},
+		{`\sec^{-1} x`, `ambiguous \sec^{-1}, write \frac{1}{\sec x} for the reciprocal`},
+		{`1 +`, `missing operand after +`},
+		{`\frac{}{2}`, `missing operand`},
+		{`(a`, `unmatched (`},
+		{`\foo`, `cannot interpret \foo`},
+		{`a, b`, `unexpected ,`},
+		{`\sqrt{a = b}`, `unexpected =`},
+		{`\sum_{1}^{n} i`, `expected the lower bound as i=1`},
+		{`\begin{matrix}a & b \\ c\end{matrix}`, `the rows of the matrix have different lengths`},
+		{`\begin{matrix}a & \end{matrix}`, `missing operand`},
+		{`\text{if } x`, `cannot interpret \text{if }`},
+	}
+
+	for _, tc := range testCases {
+		_, err := FromLatex(latex.Parse(tc.input))
+		if err == nil || err.Error() != tc.expect {
+			t.Errorf("%s: got error %v, expected %q", tc.input, err, tc.expect)
+		}
+	}
+}
+
+func TestErrorNode(t *testing.T) {
+	tree := latex.Parse(`x + a/bc`)
+	_, err := FromLatex(tree)
+	e, ok := err.(*Error)
+	if !ok || e.Node != tree.Elts[3] {
+		t.Errorf("expected the error at the division, got %#v", err)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect string
+	}{
+		{``, `end of formula`},
+		{`x_1`, `x_1`},
+		{`x_{10}`, `x_{10}`},
+		{`x^{\alpha}`, `x^\alpha`},
+		{`x^{}`, `x^{}`},
+		{`\frac{a}{b}`, `\frac{a}{b}`},
+		{`\frac{}{c}`, `\frac{}{c}`},
+		{`\sin x`, `\sin x`},
+		{`\alpha\beta`, `\alpha\beta`},
+		{`\left(a+b\right)`, `\left(a+b\right)`},
+		{`\text{if }`, `\text{if }`},
+		{`\begin{matrix}a&b\\c&d\end{matrix}`, `\begin{matrix}a&b\\c&d\end{matrix}`},
+	}
+
+	for _, tc := range testCases {
+		if got := describe(latex.Parse(tc.input).Children()...); got != tc.expect {
+			t.Errorf("%s: got %q, expected %q", tc.input, got, tc.expect)
+		}
+	}
+}
+
+func TestVarName(t *testing.T) {
+	testCases := map[string]string{
+		`x`:        "x",
+		`\alpha`:   "alpha",
+		`alpha`:    "alpha",
+		`x_{1}`:    "x_1",
+		` x_{10} `: "x_{10}",
+		`y_{ab}`:   "y_{ab}",
+		`\beta_2`:  "beta_2",
+	}
+	for input, expect := range testCases {
+		got, err := VarName(input)
+		if err != nil || got != expect {
+			t.Errorf("%q: got %q (%v), expected %q", input, got, err, expect)
+		}
+	}
+	for _, input := range []string{`xy`, `2`, `\sin`} {
+		if _, err := VarName(input); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
+
+func TestFreeVars(t *testing.T) {
+	testCases := map[string][]string{
+		`2xy + x`:                         {"x", "y"},
+		`\sum_{i=1}^{n} i x_i`:            {"n", "x_i"},
+		`i + \sum_{i=1}^{n} i`:            {"i", "n"},
+		`y = \pi r^2`:                     {"pi", "r", "y"},
+		`\begin{matrix}a & 1\end{matrix}`: {"a"},
+		`1 + 2`:                           {},
+	}
+	for input, expect := range testCases {
+		tree, err := Parse(input)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", input, err)
+			continue
+		}
+		if got := FreeVars(tree); !reflect.DeepEqual(got, expect) {
+			t.Errorf("%s: got %q, expected %q", input, got, expect)
+		}
+	}
+}
+
+func TestToLatex(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect string
+	}{
+		{`2xy+1`, `2xy + 1`},
+		{`a - (b + c)`, `a - (b + c)`},
+		{`(a + b)(c - d)`, `(a + b)(c - d)`},
+		{`2 \cdot 3x`, `2 \cdot 3x`},
+		{`a/(bc)`, `a/(bc)`},
+		{`a/b \cdot c`, `a/b \cdot c`},
+		{`\frac{a}{b} c`, `\frac{a}{b}c`},
+		{`-(x+1)`, `-(x + 1)`},
+		{`(-x)^2`, `(-x)^2`},
+		{`(x^2)^3`, `(x^2)^3`},
+		{`\sin x \cdot y`, `\sin x \cdot y`},
+		{`\sin(x) y`, `\sin x \cdot y`},
+		{`\sin(2x)`, `\sin(2x)`},
+		{`\sin^2 x`, `(\sin x)^2`},
+		{`\sqrt{x} y`, `\sqrt{x}y`},
+		{`2\log_2 x`, `2\log_2x`},
+		{`(n+1)!`, `(n + 1)!`},
+		{`\alpha_1 x_{10}`, `\alpha_1x_{10}`},
+		{`\left(\sum_{i=1}^{n} i\right) y`, `(\sum_{i = 1}^ni) \cdot y`},
+		{`3.25 \le |x|`, `3.25 \le |x|`},
+		{`\begin{matrix}a & -b \\ c & d\end{matrix}`, "\\begin{matrix}\n  a & -b \\\\\n  c & d\n\\end{matrix}"},
+	}
+
+	for _, tc := range testCases {
+		tree, err := Parse(tc.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", tc.input, err)
+			continue
+		}
+		cfg := renderer.FormatConfig{}
+		got := cfg.Format(ToLatex(tree))
+		if got != tc.expect {
+			t.Errorf("%s: got %q, expected %q", tc.input, got, tc.expect)
+		}
+		again, err := Parse(got)
+		if err != nil || again.String() != tree.String() {
+			t.Errorf("%s: read back as %v (%v), expected %s", tc.input, again, err, tree)
+		}
+	}
+}